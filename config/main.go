@@ -0,0 +1,335 @@
+// Package config reads and validates the wakatime config file (~/.wakatime.cfg).
+// It understands the whole settings surface wakatime-cli does and keeps track of
+// line numbers so problems can be pointed at precisely.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound occurs when there is no config file at the expected path
+var ErrNotFound = errors.New("config file not found")
+
+// Entry is a single key = value line in the config file
+type Entry struct {
+	// Key is the setting name
+	Key string
+	// Value is the setting value with continuation lines joined by newlines
+	Value string
+	// Line is the 1-based line number the key is on
+	Line int
+	// ValueLines holds the line number of each line of the value
+	ValueLines []int
+	// Path is the file the entry was read from
+	Path string
+}
+
+// Section is a [heading] in the config file and the entries under it
+type Section struct {
+	// Name is the section name without brackets; keys above every heading live in ""
+	Name string
+	// Line is the 1-based line number of the heading
+	Line int
+	// Entries are the keys in the section in file order
+	Entries []*Entry
+}
+
+// Entry returns the entry for key if the section has it
+func (s *Section) Entry(key string) (*Entry, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	for _, entry := range s.Entries {
+		if entry.Key == key {
+			return entry, true
+		}
+	}
+
+	return nil, false
+}
+
+// Value returns the value of key or an empty string if it isn't set
+func (s *Section) Value(key string) string {
+	if entry, ok := s.Entry(key); ok {
+		return entry.Value
+	}
+
+	return ""
+}
+
+// File is the raw contents of a config file
+type File struct {
+	// Sections are the sections in file order
+	Sections []*Section
+}
+
+// Section returns the section with the given name or nil
+func (f *File) Section(name string) *Section {
+	for _, section := range f.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// Severity is how serious a config problem is
+type Severity string
+
+// Problem severities
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Problem is something wrong with the config file
+type Problem struct {
+	// Line is the 1-based line number of the problem or 0 when it isn't tied to a line
+	Line int
+	// Section is the section the problem is in
+	Section string
+	// Key is the setting the problem is about if there is one
+	Key string
+	// Message describes the problem
+	Message string
+	// Severity is how serious the problem is
+	Severity Severity
+	// Path is set when the problem is in a file pulled in by import_cfg rather than the main config file
+	Path string
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.Path != "" && p.Line > 0:
+		return fmt.Sprintf("%s line %d: %s", p.Path, p.Line, p.Message)
+	case p.Path != "":
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	case p.Line > 0:
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+
+	return p.Message
+}
+
+// Home returns the directory wakatime-cli keeps its config and data in which is
+// $WAKATIME_HOME when it's set and the user's home directory otherwise
+func Home() (string, error) {
+	if home := os.Getenv("WAKATIME_HOME"); home != "" {
+		return ExpandHome(home), nil
+	}
+
+	return os.UserHomeDir()
+}
+
+// DefaultPath returns where wakatime-cli looks for its config file
+func DefaultPath() (string, error) {
+	home, err := Home()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".wakatime.cfg"), nil
+}
+
+// Load reads and parses the config file at path. Problems with the contents are
+// returned alongside the config rather than as an error so callers can decide how
+// strict to be; the error is only set when the file can't be read at all.
+//
+// When the file sets import_cfg the imported file is read too and its settings win
+// over the ones in path, which is what wakatime-cli does.
+func Load(path string) (*Config, []Problem, error) {
+	file, problems, err := readFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, lint := build(file)
+	problems = append(problems, lint...)
+	sortProblems(problems)
+
+	importEntry, ok := file.Section("settings").Entry("import_cfg")
+	if !ok || importEntry.Value == "" {
+		cfg.Path = path
+		return cfg, problems, nil
+	}
+
+	importPath := ExpandHome(importEntry.Value)
+	imported, importProblems, err := readFile(importPath)
+	if err != nil {
+		// the path check in build already warns about missing files
+		if !errors.Is(err, ErrNotFound) {
+			problems = append(problems, Problem{Line: importEntry.Line, Section: "settings", Key: "import_cfg", Message: fmt.Sprintf("couldn't read %s: %v", importEntry.Value, err), Severity: SeverityError})
+		}
+		cfg.Path = path
+		return cfg, problems, nil
+	}
+
+	_, importLint := build(imported)
+	importProblems = append(importProblems, importLint...)
+	sortProblems(importProblems)
+	for i := range importProblems {
+		importProblems[i].Path = importPath
+	}
+
+	// settings come from both files but the File stays the main one since that's what gets edited
+	merged := merge(file, imported)
+	cfg, _ = build(merged)
+	cfg.File = file
+	cfg.merged = merged
+	cfg.Path = path
+	cfg.ImportPath = importPath
+
+	return cfg, append(problems, importProblems...), nil
+}
+
+// readFile reads and splits a config file, recording its path on every entry
+func readFile(path string) (*File, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	file, problems := ParseFile(string(data))
+	for _, section := range file.Sections {
+		for _, entry := range section.Entries {
+			entry.Path = path
+		}
+	}
+
+	return file, problems, nil
+}
+
+// merge returns a file with the entries of overlay layered over base
+func merge(base *File, overlay *File) *File {
+	merged := &File{}
+	for _, section := range append(append([]*Section{}, base.Sections...), overlay.Sections...) {
+		target := merged.Section(section.Name)
+		if target == nil {
+			target = &Section{Name: section.Name, Line: section.Line}
+			merged.Sections = append(merged.Sections, target)
+		}
+
+		for _, entry := range section.Entries {
+			if existing, ok := target.Entry(entry.Key); ok {
+				*existing = *entry
+				continue
+			}
+			copied := *entry
+			target.Entries = append(target.Entries, &copied)
+		}
+	}
+
+	return merged
+}
+
+// ParseFile splits config text into sections and entries. Values can continue onto
+// following lines as long as those lines are indented, which is how wakatime-cli
+// expects multi-line lists like exclude and include to be written. Values are read
+// the way ini.v1 reads them for wakatime-cli: an inline # or ; comment after
+// whitespace is dropped and a pair of surrounding quotes is removed.
+func ParseFile(text string) (*File, []Problem) {
+	file := &File{}
+	var problems []Problem
+
+	current := &Section{Name: ""}
+	file.Sections = append(file.Sections, current)
+
+	var last *Entry
+	for i, raw := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		// indented lines carry on the value of the key above them
+		if (line[0] == ' ' || line[0] == '\t') && last != nil {
+			value := cleanValue(trimmed)
+			if last.Value == "" {
+				last.Value = value
+			} else {
+				last.Value += "\n" + value
+			}
+			last.ValueLines = append(last.ValueLines, lineNo)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			if !strings.HasSuffix(trimmed, "]") {
+				problems = append(problems, Problem{Line: lineNo, Message: fmt.Sprintf("section heading %q is missing its closing ]", trimmed), Severity: SeverityError})
+				last = nil
+				continue
+			}
+
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if existing := file.Section(name); existing != nil {
+				current = existing
+			} else {
+				current = &Section{Name: name, Line: lineNo}
+				file.Sections = append(file.Sections, current)
+			}
+			last = nil
+			continue
+		}
+
+		// whichever separator comes first wins so urls after a : stay whole
+		sep := strings.IndexAny(trimmed, "=:")
+		if sep == -1 {
+			problems = append(problems, Problem{Line: lineNo, Section: current.Name, Message: fmt.Sprintf("%q isn't a section heading or a key = value pair", trimmed), Severity: SeverityError})
+			last = nil
+			continue
+		}
+
+		key := strings.TrimSpace(trimmed[:sep])
+		entry := &Entry{Key: key, Value: cleanValue(trimmed[sep+1:]), Line: lineNo}
+		if entry.Value != "" {
+			entry.ValueLines = []int{lineNo}
+		}
+
+		if previous, ok := current.Entry(key); ok {
+			problems = append(problems, Problem{Line: lineNo, Section: current.Name, Key: key, Message: fmt.Sprintf("%s is set again here; the value from line %d is ignored", key, previous.Line), Severity: SeverityWarning})
+			*previous = *entry
+			last = previous
+			continue
+		}
+
+		current.Entries = append(current.Entries, entry)
+		last = entry
+	}
+
+	return file, problems
+}
+
+// cleanValue trims a raw value, drops an inline comment and removes surrounding
+// quotes. A quoted value keeps any # or ; inside the quotes.
+func cleanValue(value string) string {
+	value = strings.TrimSpace(value)
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end != -1 {
+			rest := strings.TrimSpace(value[end+2:])
+			if rest == "" || rest[0] == '#' || rest[0] == ';' {
+				return value[1 : end+1]
+			}
+		}
+	}
+
+	for i := 1; i < len(value); i++ {
+		if (value[i] == '#' || value[i] == ';') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = strings.TrimSpace(value[:i])
+			break
+		}
+	}
+
+	return value
+}
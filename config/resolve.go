@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrVaultCmd occurs when api_key_vault_cmd fails or prints nothing
+var ErrVaultCmd = errors.New("api_key_vault_cmd failed")
+
+// vaultTimeout is how long api_key_vault_cmd gets to print the key
+var vaultTimeout = 10 * time.Second
+
+// Source is where a resolved value came from
+type Source string
+
+// Value sources from highest to lowest precedence
+const (
+	SourceNone    Source = ""
+	SourceFlag    Source = "flag"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+	SourceVault   Source = "vault"
+	SourceConfig  Source = "config"
+)
+
+// Value is a resolved setting along with where it came from
+type Value struct {
+	// Value is the setting itself
+	Value string
+	// Source is the kind of place the value came from
+	Source Source
+	// Origin names the flag, environment variable, command or file the value came from
+	Origin string
+}
+
+// String describes where the value came from
+func (v Value) String() string {
+	switch v.Source {
+	case SourceFlag:
+		return "the " + v.Origin + " flag"
+	case SourceProfile:
+		return "the " + v.Origin + " profile"
+	case SourceEnv:
+		return "$" + v.Origin
+	case SourceVault:
+		return "api_key_vault_cmd (" + v.Origin + ")"
+	case SourceConfig:
+		return v.Origin
+	}
+
+	return "nowhere"
+}
+
+// Overrides are values passed on the command line which win over everything else
+type Overrides struct {
+	APIKey string
+	APIURL string
+}
+
+// Credentials are the api key and url akami should use
+type Credentials struct {
+	APIKey Value
+	APIURL Value
+}
+
+// Resolve works out the api key and url following wakatime-cli's precedence:
+// flags, then WAKATIME_API_KEY, then the output of api_key_vault_cmd and finally
+// the config file including anything pulled in with import_cfg. cfg may be nil
+// when there is no config file. Values that can't be found anywhere are left empty.
+func Resolve(ctx context.Context, cfg *Config, overrides Overrides) (Credentials, error) {
+	var creds Credentials
+	if cfg == nil {
+		cfg = &Config{File: &File{}}
+	}
+
+	switch {
+	case overrides.APIKey != "":
+		creds.APIKey = Value{Value: overrides.APIKey, Source: SourceFlag, Origin: "--key"}
+	case os.Getenv("WAKATIME_API_KEY") != "":
+		creds.APIKey = Value{Value: os.Getenv("WAKATIME_API_KEY"), Source: SourceEnv, Origin: "WAKATIME_API_KEY"}
+	case cfg.Settings.APIKeyVaultCmd != "":
+		key, err := RunVaultCmd(ctx, cfg.Settings.APIKeyVaultCmd)
+		if err != nil {
+			return creds, err
+		}
+		creds.APIKey = Value{Value: key, Source: SourceVault, Origin: cfg.Settings.APIKeyVaultCmd}
+	case cfg.Settings.APIKey != "":
+		creds.APIKey = Value{Value: cfg.Settings.APIKey, Source: SourceConfig, Origin: cfg.Origin("settings", "api_key")}
+	}
+
+	switch {
+	case overrides.APIURL != "":
+		creds.APIURL = Value{Value: overrides.APIURL, Source: SourceFlag, Origin: "--url"}
+	case cfg.Settings.APIURL != "":
+		creds.APIURL = Value{Value: cfg.Settings.APIURL, Source: SourceConfig, Origin: cfg.Origin("settings", "api_url")}
+	}
+
+	return creds, nil
+}
+
+// RunVaultCmd runs an api_key_vault_cmd and returns what it printed. Like wakatime-cli
+// the command is split on spaces and run directly rather than through a shell.
+func RunVaultCmd(ctx context.Context, command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("%w: the command is empty", ErrVaultCmd)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, vaultTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %v: %s", ErrVaultCmd, err, msg)
+		}
+		return "", fmt.Errorf("%w: %v", ErrVaultCmd, err)
+	}
+
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s didn't print anything", ErrVaultCmd, args[0])
+	}
+
+	return key, nil
+}
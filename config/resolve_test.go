@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	for _, program := range []string{"echo", "true", "false"} {
+		if _, err := exec.LookPath(program); err != nil {
+			t.Skipf("%s isn't available: %v", program, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		cfg       string
+		env       string
+		overrides Overrides
+		key       string
+		keySource Source
+		url       string
+		urlSource Source
+		err       error
+	}{
+		{
+			name:      "flag beats everything",
+			cfg:       "[settings]\napi_key = config-key\napi_key_vault_cmd = echo vault-key\napi_url = https://config.example/api\n",
+			env:       "env-key",
+			overrides: Overrides{APIKey: "flag-key", APIURL: "https://flag.example/api"},
+			key:       "flag-key",
+			keySource: SourceFlag,
+			url:       "https://flag.example/api",
+			urlSource: SourceFlag,
+		},
+		{
+			name:      "env beats the vault and config",
+			cfg:       "[settings]\napi_key = config-key\napi_key_vault_cmd = echo vault-key\napi_url = https://config.example/api\n",
+			env:       "env-key",
+			key:       "env-key",
+			keySource: SourceEnv,
+			url:       "https://config.example/api",
+			urlSource: SourceConfig,
+		},
+		{
+			name:      "vault beats config",
+			cfg:       "[settings]\napi_key = config-key\napi_key_vault_cmd = echo vault-key\n",
+			key:       "vault-key",
+			keySource: SourceVault,
+		},
+		{
+			name:      "config",
+			cfg:       "[settings]\napi_key = config-key\napi_url = https://config.example/api\n",
+			key:       "config-key",
+			keySource: SourceConfig,
+			url:       "https://config.example/api",
+			urlSource: SourceConfig,
+		},
+		{
+			name:      "env without a config",
+			env:       "env-key",
+			key:       "env-key",
+			keySource: SourceEnv,
+		},
+		{
+			name: "nothing",
+			cfg:  "[settings]\ndebug = true\n",
+		},
+		{
+			name:      "vault isn't run when env wins",
+			cfg:       "[settings]\napi_key_vault_cmd = false\n",
+			env:       "env-key",
+			key:       "env-key",
+			keySource: SourceEnv,
+		},
+		{
+			name: "failing vault",
+			cfg:  "[settings]\napi_key = config-key\napi_key_vault_cmd = false\n",
+			err:  ErrVaultCmd,
+		},
+		{
+			name: "silent vault",
+			cfg:  "[settings]\napi_key = config-key\napi_key_vault_cmd = true\n",
+			err:  ErrVaultCmd,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WAKATIME_API_KEY", tt.env)
+
+			var cfg *Config
+			if tt.cfg != "" {
+				var problems []Problem
+				cfg, problems = Parse(tt.cfg)
+				if len(problems) > 0 {
+					t.Fatalf("config problems: %v", problems)
+				}
+			}
+
+			creds, err := Resolve(context.Background(), cfg, tt.overrides)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			if creds.APIKey.Value != tt.key || creds.APIKey.Source != tt.keySource {
+				t.Errorf("api key = %q from %q, want %q from %q", creds.APIKey.Value, creds.APIKey.Source, tt.key, tt.keySource)
+			}
+			if creds.APIURL.Value != tt.url || creds.APIURL.Source != tt.urlSource {
+				t.Errorf("api url = %q from %q, want %q from %q", creds.APIURL.Value, creds.APIURL.Source, tt.url, tt.urlSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		line     int
+		severity Severity
+		message  string
+	}{
+		{name: "bad bool", text: "[settings]\ndebug = yes please\n", line: 2, severity: SeverityError, message: "should be true or false"},
+		{name: "negative int", text: "[settings]\ntimeout = -5\n", line: 2, severity: SeverityError, message: "whole number"},
+		{name: "url without scheme", text: "[settings]\napi_url = hackatime.hackclub.com/api\n", line: 2, severity: SeverityError, message: "http(s) url"},
+		{name: "quoted url", text: "[settings]\napi_url = \"ftp://example.com\"\n", line: 2, severity: SeverityError, message: "http(s) url"},
+		{name: "bad proxy scheme", text: "[settings]\nproxy = ftp://host:21\n", line: 2, severity: SeverityError, message: "scheme"},
+		{name: "missing path", text: "[settings]\nlog_file = /does/not/exist/wakatime.log\n", line: 2, severity: SeverityWarning, message: "doesn't exist"},
+		{name: "bad regex on continuation", text: "[settings]\nexclude =\n  ^/tmp/\n  (unclosed\n", line: 4, severity: SeverityError, message: "isn't a valid regex"},
+		{name: "typo", text: "[settings]\napi_kye = waka_abc\n", line: 2, severity: SeverityWarning, message: `did you mean "api_key"`},
+		{name: "wrong section", text: "[settings]\nproject_from_git_remote = true\n", line: 2, severity: SeverityWarning, message: "belongs in [git]"},
+		{name: "deprecated", text: "[settings]\nignore =\n  ^/tmp/\n", line: 2, severity: SeverityWarning, message: "use exclude instead"},
+		{name: "above headings", text: "api_key = waka_abc\n[settings]\n", line: 1, severity: SeverityWarning, message: "above every section heading"},
+		{name: "unknown section", text: "[setings]\napi_key = waka_abc\n", line: 1, severity: SeverityWarning, message: "isn't a section"},
+		{name: "bad mapping regex", text: "[projectmap]\n(oops = akami\n", line: 2, severity: SeverityError, message: "isn't a valid regex"},
+		{name: "empty mapping", text: "[projectmap]\n^/code/akami =\n", line: 2, severity: SeverityWarning, message: "doesn't map to anything"},
+		{name: "bad backoff time", text: "[internal]\nbackoff_at = yesterday\n", line: 2, severity: SeverityWarning, message: "timestamp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := Parse(tt.text)
+			if len(problems) != 1 {
+				t.Fatalf("got %d problems, want 1: %v", len(problems), problems)
+			}
+
+			problem := problems[0]
+			if problem.Line != tt.line || problem.Severity != tt.severity || !strings.Contains(problem.Message, tt.message) {
+				t.Errorf("got %s %q on line %d, want %s containing %q on line %d", problem.Severity, problem.Message, problem.Line, tt.severity, tt.message, tt.line)
+			}
+		})
+	}
+}
+
+func TestParseValid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "empty", text: ""},
+		{name: "typical", text: "[settings]\napi_key = waka_abc\napi_url = https://hackatime.hackclub.com/api/hackatime/v1\ndebug = false\n"},
+		{name: "quoted and commented", text: "[settings]\napi_key = \"waka_abc\" ; work\napi_url = 'https://example.com/api' # self hosted\ntimeout = 30 # seconds\n"},
+		{name: "bool or regex", text: "[settings]\nhide_file_names = true\nhide_project_names =\n  ^/code/work/\n"},
+		{name: "ntlm proxy", text: "[settings]\nproxy = domain\\user:pass\n"},
+		{name: "socks proxy", text: "[settings]\nproxy = socks5://127.0.0.1:1080\n"},
+		{name: "mappings", text: "[projectmap]\n^/code/([^/]+)/ = {0}\n"},
+		{name: "backoff", text: "[internal]\nbackoff_at = 2025-03-09T10:00:00Z\nbackoff_retries = 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, problems := Parse(tt.text); len(problems) > 0 {
+				t.Errorf("unexpected problems: %v", problems)
+			}
+		})
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	cfg, problems := Parse(`[settings]
+api_key = "waka_abc" # personal
+api_url: https://hackatime.hackclub.com/api/hackatime/v1
+timeout = 30
+offline = false
+hidefilenames = true
+hide_project_names =
+    ^/code/work/
+exclude =
+    ^/tmp/
+ignore =
+    \.log$
+include = ^/tmp/keep/
+
+[git]
+project_from_git_remote = true
+
+[internal]
+backoff_at = 2025-03-09T10:00:00Z
+`)
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			t.Fatalf("unexpected error: %v", problem)
+		}
+	}
+
+	s := cfg.Settings
+	if s.APIKey != "waka_abc" {
+		t.Errorf("api key = %q", s.APIKey)
+	}
+	if s.APIURL != "https://hackatime.hackclub.com/api/hackatime/v1" {
+		t.Errorf("api url = %q", s.APIURL)
+	}
+	if s.Timeout != 30*time.Second {
+		t.Errorf("timeout = %v", s.Timeout)
+	}
+	if s.Offline {
+		t.Error("offline should be off")
+	}
+	if !s.StatusBarEnabled {
+		t.Error("status bar should default to on")
+	}
+	if !s.HideFileNames.Match("/anything") {
+		t.Error("hidefilenames = true should hide every file")
+	}
+	if !s.HideProjectNames.Match("/code/work/secret") || s.HideProjectNames.Match("/code/fun/akami") {
+		t.Error("hide_project_names should only match /code/work/")
+	}
+	if len(s.Exclude) != 2 || !s.Exclude[1].MatchString("/code/debug.log") {
+		t.Errorf("exclude should have both the exclude and ignore patterns but has %v", s.Exclude)
+	}
+	if len(s.Include) != 1 {
+		t.Errorf("include = %v", s.Include)
+	}
+	if !cfg.Git.ProjectFromGitRemote {
+		t.Error("project_from_git_remote should be on")
+	}
+	if want := time.Date(2025, 3, 9, 10, 0, 0, 0, time.UTC); !cfg.Internal.BackoffAt.Equal(want) {
+		t.Errorf("backoff at = %v, want %v", cfg.Internal.BackoffAt, want)
+	}
+}
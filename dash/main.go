@@ -0,0 +1,390 @@
+// Package dash is the interactive dashboard behind akami dash. It is a Bubble Tea
+// program that keeps today's total and the week's breakdowns up to date in the
+// background and has tabs for a day's timeline, goals and the results of akami doc,
+// so it can be left open in a terminal pane all day.
+package dash
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/goals"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// DefaultRefresh is how often the dashboard refreshes when no interval is given
+const DefaultRefresh = time.Minute
+
+// Options configures the dashboard
+type Options struct {
+	// Client is used for every request the dashboard makes
+	Client *wakatime.Client
+	// Refresh is how often today's total and the week's stats are fetched again
+	Refresh time.Duration
+	// Goals loads the goals for the goals tab; the tab is empty when it's nil
+	Goals func(ctx context.Context) ([]goals.Progress, error)
+	// Doctor runs akami doc's checks for the doctor tab; the tab is empty when it's nil
+	Doctor func(ctx context.Context) ([]doctor.Outcome, error)
+	// Now returns the current time and defaults to time.Now
+	Now func() time.Time
+}
+
+// tab is one of the dashboard's pages
+type tab int
+
+// The dashboard's tabs in the order they're shown
+const (
+	overviewTab tab = iota
+	timelineTab
+	goalsTab
+	doctorTab
+)
+
+// tabNames are the labels shown in the tab bar
+var tabNames = []string{"overview", "timeline", "goals", "doctor"}
+
+// Model is the dashboard's Bubble Tea model
+type Model struct {
+	ctx  context.Context
+	opts Options
+
+	width  int
+	height int
+	tab    tab
+
+	// overview
+	today      wakatime.StatusBarResponse
+	todayErr   error
+	week       wakatime.StatsResponse
+	weekErr    error
+	weekLoaded bool
+	updated    time.Time
+	selected   int
+
+	// project drill-down, open when project is set
+	project        string
+	projectSummary wakatime.SummariesResponse
+	projectErr     error
+	projectLoaded  bool
+
+	// timeline
+	day            time.Time
+	durations      wakatime.DurationsResponse
+	heartbeats     wakatime.HeartbeatsResponse
+	timelineErr    error
+	timelineLoaded bool
+
+	// goals
+	goals       []goals.Progress
+	goalsErr    error
+	goalsLoaded bool
+
+	// doctor
+	outcomes      []doctor.Outcome
+	doctorErr     error
+	doctorRan     bool
+	doctorRunning bool
+}
+
+// New creates a dashboard model; requests it makes are cancelled along with ctx
+func New(ctx context.Context, opts Options) Model {
+	if opts.Refresh <= 0 {
+		opts.Refresh = DefaultRefresh
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return Model{ctx: ctx, opts: opts, width: 80, height: 24, day: midnight(opts.Now())}
+}
+
+// Run shows the dashboard until it's quit or ctx is cancelled
+func Run(ctx context.Context, opts Options) error {
+	_, err := tea.NewProgram(New(ctx, opts), tea.WithContext(ctx), tea.WithAltScreen()).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// Messages carrying the results of background requests
+type (
+	tickMsg      time.Time
+	statusBarMsg struct {
+		resp wakatime.StatusBarResponse
+		err  error
+	}
+	statsMsg struct {
+		resp wakatime.StatsResponse
+		err  error
+	}
+	projectMsg struct {
+		name string
+		resp wakatime.SummariesResponse
+		err  error
+	}
+	timelineMsg struct {
+		day        time.Time
+		durations  wakatime.DurationsResponse
+		heartbeats wakatime.HeartbeatsResponse
+		err        error
+	}
+	goalsMsg struct {
+		goals []goals.Progress
+		err   error
+	}
+	doctorMsg struct {
+		outcomes []doctor.Outcome
+		err      error
+	}
+)
+
+// Init starts the first refresh
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.fetchStatusBar(), m.fetchStats(), m.tick())
+}
+
+// Update handles key presses, resizes and the results of requests
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tea.KeyPressMsg:
+		return m.key(msg.String())
+
+	case tickMsg:
+		cmds := []tea.Cmd{m.fetchStatusBar(), m.fetchStats(), m.tick()}
+		// today's timeline keeps growing so it's refreshed along with everything else
+		if m.timelineLoaded && m.day.Equal(midnight(m.opts.Now())) {
+			cmds = append(cmds, m.fetchTimeline(m.day))
+		}
+		if m.goalsLoaded {
+			cmds = append(cmds, m.fetchGoals())
+		}
+		return m, tea.Batch(cmds...)
+
+	case statusBarMsg:
+		m.todayErr = msg.err
+		if msg.err == nil {
+			m.today = msg.resp
+			m.updated = m.opts.Now()
+		}
+		return m, nil
+
+	case statsMsg:
+		m.weekErr = msg.err
+		if msg.err == nil {
+			m.week = msg.resp
+			m.weekLoaded = true
+			m.selected = min(m.selected, max(len(m.week.Data.Projects)-1, 0))
+		}
+		return m, nil
+
+	case projectMsg:
+		// ignore answers for a project that was closed before they arrived
+		if msg.name == m.project {
+			m.projectSummary, m.projectErr, m.projectLoaded = msg.resp, msg.err, true
+		}
+		return m, nil
+
+	case timelineMsg:
+		if msg.day.Equal(m.day) {
+			m.durations, m.heartbeats, m.timelineErr, m.timelineLoaded = msg.durations, msg.heartbeats, msg.err, true
+		}
+		return m, nil
+
+	case goalsMsg:
+		m.goals, m.goalsErr, m.goalsLoaded = msg.goals, msg.err, true
+		return m, nil
+
+	case doctorMsg:
+		m.outcomes, m.doctorErr, m.doctorRan, m.doctorRunning = msg.outcomes, msg.err, true, false
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// key handles a key press
+func (m Model) key(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "tab":
+		return m.switchTab((m.tab + 1) % tab(len(tabNames)))
+	case "shift+tab":
+		return m.switchTab((m.tab + tab(len(tabNames)) - 1) % tab(len(tabNames)))
+	case "1", "2", "3", "4":
+		return m.switchTab(tab(key[0] - '1'))
+	case "r":
+		return m, m.refresh()
+	}
+
+	switch m.tab {
+	case overviewTab:
+		return m.overviewKey(key)
+	case timelineTab:
+		return m.timelineKey(key)
+	}
+
+	return m, nil
+}
+
+// switchTab changes tab, loading the new tab's data the first time it's opened
+func (m Model) switchTab(t tab) (tea.Model, tea.Cmd) {
+	m.tab = t
+
+	switch {
+	case t == timelineTab && !m.timelineLoaded:
+		return m, m.fetchTimeline(m.day)
+	case t == goalsTab && !m.goalsLoaded:
+		return m, m.fetchGoals()
+	case t == doctorTab && !m.doctorRan && !m.doctorRunning:
+		m.doctorRunning = true
+		return m, m.runDoctor()
+	}
+
+	return m, nil
+}
+
+// refresh fetches everything the current tab shows again
+func (m *Model) refresh() tea.Cmd {
+	switch m.tab {
+	case timelineTab:
+		return m.fetchTimeline(m.day)
+	case goalsTab:
+		return m.fetchGoals()
+	case doctorTab:
+		if m.doctorRunning {
+			return nil
+		}
+		m.doctorRunning = true
+		return m.runDoctor()
+	}
+
+	cmds := []tea.Cmd{m.fetchStatusBar(), m.fetchStats()}
+	if m.project != "" {
+		cmds = append(cmds, m.fetchProject(m.project))
+	}
+	return tea.Batch(cmds...)
+}
+
+// overviewKey moves through the week's projects and opens or closes one
+func (m Model) overviewKey(key string) (tea.Model, tea.Cmd) {
+	projects := m.week.Data.Projects
+
+	switch key {
+	case "up", "k":
+		if m.project == "" {
+			m.selected = max(m.selected-1, 0)
+		}
+	case "down", "j":
+		if m.project == "" {
+			m.selected = min(m.selected+1, max(len(projects)-1, 0))
+		}
+	case "enter":
+		if m.project == "" && m.selected < len(projects) {
+			m.project = projects[m.selected].Name
+			m.projectLoaded = false
+			return m, m.fetchProject(m.project)
+		}
+	case "esc", "backspace":
+		m.project = ""
+	}
+
+	return m, nil
+}
+
+// timelineKey moves the timeline between days
+func (m Model) timelineKey(key string) (tea.Model, tea.Cmd) {
+	today := midnight(m.opts.Now())
+	day := m.day
+
+	switch key {
+	case "left", "h":
+		day = day.AddDate(0, 0, -1)
+	case "right", "l":
+		if day.Before(today) {
+			day = day.AddDate(0, 0, 1)
+		}
+	case "t":
+		day = today
+	}
+
+	if day.Equal(m.day) {
+		return m, nil
+	}
+
+	m.day = day
+	m.timelineLoaded = false
+	return m, m.fetchTimeline(day)
+}
+
+// tick waits for the next refresh
+func (m Model) tick() tea.Cmd {
+	return tea.Tick(m.opts.Refresh, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m Model) fetchStatusBar() tea.Cmd {
+	return func() tea.Msg {
+		resp, err := m.opts.Client.GetStatusBarContext(m.ctx)
+		return statusBarMsg{resp, err}
+	}
+}
+
+func (m Model) fetchStats() tea.Cmd {
+	return func() tea.Msg {
+		resp, err := m.opts.Client.GetStatsContext(m.ctx, wakatime.RangeLast7Days)
+		return statsMsg{resp, err}
+	}
+}
+
+func (m Model) fetchProject(name string) tea.Cmd {
+	today := midnight(m.opts.Now())
+	return func() tea.Msg {
+		resp, err := m.opts.Client.GetSummariesContext(m.ctx, today.AddDate(0, 0, -6), today, wakatime.SummariesOptions{Project: name})
+		return projectMsg{name, resp, err}
+	}
+}
+
+func (m Model) fetchTimeline(day time.Time) tea.Cmd {
+	return func() tea.Msg {
+		durations, err := m.opts.Client.GetDurationsContext(m.ctx, day, "", wakatime.SliceByNone)
+		if err != nil {
+			return timelineMsg{day: day, err: err}
+		}
+		heartbeats, err := m.opts.Client.GetHeartbeatsContext(m.ctx, day)
+		return timelineMsg{day, durations, heartbeats, err}
+	}
+}
+
+func (m Model) fetchGoals() tea.Cmd {
+	return func() tea.Msg {
+		if m.opts.Goals == nil {
+			return goalsMsg{}
+		}
+		progress, err := m.opts.Goals(m.ctx)
+		return goalsMsg{progress, err}
+	}
+}
+
+func (m Model) runDoctor() tea.Cmd {
+	return func() tea.Msg {
+		if m.opts.Doctor == nil {
+			return doctorMsg{}
+		}
+		outcomes, err := m.opts.Doctor(m.ctx)
+		return doctorMsg{outcomes, err}
+	}
+}
+
+// midnight returns the start of the day t is in
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,338 @@
+package dash
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// View draws the tab bar, the current tab and a line of key hints, cut down to fit the window
+func (m Model) View() string {
+	var body string
+	switch m.tab {
+	case timelineTab:
+		body = m.timelineView()
+	case goalsTab:
+		body = m.goalsView()
+	case doctorTab:
+		body = m.doctorView()
+	default:
+		if m.project != "" {
+			body = m.projectView()
+		} else {
+			body = m.overviewView()
+		}
+	}
+
+	lines := strings.Split(strings.TrimRight(m.header()+"\n\n"+body, "\n"), "\n")
+	footer := styles.Muted.Render(render.Truncate(m.help(), m.width))
+	if available := max(m.height-2, 1); len(lines) > available {
+		lines = lines[:available]
+	}
+	for i, line := range lines {
+		lines[i] = render.Truncate(line, m.width)
+	}
+
+	return strings.Join(lines, "\n") + "\n\n" + footer
+}
+
+// header draws the tab bar and when the data was last refreshed
+func (m Model) header() string {
+	tabs := []string{styles.Fancy.Render("🌷 akami")}
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if tab(i) == m.tab {
+			tabs = append(tabs, styles.Success.Render("["+label+"]"))
+		} else {
+			tabs = append(tabs, styles.Muted.Render(" "+label+" "))
+		}
+	}
+
+	header := strings.Join(tabs, " ")
+	if !m.updated.IsZero() {
+		header += "  " + styles.Muted.Render("updated "+m.updated.Format(time.TimeOnly))
+	}
+
+	return header
+}
+
+// help lists the keys that do something on the current tab
+func (m Model) help() string {
+	hints := []string{"tab switch", "r refresh", "q quit"}
+
+	switch {
+	case m.tab == overviewTab && m.project != "":
+		hints = append([]string{"esc back"}, hints...)
+	case m.tab == overviewTab:
+		hints = append([]string{"↑/↓ select", "enter open project"}, hints...)
+	case m.tab == timelineTab:
+		hints = append([]string{"←/→ change day", "t today"}, hints...)
+	}
+
+	return strings.Join(hints, " · ")
+}
+
+// overviewView shows today's total and the week's projects and languages
+func (m Model) overviewView() string {
+	var out strings.Builder
+
+	if m.todayErr != nil {
+		out.WriteString(styles.Bad.Render("couldn't load today: "+m.todayErr.Error()) + "\n")
+	} else {
+		out.WriteString(fmt.Sprintf("today %s\n", styles.Fancy.Render(utils.PrettyPrintTime(m.today.Data.GrandTotal.TotalSeconds))))
+	}
+
+	switch {
+	case m.weekErr != nil:
+		out.WriteString(styles.Bad.Render("couldn't load the last 7 days: "+m.weekErr.Error()) + "\n")
+		return out.String()
+	case !m.weekLoaded:
+		out.WriteString(styles.Muted.Render("loading the last 7 days...") + "\n")
+		return out.String()
+	}
+
+	week := m.week.Data
+	out.WriteString(fmt.Sprintf("last 7 days %s with a daily average of %s\n\n",
+		styles.Fancy.Render(utils.PrettyPrintTime(int(week.TotalSeconds))),
+		styles.Fancy.Render(utils.PrettyPrintTime(int(week.DailyAverage))),
+	))
+
+	// projects keep the api's order so the selection lines up with the chart
+	projects := statChart("Projects:", week.Projects, week.TotalSeconds, m.width)
+	projects.Sort = render.Unsorted
+	lines := strings.Split(strings.TrimRight(projects.String(), "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		if i-1 == m.selected {
+			lines[i] = styles.Success.Render("›") + lines[i][1:]
+		}
+	}
+	out.WriteString(strings.Join(lines, "\n") + "\n\n")
+
+	languages := statChart("Languages:", week.Languages, week.TotalSeconds, m.width)
+	languages.Top = 5
+	out.WriteString(languages.String())
+
+	return out.String()
+}
+
+// projectView shows a project's last 7 days day by day along with its languages, branches and files
+func (m Model) projectView() string {
+	var out strings.Builder
+	out.WriteString(styles.Fancy.Render(m.project) + styles.Muted.Render(" over the last 7 days") + "\n\n")
+
+	switch {
+	case m.projectErr != nil:
+		out.WriteString(styles.Bad.Render("couldn't load "+m.project+": "+m.projectErr.Error()) + "\n")
+		return out.String()
+	case !m.projectLoaded:
+		out.WriteString(styles.Muted.Render("loading...") + "\n")
+		return out.String()
+	}
+
+	var days []float64
+	var labels []string
+	for _, day := range m.projectSummary.Data {
+		days = append(days, day.GrandTotal.TotalSeconds)
+		if date, err := time.Parse(wakatime.DateFormat, day.Range.Date); err == nil {
+			labels = append(labels, date.Format("Mon")[:2])
+		}
+	}
+
+	total := m.projectSummary.Total()
+	out.WriteString(fmt.Sprintf("  %s  %s total\n", styles.Success.Render(spread(render.Spark(days))), styles.Fancy.Render(utils.PrettyPrintTime(int(total.GrandTotal.TotalSeconds)))))
+	if len(labels) == len(days) {
+		out.WriteString("  " + styles.Muted.Render(strings.Join(labels, "")) + "\n")
+	}
+	out.WriteString("\n")
+
+	for _, section := range []struct {
+		title string
+		items []wakatime.StatItem
+	}{
+		{"Languages:", total.Languages},
+		{"Branches:", total.Branches},
+		{"Files:", total.Entities},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		chart := statChart(section.title, section.items, total.GrandTotal.TotalSeconds, m.width)
+		chart.Top = 5
+		out.WriteString(chart.String() + "\n")
+	}
+
+	return out.String()
+}
+
+// timelineView shows the day's durations per project and its heartbeats
+func (m Model) timelineView() string {
+	var out strings.Builder
+	out.WriteString(styles.Fancy.Render(m.day.Format("Mon Jan 2 2006")))
+
+	switch {
+	case m.timelineErr != nil:
+		out.WriteString("\n\n" + styles.Bad.Render("couldn't load the timeline: "+m.timelineErr.Error()) + "\n")
+		return out.String()
+	case !m.timelineLoaded:
+		out.WriteString("\n\n" + styles.Muted.Render("loading...") + "\n")
+		return out.String()
+	}
+
+	totals := map[string]float64{}
+	spans := map[string][]render.Span{}
+	var total float64
+	for _, d := range m.durations.Data {
+		totals[d.Project] += d.Duration
+		spans[d.Project] = append(spans[d.Project], render.Span{Start: d.Start(), End: d.End()})
+		total += d.Duration
+	}
+
+	projects := make([]string, 0, len(totals))
+	for name := range totals {
+		projects = append(projects, name)
+	}
+	sort.Slice(projects, func(i, j int) bool { return totals[projects[i]] > totals[projects[j]] })
+
+	out.WriteString(styles.Muted.Render(fmt.Sprintf(" · %s coded · %d heartbeats", utils.PrettyPrintTime(int(total)), len(m.heartbeats.Data))) + "\n\n")
+
+	if len(projects) == 0 && len(m.heartbeats.Data) == 0 {
+		out.WriteString("🌱 nothing was recorded that day\n")
+		return out.String()
+	}
+
+	timeline := render.Timeline{Day: m.day, Width: min(max(m.width-50, 24), 96), MarksLabel: "heartbeats"}
+	for _, name := range projects {
+		timeline.Rows = append(timeline.Rows, render.TimelineRow{Label: name, Spans: spans[name], Text: utils.PrettyPrintTime(int(totals[name]))})
+	}
+	for _, hb := range m.heartbeats.Data {
+		timeline.Marks = append(timeline.Marks, hb.At())
+	}
+	out.WriteString(timeline.String())
+
+	return out.String()
+}
+
+// goalsView shows every goal's progress this period and how the last few went
+func (m Model) goalsView() string {
+	switch {
+	case m.opts.Goals == nil:
+		return styles.Muted.Render("goals aren't available here") + "\n"
+	case m.goalsErr != nil:
+		return styles.Bad.Render("couldn't load goals: "+m.goalsErr.Error()) + "\n"
+	case !m.goalsLoaded:
+		return styles.Muted.Render("loading...") + "\n"
+	case len(m.goals) == 0:
+		return "🌱 you don't have any goals yet; add one with akami goals add\n"
+	}
+
+	progress := render.Progress{Width: m.width}
+	for _, goal := range m.goals {
+		current, ok := goal.Current()
+		if !ok {
+			continue
+		}
+
+		var history strings.Builder
+		for _, period := range goal.Periods {
+			switch period.Status {
+			case wakatime.GoalSuccess:
+				history.WriteString(styles.Success.Render("✔"))
+			case wakatime.GoalFail:
+				history.WriteString(styles.Bad.Render("✘"))
+			default:
+				history.WriteString(styles.Muted.Render("·"))
+			}
+		}
+
+		progress.Bars = append(progress.Bars, render.ProgressBar{
+			Label:  goal.Name,
+			Value:  current.Actual,
+			Target: current.Target,
+			Text:   utils.ShortTime(current.Actual) + " of " + utils.ShortTime(current.Target),
+			Suffix: history.String(),
+		})
+	}
+
+	return progress.String() + "\n" + styles.Muted.Render("history covers the last few days or weeks, oldest first") + "\n"
+}
+
+// doctorView lists the outcome of every check akami doc ran
+func (m Model) doctorView() string {
+	switch {
+	case m.doctorRunning && !m.doctorRan:
+		return styles.Muted.Render("running checks...") + "\n"
+	case m.doctorErr != nil:
+		return styles.Bad.Render("couldn't run the checks: "+m.doctorErr.Error()) + "\n"
+	case m.opts.Doctor == nil:
+		return styles.Muted.Render("checks aren't available here") + "\n"
+	}
+
+	var out strings.Builder
+	counts := map[doctor.Status]int{}
+	for _, outcome := range m.outcomes {
+		result := outcome.Result
+		counts[result.Status]++
+
+		icon := styles.Success.Render("✔")
+		switch result.Status {
+		case doctor.Warn:
+			icon = styles.Warn.Render("!")
+		case doctor.Fail:
+			icon = styles.Bad.Render("✘")
+		case doctor.Skip:
+			icon = styles.Muted.Render("-")
+		}
+
+		out.WriteString(fmt.Sprintf("%s %s", icon, outcome.Check.Description()))
+		if result.Message != "" {
+			out.WriteString(styles.Muted.Render(" — " + result.Message))
+		}
+		out.WriteString("\n")
+
+		if result.Status == doctor.Fail && result.Remediation != "" {
+			for _, line := range strings.Split(result.Remediation, "\n") {
+				out.WriteString("    " + line + "\n")
+			}
+		}
+	}
+
+	out.WriteString(fmt.Sprintf("\n%s passed, %s warnings, %s failed, %s skipped",
+		styles.Success.Render(fmt.Sprint(counts[doctor.Pass])),
+		styles.Warn.Render(fmt.Sprint(counts[doctor.Warn])),
+		styles.Bad.Render(fmt.Sprint(counts[doctor.Fail])),
+		styles.Muted.Render(fmt.Sprint(counts[doctor.Skip])),
+	))
+	if m.doctorRunning {
+		out.WriteString(styles.Muted.Render(" · running again..."))
+	}
+
+	return out.String() + "\n"
+}
+
+// statChart turns a breakdown into a horizontal chart as wide as the window
+func statChart(title string, items []wakatime.StatItem, total float64, width int) render.Chart {
+	chart := render.Chart{Title: title, Total: total, Width: width}
+	for _, item := range items {
+		chart.Items = append(chart.Items, render.Item{Label: item.Name, Value: item.TotalSeconds, Text: utils.PrettyPrintTime(int(item.TotalSeconds))})
+	}
+
+	return chart
+}
+
+// spread puts a space after every character so a sparkline lines up with two letter day names
+func spread(s string) string {
+	var out strings.Builder
+	for _, r := range s {
+		out.WriteRune(r)
+		out.WriteRune(' ')
+	}
+
+	return out.String()
+}
@@ -0,0 +1,354 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// HackatimeAPIURL is the api url a correctly configured hackatime setup uses
+const HackatimeAPIURL = "https://hackatime.hackclub.com/api/hackatime/v1"
+
+// setupURL is where users can regenerate their config
+var setupURL = "https://hackatime.hackclub.com/my/wakatime_setup"
+
+// Default returns a registry with all of akami's built in checks
+func Default() *Registry {
+	registry := NewRegistry()
+	registry.Register(
+		NewCheck("os", "Checking operating system", nil, checkOS),
+		NewCheck("config", "Checking wakatime config file", []string{"os"}, checkConfig),
+		NewCheck("config-lint", "Validating wakatime settings", []string{"config"}, checkConfigLint),
+		NewCheck("credentials", "Verifying API credentials", []string{"config"}, checkCredentials),
+		NewCheck("api-url", "Validating API URL", []string{"credentials"}, checkAPIURL),
+		NewCheck("statusbar", "Checking your coding stats for today", []string{"credentials"}, checkStatusBar),
+		NewCheck("offline-queue", "Checking wakatime-cli offline queue", nil, checkOfflineQueue),
+		NewCheck("heartbeat", "Sending test heartbeat", []string{"api-url", "statusbar"}, checkHeartbeat),
+	)
+
+	return registry
+}
+
+func checkOS(_ context.Context, env *Env) Result {
+	env.OS = runtime.GOOS
+
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		return Result{
+			Status:      Fail,
+			Message:     "couldn't find your home directory",
+			Remediation: "somehow your user doesn't exist? fairly sure this should never happen; plz report this to @krn on slack or via email at me@dunkirk.sh",
+		}
+	}
+	env.HomeDir = userDir
+
+	env.ConfigPath, err = config.DefaultPath()
+	if err != nil {
+		return Result{Status: Fail, Message: "couldn't work out where your wakatime config lives", Remediation: err.Error()}
+	}
+
+	if env.OS != "linux" && env.OS != "darwin" && env.OS != "windows" {
+		return Result{
+			Status:      Fail,
+			Message:     "unrecognized operating system " + env.OS,
+			Remediation: "hmm you don't seem to be running a recognized os? you are listed as running " + styles.Fancy.Render(env.OS) + "; can you plz report this to @krn on slack or via email at me@dunkirk.sh?",
+		}
+	}
+
+	detail := fmt.Sprintf("Looks like you are running %s so lets take a look at %s for your config", styles.Fancy.Render(env.OS), styles.Muted.Render(env.ConfigPath))
+	if home := os.Getenv("WAKATIME_HOME"); home != "" {
+		detail += fmt.Sprintf(" (since %s is set to %s)", styles.Muted.Render("WAKATIME_HOME"), styles.Muted.Render(home))
+	}
+
+	return Result{
+		Status:  Pass,
+		Message: "running " + env.OS,
+		Detail:  detail,
+	}
+}
+
+func checkConfig(_ context.Context, env *Env) Result {
+	cfg, problems, err := config.Load(env.ConfigPath)
+	if errors.Is(err, config.ErrNotFound) && env.Profile != nil {
+		return Result{Status: Pass, Message: fmt.Sprintf("no wakatime config file but the %s profile doesn't need one", env.ProfileName)}
+	} else if errors.Is(err, config.ErrNotFound) {
+		return Result{
+			Status:      Fail,
+			Message:     "no wakatime config file at " + env.ConfigPath,
+			Remediation: "you don't have a wakatime config file! go check " + styles.Muted.Render(setupURL) + " for the instructions and then try this again",
+		}
+	} else if err != nil {
+		return Result{Status: Fail, Message: "couldn't read " + env.ConfigPath, Remediation: err.Error()}
+	}
+	env.Config = cfg
+	env.ConfigProblems = problems
+
+	if cfg.File.Section("settings") == nil {
+		return Result{
+			Status:      Fail,
+			Message:     "config file has no settings section",
+			Remediation: "wow! your config file seems to be messed up and doesn't have a settings heading; can you follow the instructions at " + styles.Muted.Render(setupURL) + " to regenerate it?",
+			Fixes:       []Fix{addSectionFix("add the missing [settings] section", "settings")},
+		}
+	}
+
+	return Result{Status: Pass, Message: "found a settings section in " + env.ConfigPath}
+}
+
+func checkConfigLint(_ context.Context, env *Env) Result {
+	if env.Config == nil {
+		return Result{Status: Pass, Message: "there's no wakatime config file to lint"}
+	}
+	if len(env.ConfigProblems) == 0 {
+		return Result{Status: Pass, Message: "every setting in " + env.ConfigPath + " looks valid"}
+	}
+
+	findings := make([]Finding, 0, len(env.ConfigProblems))
+	for _, problem := range env.ConfigProblems {
+		// problems in an import_cfg file carry their own path and line in the message
+		if problem.Path != "" {
+			findings = append(findings, Finding{Message: problem.String()})
+			continue
+		}
+		findings = append(findings, Finding{Line: problem.Line, Message: problem.Message})
+	}
+
+	return Result{
+		Status:      Warn,
+		Message:     fmt.Sprintf("found %d problems in %s", len(env.ConfigProblems), env.ConfigPath),
+		Remediation: fmt.Sprintf("wakatime-cli will mostly carry on regardless but these settings won't do what you expect until they're fixed in %s", styles.Muted.Render(env.ConfigPath)),
+		Findings:    findings,
+	}
+}
+
+func checkCredentials(ctx context.Context, env *Env) Result {
+	if env.Profile != nil {
+		return checkProfileCredentials(ctx, env)
+	}
+
+	creds, err := config.Resolve(ctx, env.Config, env.Overrides)
+	if errors.Is(err, config.ErrVaultCmd) {
+		return vaultFailure(env.Config.Settings.APIKeyVaultCmd, err)
+	} else if err != nil {
+		return Result{Status: Fail, Message: "couldn't work out your api credentials", Remediation: err.Error()}
+	}
+	env.Credentials = creds
+	env.APIKey = creds.APIKey.Value
+	env.APIURL = creds.APIURL.Value
+	settings := env.Config.File.Section("settings")
+
+	// work out fixes for both settings up front so one --fix run repairs everything
+	var fixes []Fix
+	if env.APIKey == "" {
+		if stale := staleKey(settings, "api_key"); stale != "" {
+			fixes = append(fixes, renameKeyFix("rename "+stale+" to api_key", "settings", stale, "api_key"))
+		}
+	}
+	if env.APIURL == "" {
+		if stale := staleKey(settings, "api_url"); stale != "" {
+			fixes = append(fixes, renameKeyFix("rename "+stale+" to api_url", "settings", stale, "api_url"))
+		} else {
+			fixes = append(fixes, setKeyFix("set api_url to hackatime", "settings", "api_url", HackatimeAPIURL))
+		}
+	}
+
+	if env.APIKey == "" {
+		return Result{
+			Status:      Fail,
+			Message:     "no api_key in the config file",
+			Remediation: "hmm 🤔 looks like you don't have an api_key in your config file? are you sure you have followed the setup instructions at " + styles.Muted.Render(setupURL) + " correctly? if you keep your key somewhere else you can also set " + styles.Muted.Render("WAKATIME_API_KEY") + " or " + styles.Muted.Render("api_key_vault_cmd"),
+			Fixes:       fixes,
+		}
+	}
+	if env.APIURL == "" {
+		return Result{
+			Status:      Fail,
+			Message:     "no api_url in the config file",
+			Remediation: "hmm 🤔 looks like you don't have an api_url in your config file? are you sure you have followed the setup instructions at " + styles.Muted.Render(setupURL) + " correctly?",
+			Fixes:       fixes,
+		}
+	}
+
+	env.Client = wakatime.NewClientWithOptions(env.APIKey, env.APIURL)
+
+	return Result{
+		Status:  Pass,
+		Message: fmt.Sprintf("api_key from %s and api_url from %s", creds.APIKey, creds.APIURL),
+		Detail:  fmt.Sprintf("Using the api_key from %s and the api_url from %s", styles.Fancy.Render(creds.APIKey.String()), styles.Fancy.Render(creds.APIURL.String())),
+	}
+}
+
+// checkProfileCredentials resolves the credentials of an akami profile; the wakatime config isn't involved
+func checkProfileCredentials(ctx context.Context, env *Env) Result {
+	creds, err := env.Profile.Credentials(ctx, env.ProfileName, env.Overrides)
+	if errors.Is(err, config.ErrVaultCmd) {
+		return vaultFailure(env.Profile.APIKeyVaultCmd, err)
+	} else if err != nil {
+		return Result{Status: Fail, Message: "couldn't work out the profile's api credentials", Remediation: err.Error()}
+	}
+	env.Credentials = creds
+	env.APIKey = creds.APIKey.Value
+	env.APIURL = creds.APIURL.Value
+
+	if env.APIKey == "" || env.APIURL == "" {
+		return Result{
+			Status:      Fail,
+			Message:     fmt.Sprintf("the %s profile is missing its api key or url", env.ProfileName),
+			Remediation: fmt.Sprintf("profiles need both an api key and an api url; re-add it with %s", styles.Muted.Render("akami profile add "+env.ProfileName+" --url <url> --key <key> --force")),
+		}
+	}
+
+	env.Client = wakatime.NewClientWithOptions(env.APIKey, env.APIURL)
+
+	return Result{
+		Status:  Pass,
+		Message: fmt.Sprintf("api_key from %s and api_url from %s", creds.APIKey, creds.APIURL),
+	}
+}
+
+// vaultFailure reports an api_key_vault_cmd that didn't produce a key
+func vaultFailure(command string, err error) Result {
+	return Result{
+		Status:      Fail,
+		Message:     "api_key_vault_cmd didn't give us a key",
+		Remediation: fmt.Sprintf("we asked %s for your api key but it didn't work; try running it yourself and check it prints just the key\n\n%s", styles.Muted.Render(command), styles.Bad.Render("Full error: "+err.Error())),
+	}
+}
+
+// staleKey returns an outdated or misspelled key in section that holds the value for key
+func staleKey(section *config.Section, key string) string {
+	for _, entry := range section.Entries {
+		if staleKeys[entry.Key] == key && entry.Value != "" {
+			return entry.Key
+		}
+	}
+
+	return ""
+}
+
+func checkAPIURL(ctx context.Context, env *Env) Result {
+	if env.APIURL == HackatimeAPIURL {
+		return Result{Status: Pass, Message: "api url points at hackatime"}
+	}
+
+	// only offer to edit the config when that's where the url actually came from
+	editable := env.Credentials.APIURL.Source == config.SourceConfig && env.Credentials.APIURL.Origin == env.ConfigPath
+	var useHackatime, trimSlash []Fix
+	if editable {
+		useHackatime = []Fix{setKeyFix("set api_url to hackatime", "settings", "api_url", HackatimeAPIURL)}
+	}
+
+	if trimmed := strings.TrimRight(env.APIURL, "/"); trimmed != env.APIURL {
+		if editable {
+			trimSlash = []Fix{setKeyFix("remove the trailing slash from api_url", "settings", "api_url", trimmed)}
+		}
+		return Result{
+			Status:      Warn,
+			Message:     "api url " + env.APIURL + " has a trailing slash",
+			Remediation: fmt.Sprintf("Your api url %s ends with a slash which some wakatime plugins turn into a double slash; it should be %s", styles.Muted.Render(env.APIURL), styles.Muted.Render(trimmed)),
+			Fixes:       trimSlash,
+		}
+	}
+
+	// profiles point wherever they were meant to so any other backend is fine
+	if env.Profile != nil {
+		return Result{Status: Pass, Message: fmt.Sprintf("the %s profile points at %s", env.ProfileName, env.APIURL)}
+	}
+
+	if env.APIURL == wakatime.DefaultAPIURL {
+		client := wakatime.NewClient(env.APIKey)
+		_, err := client.GetStatusBarContext(ctx)
+
+		if !errors.Is(err, wakatime.ErrUnauthorized) {
+			return Result{
+				Status:      Fail,
+				Message:     "config is connected to wakatime.com instead of hackatime",
+				Fixes:       useHackatime,
+				Remediation: "turns out you were connected to wakatime.com instead of hackatime; since your key seems to work if you would like to keep syncing data to wakatime.com as well as to hackatime you can either setup a realy serve like " + styles.Muted.Render("https://github.com/JasonLovesDoggo/multitime") + " or you can wait for " + styles.Muted.Render("https://github.com/hackclub/hackatime/issues/85") + " to get merged in hackatime and have it synced there :)\n\nIf you want to import your wakatime.com data into hackatime then you can use hackatime v1 temporarily to connect your wakatime account and import (in settings under integrations at " + styles.Muted.Render("https://waka.hackclub.com") + ") and then click the import from hackatime v1 button at " + styles.Muted.Render("https://hackatime.hackclub.com/my/settings") + ".\n\n If you have more questions feel free to reach out to me (hackatime v1 creator) on slack (at @krn) or via email at me@dunkirk.sh",
+			}
+		}
+
+		return Result{
+			Status:      Fail,
+			Message:     "config uses the wakatime.com api url without a wakatime.com key",
+			Fixes:       useHackatime,
+			Remediation: "turns out your config is connected to the wrong api url and is trying to use wakatime.com to sync time but you don't have a working api key from them. Go to " + styles.Muted.Render(setupURL) + " to run the setup script and fix your config file",
+		}
+	}
+
+	return Result{
+		Status:      Warn,
+		Message:     fmt.Sprintf("api url %s doesn't match the expected url of %s", env.APIURL, HackatimeAPIURL),
+		Remediation: fmt.Sprintf("Your api url %s doesn't match the expected url of %s however if you are using a custom forwarder or are sure you know what you are doing then you are probably fine", styles.Muted.Render(env.APIURL), styles.Muted.Render(HackatimeAPIURL)),
+	}
+}
+
+func checkStatusBar(ctx context.Context, env *Env) Result {
+	duration, err := env.Client.GetStatusBarContext(ctx)
+	if errors.Is(err, wakatime.ErrUnauthorized) {
+		return Result{
+			Status:      Fail,
+			Message:     "the api rejected your api_key",
+			Remediation: "Your config file looks mostly correct and you have the correct api url but when we tested your api_key it looks like it is invalid? Can you double check if the key in your config file is the same as at " + styles.Muted.Render(setupURL) + "?",
+		}
+	} else if err != nil {
+		return Result{
+			Status:      Fail,
+			Message:     "the api returned an error",
+			Remediation: "Something weird happened with the hackatime api; if the error doesn't make sense then please contact @krn on slack or via email at me@dunkirk.sh\n\n" + styles.Bad.Render("Full error: "+err.Error()),
+		}
+	}
+
+	today := utils.PrettyPrintTime(duration.Data.GrandTotal.TotalSeconds)
+	return Result{
+		Status:  Pass,
+		Message: "coded today for " + today,
+		Detail:  fmt.Sprintf("Sweet!!! Looks like your hackatime is configured properly! Looks like you have coded today for %s", styles.Fancy.Render(today)),
+	}
+}
+
+func checkOfflineQueue(_ context.Context, _ *Env) Result {
+	offlinePath, err := wakatime.OfflineQueuePath()
+	if err != nil {
+		return Result{Status: Fail, Message: "couldn't work out where wakatime-cli keeps its offline queue", Remediation: err.Error()}
+	}
+
+	offline, err := wakatime.ReadOfflineQueue(offlinePath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(offline) == 0) {
+		return Result{Status: Pass, Message: "no heartbeats are waiting in wakatime-cli's offline queue"}
+	} else if err != nil {
+		return Result{
+			Status:      Warn,
+			Message:     "couldn't open " + offlinePath + ": " + err.Error(),
+			Remediation: fmt.Sprintf("We couldn't open wakatime-cli's offline queue at %s so we can't tell if heartbeats are stuck in it: %s", styles.Muted.Render(offlinePath), err.Error()),
+		}
+	}
+
+	oldest := time.Unix(0, int64(offline[0].Heartbeat.Time*float64(time.Second)))
+	return Result{
+		Status:      Warn,
+		Message:     fmt.Sprintf("%d heartbeats are waiting and the oldest is from %s", len(offline), oldest.Format(time.RFC3339)),
+		Remediation: fmt.Sprintf("wakatime-cli has %s heartbeats waiting to be sent and the oldest one is from %s ago; if your time isn't showing up this is probably why! Run %s to send them now", styles.Fancy.Render(fmt.Sprint(len(offline))), styles.Warn.Render(utils.PrettyPrintTime(int(time.Since(oldest).Seconds()))), styles.Muted.Render("akami offline flush")),
+	}
+}
+
+func checkHeartbeat(ctx context.Context, env *Env) Result {
+	if err := env.Client.SendHeartbeatContext(ctx, env.TestHeartbeat); err != nil {
+		return Result{
+			Status:      Fail,
+			Message:     "the test heartbeat was rejected",
+			Remediation: "oh dear; looks like something went wrong when sending that heartbeat. " + styles.Bad.Render("Full error: \""+strings.TrimSpace(err.Error())+"\""),
+		}
+	}
+
+	return Result{Status: Pass, Message: "test heartbeat was accepted"}
+}
@@ -0,0 +1,255 @@
+package doctor
+
+import (
+	"strings"
+)
+
+// Fix is a concrete edit to the wakatime config file that resolves a problem a check found.
+// Fixes work on the raw lines of the file so comments and formatting survive untouched.
+type Fix struct {
+	// Description explains what the fix changes
+	Description string
+	// Apply returns the config file lines with the fix applied
+	Apply func(lines []string) []string
+}
+
+// staleKeys maps misspelled or outdated setting names to the name wakatime-cli reads
+var staleKeys = map[string]string{
+	"apikey":    "api_key",
+	"api-key":   "api_key",
+	"api_token": "api_key",
+	"key":       "api_key",
+	"apiurl":    "api_url",
+	"api-url":   "api_url",
+	"url":       "api_url",
+}
+
+// setKeyFix sets a key in a section, adding the section and key when they're missing
+func setKeyFix(description string, section string, key string, value string) Fix {
+	return Fix{
+		Description: description,
+		Apply: func(lines []string) []string {
+			return setKey(lines, section, key, value)
+		},
+	}
+}
+
+// renameKeyFix renames a key within a section keeping its value
+func renameKeyFix(description string, section string, from string, to string) Fix {
+	return Fix{
+		Description: description,
+		Apply: func(lines []string) []string {
+			return renameKey(lines, section, from, to)
+		},
+	}
+}
+
+// addSectionFix adds a section that's missing. Keys sitting above every section
+// header are what wakatime-cli would have read from it, so the header goes above them.
+func addSectionFix(description string, section string) Fix {
+	return Fix{
+		Description: description,
+		Apply: func(lines []string) []string {
+			if start, _ := findSection(lines, section); start >= 0 {
+				return lines
+			}
+
+			for i, line := range lines {
+				trimmed := strings.TrimSpace(line)
+				if strings.HasPrefix(trimmed, "[") {
+					break
+				}
+				if _, _, ok := cutKey(trimmed); ok && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, ";") {
+					return append(append(append([]string{}, lines[:i]...), "["+section+"]"), lines[i:]...)
+				}
+			}
+
+			return appendSection(lines, section)
+		},
+	}
+}
+
+// ApplyFixes runs every fix over the config text and returns the new text
+func ApplyFixes(text string, fixes []Fix) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	for _, fix := range fixes {
+		lines = fix.Apply(lines)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// findSection returns the line range [start, end) of a section's body, or -1 if it isn't there
+func findSection(lines []string, section string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+
+		if start >= 0 {
+			return start, i
+		}
+		if strings.TrimSpace(trimmed[1:len(trimmed)-1]) == section {
+			start = i + 1
+		}
+	}
+
+	if start >= 0 {
+		return start, len(lines)
+	}
+
+	return -1, -1
+}
+
+// findKey returns the line range [start, end) of a key within a section including
+// any indented continuation lines, or -1 if it isn't there
+func findKey(lines []string, section string, key string) (int, int) {
+	start, end := findSection(lines, section)
+	if start < 0 {
+		return -1, -1
+	}
+
+	for i := start; i < end; i++ {
+		name, _, ok := cutKey(lines[i])
+		if !ok || isContinuation(lines[i]) || name != key {
+			continue
+		}
+
+		j := i + 1
+		for j < end && isContinuation(lines[j]) {
+			j++
+		}
+		return i, j
+	}
+
+	return -1, -1
+}
+
+// cutKey splits a key = value line on whichever of = or : comes first, the way
+// config.ParseFile does, and trims both halves
+func cutKey(line string) (string, string, bool) {
+	sep := strings.IndexAny(line, "=:")
+	if sep < 0 {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(line[:sep]), strings.TrimSpace(line[sep+1:]), true
+}
+
+// isContinuation reports whether a line continues the value of the key above it
+func isContinuation(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t') && strings.TrimSpace(line) != ""
+}
+
+// setKey sets key = value in section
+func setKey(lines []string, section string, key string, value string) []string {
+	entry := key + " = " + value
+
+	if start, end := findKey(lines, section, key); start >= 0 {
+		return append(append(append([]string{}, lines[:start]...), entry), lines[end:]...)
+	}
+
+	start, end := findSection(lines, section)
+	if start < 0 {
+		lines = appendSection(lines, section)
+		return append(lines, entry)
+	}
+
+	// add the key after the last non-blank line of the section
+	insert := end
+	for insert > start && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+
+	return append(append(append([]string{}, lines[:insert]...), entry), lines[insert:]...)
+}
+
+// renameKey renames a key in section, dropping it if the new name is already set
+func renameKey(lines []string, section string, from string, to string) []string {
+	start, end := findKey(lines, section, from)
+	if start < 0 {
+		return lines
+	}
+
+	if existing, _ := findKey(lines, section, to); existing >= 0 {
+		return append(append([]string{}, lines[:start]...), lines[end:]...)
+	}
+
+	renamed := append([]string{}, lines...)
+	_, value, _ := cutKey(lines[start])
+	renamed[start] = to + " = " + value
+
+	return renamed
+}
+
+// appendSection adds a section header to the end of the file
+func appendSection(lines []string, section string) []string {
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+
+	return append(lines, "["+section+"]")
+}
+
+// DiffLine is a single line of a line diff
+type DiffLine struct {
+	// Op is ' ' for unchanged lines, '-' for removed lines and '+' for added lines
+	Op byte
+	// Text is the content of the line
+	Text string
+}
+
+// Diff computes a line diff between two texts using the longest common subsequence
+func Diff(before string, after string) []DiffLine {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+	if before == "" {
+		a = nil
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{'-', a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{'+', b[j]})
+	}
+
+	return diff
+}
@@ -0,0 +1,172 @@
+package doctor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		fixes  []Fix
+		after  string
+	}{
+		{
+			name:   "set existing key",
+			before: "[settings]\napi_key = waka_abc\napi_url = https://example.com/\n",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", "https://example.com")},
+			after:  "[settings]\napi_key = waka_abc\napi_url = https://example.com\n",
+		},
+		{
+			name:   "set key written with a colon",
+			before: "[settings]\napi_url: https://example.com/\n",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", "https://example.com")},
+			after:  "[settings]\napi_url = https://example.com\n",
+		},
+		{
+			name:   "set key replaces continuation lines",
+			before: "[settings]\nexclude =\n  ^/tmp/\n  ^/var/\ndebug = false\n",
+			fixes:  []Fix{setKeyFix("", "settings", "exclude", "^/opt/")},
+			after:  "[settings]\nexclude = ^/opt/\ndebug = false\n",
+		},
+		{
+			name:   "set missing key goes after the section's last line",
+			before: "[settings]\napi_key = waka_abc\n\n[git]\nproject_from_git_remote = true\n",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", HackatimeAPIURL)},
+			after:  "[settings]\napi_key = waka_abc\napi_url = " + HackatimeAPIURL + "\n\n[git]\nproject_from_git_remote = true\n",
+		},
+		{
+			name:   "set key in missing section",
+			before: "[git]\nproject_from_git_remote = true\n",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", HackatimeAPIURL)},
+			after:  "[git]\nproject_from_git_remote = true\n\n[settings]\napi_url = " + HackatimeAPIURL + "\n",
+		},
+		{
+			name:   "set key in empty file",
+			before: "",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", HackatimeAPIURL)},
+			after:  "[settings]\napi_url = " + HackatimeAPIURL + "\n",
+		},
+		{
+			name:   "set key only in its own section",
+			before: "[projectmap]\napi_url = elsewhere\n[settings]\napi_key = waka_abc\n",
+			fixes:  []Fix{setKeyFix("", "settings", "api_url", HackatimeAPIURL)},
+			after:  "[projectmap]\napi_url = elsewhere\n[settings]\napi_key = waka_abc\napi_url = " + HackatimeAPIURL + "\n",
+		},
+		{
+			name:   "rename key",
+			before: "[settings]\n# my key\napikey = waka_abc\n",
+			fixes:  []Fix{renameKeyFix("", "settings", "apikey", "api_key")},
+			after:  "[settings]\n# my key\napi_key = waka_abc\n",
+		},
+		{
+			name:   "rename key written with a colon",
+			before: "[settings]\nurl: https://example.com\n",
+			fixes:  []Fix{renameKeyFix("", "settings", "url", "api_url")},
+			after:  "[settings]\napi_url = https://example.com\n",
+		},
+		{
+			name:   "rename drops the old key when the new one is set",
+			before: "[settings]\napi_key = waka_new\napikey = waka_old\n",
+			fixes:  []Fix{renameKeyFix("", "settings", "apikey", "api_key")},
+			after:  "[settings]\napi_key = waka_new\n",
+		},
+		{
+			name:   "rename missing key does nothing",
+			before: "[settings]\napi_key = waka_abc\n",
+			fixes:  []Fix{renameKeyFix("", "settings", "apikey", "api_key")},
+			after:  "[settings]\napi_key = waka_abc\n",
+		},
+		{
+			name:   "add section above stray keys",
+			before: "# wakatime\napi_key = waka_abc\napi_url = https://example.com\n",
+			fixes:  []Fix{addSectionFix("", "settings")},
+			after:  "# wakatime\n[settings]\napi_key = waka_abc\napi_url = https://example.com\n",
+		},
+		{
+			name:   "add section to the end",
+			before: "# nothing here\n",
+			fixes:  []Fix{addSectionFix("", "settings")},
+			after:  "# nothing here\n\n[settings]\n",
+		},
+		{
+			name:   "add existing section does nothing",
+			before: "[settings]\napi_key = waka_abc\n",
+			fixes:  []Fix{addSectionFix("", "settings")},
+			after:  "[settings]\napi_key = waka_abc\n",
+		},
+		{
+			name:   "fixes build on each other",
+			before: "apikey = waka_abc\n",
+			fixes: []Fix{
+				addSectionFix("", "settings"),
+				renameKeyFix("", "settings", "apikey", "api_key"),
+				setKeyFix("", "settings", "api_url", HackatimeAPIURL),
+			},
+			after: "[settings]\napi_key = waka_abc\napi_url = " + HackatimeAPIURL + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyFixes(tt.before, tt.fixes); got != tt.after {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []DiffLine
+	}{
+		{
+			name:   "unchanged",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   []DiffLine{{' ', "a"}, {' ', "b"}},
+		},
+		{
+			name:   "changed line",
+			before: "[settings]\napikey = x\ndebug = true\n",
+			after:  "[settings]\napi_key = x\ndebug = true\n",
+			want:   []DiffLine{{' ', "[settings]"}, {'-', "apikey = x"}, {'+', "api_key = x"}, {' ', "debug = true"}},
+		},
+		{
+			name:   "added at the end",
+			before: "a\n",
+			after:  "a\nb\nc\n",
+			want:   []DiffLine{{' ', "a"}, {'+', "b"}, {'+', "c"}},
+		},
+		{
+			name:   "added at the start",
+			before: "b\n",
+			after:  "a\nb\n",
+			want:   []DiffLine{{'+', "a"}, {' ', "b"}},
+		},
+		{
+			name:   "removed in the middle",
+			before: "a\nb\nc\n",
+			after:  "a\nc\n",
+			want:   []DiffLine{{' ', "a"}, {'-', "b"}, {' ', "c"}},
+		},
+		{
+			name:   "from nothing",
+			before: "",
+			after:  "[settings]\n",
+			want:   []DiffLine{{'+', "[settings]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Package doctor contains the diagnostics behind akami doc. Every diagnostic is a
+// Check registered in a Registry which runs them all in dependency order so a
+// single run can report every problem instead of stopping at the first one.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/profile"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// Status is the outcome of a check
+type Status string
+
+// Possible check outcomes
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Result is what a check reports back after running
+type Result struct {
+	// Status is whether the check passed, warned, failed or was skipped
+	Status Status
+	// Message is a short one line summary of the outcome
+	Message string
+	// Detail is optional extra context shown under the check
+	Detail string
+	// Remediation explains how to fix a warning or failure
+	Remediation string
+	// Fixes are config edits akami doc --fix can make to resolve the problem
+	Fixes []Fix
+	// Findings are individual problems a check found that are each worth reporting on their own
+	Findings []Finding
+}
+
+// Finding is a single problem found by a check, usually tied to a line of the config file
+type Finding struct {
+	// Line is the 1-based line number of the problem or 0 when it isn't tied to a line
+	Line int
+	// Message describes the problem
+	Message string
+}
+
+// Env is the shared state checks read from and fill in for the checks that depend on them
+type Env struct {
+	// OS is the operating system akami is running on
+	OS string
+	// HomeDir is the current user's home directory
+	HomeDir string
+	// ConfigPath is the path to the wakatime config file
+	ConfigPath string
+	// Config is the parsed wakatime config file
+	Config *config.Config
+	// ConfigProblems are the problems found while parsing the config file
+	ConfigProblems []config.Problem
+	// ProfileName is the akami profile being checked
+	ProfileName string
+	// Profile is the akami profile being checked or nil when checking the wakatime config
+	Profile *profile.Profile
+	// Overrides are the --key and --url flags which win over the config
+	Overrides config.Overrides
+	// Credentials records where the api key and url were found
+	Credentials config.Credentials
+	// APIKey is the resolved api key
+	APIKey string
+	// APIURL is the resolved api url
+	APIURL string
+	// Client is an api client built from the config
+	Client *wakatime.Client
+	// TestHeartbeat is the heartbeat sent to check that ingestion works
+	TestHeartbeat wakatime.Heartbeat
+}
+
+// Check is a single diagnostic that akami doc can run
+type Check interface {
+	// ID is the short stable identifier used by --only and --skip
+	ID() string
+	// Description is the human readable name shown while the check runs
+	Description() string
+	// Run performs the check against the shared environment
+	Run(ctx context.Context, env *Env) Result
+}
+
+// Dependent is implemented by checks that need other checks to pass before they can run
+type Dependent interface {
+	// Dependencies returns the ids of the checks that must pass first
+	Dependencies() []string
+}
+
+// Outcome pairs a check with the result it produced
+type Outcome struct {
+	Check  Check
+	Result Result
+}
+
+// Hooks lets callers follow along while checks run
+type Hooks struct {
+	// Start is called right before a check runs
+	Start func(check Check)
+	// Done is called with the result of every check including skipped ones
+	Done func(check Check, result Result)
+}
+
+// Registry holds the set of known checks
+type Registry struct {
+	checks []Check
+	byID   map[string]Check
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{byID: map[string]Check{}}
+}
+
+// Register adds checks to the registry; check ids must be unique
+func (r *Registry) Register(checks ...Check) error {
+	for _, check := range checks {
+		if _, ok := r.byID[check.ID()]; ok {
+			return fmt.Errorf("a check with the id %q is already registered", check.ID())
+		}
+
+		r.checks = append(r.checks, check)
+		r.byID[check.ID()] = check
+	}
+
+	return nil
+}
+
+// Checks returns the registered checks in dependency order
+func (r *Registry) Checks() ([]Check, error) {
+	ordered := make([]Check, 0, len(r.checks))
+	state := map[string]int{} // 1 = visiting, 2 = done
+
+	var visit func(check Check) error
+	visit = func(check Check) error {
+		switch state[check.ID()] {
+		case 1:
+			return fmt.Errorf("check %q depends on itself through its dependencies", check.ID())
+		case 2:
+			return nil
+		}
+
+		state[check.ID()] = 1
+		for _, dep := range dependencies(check) {
+			depCheck, ok := r.byID[dep]
+			if !ok {
+				return fmt.Errorf("check %q depends on %q which isn't registered", check.ID(), dep)
+			}
+			if err := visit(depCheck); err != nil {
+				return err
+			}
+		}
+		state[check.ID()] = 2
+
+		ordered = append(ordered, check)
+		return nil
+	}
+
+	for _, check := range r.checks {
+		if err := visit(check); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// IDs returns the ids of every registered check in dependency order
+func (r *Registry) IDs() []string {
+	checks, err := r.Checks()
+	if err != nil {
+		checks = r.checks
+	}
+
+	ids := make([]string, 0, len(checks))
+	for _, check := range checks {
+		ids = append(ids, check.ID())
+	}
+
+	return ids
+}
+
+// Run executes the selected checks in dependency order.
+// When only is non-empty just those checks run, along with anything they depend on.
+// Checks listed in skip never run, and checks whose dependencies failed or were
+// skipped are reported as skipped instead of running.
+func (r *Registry) Run(ctx context.Context, env *Env, only []string, skip []string, hooks Hooks) ([]Outcome, error) {
+	for _, id := range append(slices.Clone(only), skip...) {
+		if _, ok := r.byID[id]; !ok {
+			return nil, fmt.Errorf("there's no check called %q; the checks are %s", id, strings.Join(r.IDs(), ", "))
+		}
+	}
+
+	checks, err := r.Checks()
+	if err != nil {
+		return nil, err
+	}
+
+	// work out which checks were asked for including their dependencies
+	selected := map[string]bool{}
+	var include func(id string)
+	include = func(id string) {
+		if selected[id] {
+			return
+		}
+		selected[id] = true
+		for _, dep := range dependencies(r.byID[id]) {
+			include(dep)
+		}
+	}
+	for _, check := range checks {
+		if len(only) == 0 || slices.Contains(only, check.ID()) {
+			include(check.ID())
+		}
+	}
+
+	outcomes := []Outcome{}
+	statuses := map[string]Status{}
+	for _, check := range checks {
+		if !selected[check.ID()] {
+			continue
+		}
+
+		var result Result
+		if slices.Contains(skip, check.ID()) {
+			result = Result{Status: Skip, Message: "skipped by request"}
+		} else if blocker := blockedBy(check, statuses); blocker != "" {
+			result = Result{Status: Skip, Message: fmt.Sprintf("skipped because %s didn't pass", blocker)}
+		} else if ctx.Err() != nil {
+			result = Result{Status: Skip, Message: "skipped because the run was cancelled"}
+		} else {
+			if hooks.Start != nil {
+				hooks.Start(check)
+			}
+			result = check.Run(ctx, env)
+		}
+
+		statuses[check.ID()] = result.Status
+		outcomes = append(outcomes, Outcome{Check: check, Result: result})
+
+		if hooks.Done != nil {
+			hooks.Done(check, result)
+		}
+	}
+
+	return outcomes, nil
+}
+
+// blockedBy returns the first dependency of check that failed or was skipped
+func blockedBy(check Check, statuses map[string]Status) string {
+	for _, dep := range dependencies(check) {
+		if status := statuses[dep]; status == Fail || status == Skip {
+			return dep
+		}
+	}
+
+	return ""
+}
+
+// dependencies returns the dependencies of a check if it has any
+func dependencies(check Check) []string {
+	if dependent, ok := check.(Dependent); ok {
+		return dependent.Dependencies()
+	}
+
+	return nil
+}
+
+// funcCheck is a Check built from a plain function
+type funcCheck struct {
+	id          string
+	description string
+	deps        []string
+	run         func(ctx context.Context, env *Env) Result
+}
+
+func (f funcCheck) ID() string             { return f.id }
+func (f funcCheck) Description() string    { return f.description }
+func (f funcCheck) Dependencies() []string { return f.deps }
+func (f funcCheck) Run(ctx context.Context, env *Env) Result {
+	return f.run(ctx, env)
+}
+
+// NewCheck builds a Check out of a function
+func NewCheck(id string, description string, deps []string, run func(ctx context.Context, env *Env) Result) Check {
+	return funcCheck{id: id, description: description, deps: deps, run: run}
+}
@@ -0,0 +1,106 @@
+package doctor
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestRegistryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses map[string]Status
+		only     []string
+		skip     []string
+		want     map[string]Status
+	}{
+		{
+			name: "everything passes",
+			want: map[string]Status{"base": Pass, "middle": Pass, "leaf": Pass, "other": Pass},
+		},
+		{
+			name:     "a warning doesn't block",
+			statuses: map[string]Status{"middle": Warn},
+			want:     map[string]Status{"base": Pass, "middle": Warn, "leaf": Pass, "other": Pass},
+		},
+		{
+			name:     "a failure skips what depends on it",
+			statuses: map[string]Status{"base": Fail},
+			want:     map[string]Status{"base": Fail, "middle": Skip, "leaf": Skip, "other": Pass},
+		},
+		{
+			name: "skipping skips dependents",
+			skip: []string{"middle"},
+			want: map[string]Status{"base": Pass, "middle": Skip, "leaf": Skip, "other": Pass},
+		},
+		{
+			name: "only pulls in dependencies",
+			only: []string{"leaf"},
+			want: map[string]Status{"base": Pass, "middle": Pass, "leaf": Pass},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(id string, deps ...string) Check {
+				return NewCheck(id, id, deps, func(context.Context, *Env) Result {
+					status := tt.statuses[id]
+					if status == "" {
+						status = Pass
+					}
+					return Result{Status: status}
+				})
+			}
+
+			registry := NewRegistry()
+			// registered out of order to make sure dependencies still run first
+			if err := registry.Register(check("leaf", "middle", "base"), check("middle", "base"), check("base"), check("other")); err != nil {
+				t.Fatal(err)
+			}
+
+			outcomes, err := registry.Run(context.Background(), &Env{}, tt.only, tt.skip, Hooks{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := map[string]bool{}
+			for _, outcome := range outcomes {
+				id := outcome.Check.ID()
+				for _, dep := range dependencies(outcome.Check) {
+					if !seen[dep] {
+						t.Errorf("%s ran before its dependency %s", id, dep)
+					}
+				}
+				seen[id] = true
+
+				if outcome.Result.Status != tt.want[id] {
+					t.Errorf("%s is %s, want %s", id, outcome.Result.Status, tt.want[id])
+				}
+			}
+			if len(outcomes) != len(tt.want) {
+				t.Errorf("ran %d checks, want %d", len(outcomes), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDefaultHeartbeatDependsOnAPIURL(t *testing.T) {
+	checks, err := Default().Checks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, check := range checks {
+		if check.ID() == "heartbeat" {
+			deps := dependencies(check)
+			for _, want := range []string{"api-url", "statusbar"} {
+				if !slices.Contains(deps, want) {
+					t.Errorf("heartbeat depends on %v, want it to include %s", deps, want)
+				}
+			}
+			return
+		}
+	}
+
+	t.Fatal("there's no heartbeat check")
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HookNames are the hooks akami installs
+var HookNames = []string{"post-commit", "post-checkout"}
+
+// hookMarker is how akami recognises hooks it installed
+const hookMarker = "# installed by akami git install-hooks"
+
+// chainedSuffix is added to a hook that was there before akami's so it still runs
+const chainedSuffix = ".pre-akami"
+
+// hookScript runs any hook akami replaced and then hands off to akami in the background
+// so committing and switching branches never wait on the network
+const hookScript = `#!/bin/sh
+%s
+# a hook that was here before is kept next to this one as %s%s and still runs first
+if [ -x "$0%s" ]; then
+  "$0%s" "$@" || exit $?
+fi
+%s git hook %s "$@" >/dev/null 2>&1 &
+exit 0
+`
+
+// Installed is a hook InstallHooks wrote
+type Installed struct {
+	// Name is the hook's name like post-commit
+	Name string
+	// Path is where it was written
+	Path string
+	// Chained is where the hook that was already there was moved to, if there was one
+	Chained string
+}
+
+// InstallHooks writes akami's hooks into the repository. command runs akami, like the
+// path to it and any --profile flag. Hooks that akami didn't write are moved aside
+// and run before akami's instead of being replaced.
+func (r Repo) InstallHooks(ctx context.Context, command []string) ([]Installed, error) {
+	dir, err := r.HooksDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+
+	var installed []Installed
+	for _, name := range HookNames {
+		hook := Installed{Name: name, Path: filepath.Join(dir, name)}
+
+		existing, err := os.ReadFile(hook.Path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return installed, err
+		case !strings.Contains(string(existing), hookMarker):
+			chained := hook.Path + chainedSuffix
+			if _, err := os.Stat(chained); err == nil {
+				return installed, fmt.Errorf("%s already exists so %s can't be moved out of the way; merge them by hand", chained, hook.Path)
+			}
+			if err := os.Rename(hook.Path, chained); err != nil {
+				return installed, err
+			}
+			hook.Chained = chained
+		}
+
+		script := fmt.Sprintf(hookScript, hookMarker, name, chainedSuffix, chainedSuffix, chainedSuffix, strings.Join(quoted, " "), name)
+		if err := os.WriteFile(hook.Path, []byte(script), 0o755); err != nil {
+			return installed, err
+		}
+		// WriteFile keeps the mode of a file that's already there
+		if err := os.Chmod(hook.Path, 0o755); err != nil {
+			return installed, err
+		}
+
+		installed = append(installed, hook)
+	}
+
+	return installed, nil
+}
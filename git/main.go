@@ -0,0 +1,178 @@
+// Package git backs akami git, which installs hooks that send heartbeats when
+// commits are made and branches are switched, and lines up a repository's
+// commits with the coding time the server recorded for its project. It runs the
+// git binary rather than reading repositories itself so it sees exactly what git does.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotRepo occurs when a folder isn't inside a git repository
+var ErrNotRepo = errors.New("not inside a git repository")
+
+// Repo is a git working tree
+type Repo struct {
+	// Root is the top folder of the working tree
+	Root string
+}
+
+// Commit is a commit from the log along with the branch it was found on
+type Commit struct {
+	Hash    string    `json:"hash" yaml:"hash"`
+	Branch  string    `json:"branch" yaml:"branch"`
+	Time    time.Time `json:"time" yaml:"time"`
+	Author  string    `json:"author" yaml:"author"`
+	Email   string    `json:"email" yaml:"email"`
+	Subject string    `json:"subject" yaml:"subject"`
+}
+
+// Short returns the abbreviated hash
+func (c Commit) Short() string {
+	return c.Hash[:min(len(c.Hash), 7)]
+}
+
+// Open finds the repository dir is in
+func Open(ctx context.Context, dir string) (Repo, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return Repo{}, fmt.Errorf("%w: %s", ErrNotRepo, dir)
+		}
+		return Repo{}, err
+	}
+
+	return Repo{Root: out}, nil
+}
+
+// HooksDir returns the folder git runs hooks from, which core.hooksPath can move
+func (r Repo) HooksDir(ctx context.Context) (string, error) {
+	out, err := run(ctx, r.Root, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(r.Root, out)
+	}
+	return out, nil
+}
+
+// Email returns the user.email commits are made with
+func (r Repo) Email(ctx context.Context) string {
+	out, _ := run(ctx, r.Root, "config", "user.email")
+	return out
+}
+
+// CommitFiles returns the files a commit changed that still exist, as absolute paths
+func (r Repo) CommitFiles(ctx context.Context, rev string) ([]string, error) {
+	out, err := run(ctx, r.Root, "diff-tree", "--root", "--no-commit-id", "--name-only", "--diff-filter=d", "-r", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.paths(out), nil
+}
+
+// ChangedFiles returns the files that differ between two revisions and exist in the second one
+func (r Repo) ChangedFiles(ctx context.Context, from string, to string) ([]string, error) {
+	out, err := run(ctx, r.Root, "diff", "--name-only", "--diff-filter=d", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.paths(out), nil
+}
+
+// Log returns the commits on local branches since a time, newest first. author
+// limits them to one author when it isn't empty. Each commit's branch is the first
+// branch git found it on, so commits that were merged count for the branch they
+// were merged into only when the original branch is gone.
+func (r Repo) Log(ctx context.Context, since time.Time, author string) ([]Commit, error) {
+	args := []string{"log", "--branches", "--source", "--since=" + since.Format(time.RFC3339), "--format=%H%x1f%S%x1f%ct%x1f%an%x1f%ae%x1f%s%x1e"}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+
+	out, err := run(ctx, r.Root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Branch:  strings.TrimPrefix(fields[1], "refs/heads/"),
+			Time:    time.Unix(seconds, 0),
+			Author:  fields[3],
+			Email:   fields[4],
+			Subject: fields[5],
+		})
+	}
+
+	return commits, nil
+}
+
+// paths turns git's repository relative file list into absolute paths
+func (r Repo) paths(out string) []string {
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, filepath.Join(r.Root, filepath.FromSlash(line)))
+		}
+	}
+
+	return paths
+}
+
+// run runs git in dir and returns its trimmed output, with git's complaint as the error when it fails
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return "", &gitError{exitErr, strings.TrimSpace(stderr.String())}
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitError is a failed git command along with what it printed
+type gitError struct {
+	*exec.ExitError
+	message string
+}
+
+func (e *gitError) Error() string {
+	return e.message
+}
+
+func (e *gitError) Unwrap() error {
+	return e.ExitError
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"sort"
+	"time"
+)
+
+// Span is a stretch of coding the server recorded, along with the branch it was on
+type Span struct {
+	Branch string
+	Start  time.Time
+	End    time.Time
+}
+
+// CommitTime is a commit and the coding time that led up to it
+type CommitTime struct {
+	Commit
+	// Seconds is the time coded on the commit's branch since the commit before it on that branch
+	Seconds float64
+}
+
+// BranchTime is the coding time spent on a branch
+type BranchTime struct {
+	Branch string
+	// Seconds is all the time coded on the branch
+	Seconds float64
+	// Commits is how many of the commits were found on the branch
+	Commits int
+	// Uncommitted is the time coded on the branch after its last commit
+	Uncommitted float64
+}
+
+// Report is the result of Correlate
+type Report struct {
+	// Commits are oldest first
+	Commits []CommitTime
+	// Branches are sorted by the most time first
+	Branches []BranchTime
+	// Total is all the time in the spans
+	Total float64
+	// Uncommitted is the time that no commit came after
+	Uncommitted float64
+}
+
+// Correlate splits coding time between commits. Each commit gets the time spent on
+// its branch between the commit before it on that branch and itself, so a span that
+// runs over a commit is split at the commit. Spans without a branch, from editors
+// that don't send one, count towards whichever branch is committed to next.
+func Correlate(commits []Commit, spans []Span) Report {
+	report := Report{Commits: make([]CommitTime, len(commits))}
+	for i, commit := range commits {
+		report.Commits[i] = CommitTime{Commit: commit}
+	}
+	sort.SliceStable(report.Commits, func(i, j int) bool { return report.Commits[i].Time.Before(report.Commits[j].Time) })
+
+	branches := map[string]*BranchTime{}
+	branch := func(name string) *BranchTime {
+		if branches[name] == nil {
+			branches[name] = &BranchTime{Branch: name}
+		}
+		return branches[name]
+	}
+	for _, commit := range report.Commits {
+		branch(commit.Branch).Commits++
+	}
+
+	for _, span := range spans {
+		cursor := span.Start
+		for i := range report.Commits {
+			commit := &report.Commits[i]
+			if !commit.Time.After(cursor) || (span.Branch != "" && commit.Branch != span.Branch) {
+				continue
+			}
+
+			end := span.End
+			if commit.Time.Before(end) {
+				end = commit.Time
+			}
+			seconds := end.Sub(cursor).Seconds()
+			commit.Seconds += seconds
+			branch(commit.Branch).Seconds += seconds
+
+			cursor = end
+			if !cursor.Before(span.End) {
+				break
+			}
+		}
+
+		if rest := span.End.Sub(cursor).Seconds(); rest > 0 {
+			name := span.Branch
+			if name == "" {
+				name = "unknown"
+			}
+			branch(name).Seconds += rest
+			branch(name).Uncommitted += rest
+			report.Uncommitted += rest
+		}
+		report.Total += span.End.Sub(span.Start).Seconds()
+	}
+
+	for _, b := range branches {
+		report.Branches = append(report.Branches, *b)
+	}
+	sort.Slice(report.Branches, func(i, j int) bool {
+		if report.Branches[i].Seconds != report.Branches[j].Seconds {
+			return report.Branches[i].Seconds > report.Branches[j].Seconds
+		}
+		return report.Branches[i].Branch < report.Branches[j].Branch
+	})
+
+	return report
+}
@@ -0,0 +1,128 @@
+package git
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestCorrelate(t *testing.T) {
+	base := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	commit := func(hash string, branch string, minutes int) Commit {
+		return Commit{Hash: hash, Branch: branch, Time: at(minutes)}
+	}
+	span := func(branch string, start int, end int) Span {
+		return Span{Branch: branch, Start: at(start), End: at(end)}
+	}
+
+	tests := []struct {
+		name        string
+		commits     []Commit
+		spans       []Span
+		commitMins  map[string]float64
+		branchMins  map[string]float64
+		total       float64
+		uncommitted float64
+	}{
+		{
+			name:       "nothing",
+			commitMins: map[string]float64{},
+			branchMins: map[string]float64{},
+		},
+		{
+			name:       "span before a commit",
+			commits:    []Commit{commit("a", "main", 60)},
+			spans:      []Span{span("main", 0, 30)},
+			commitMins: map[string]float64{"a": 30},
+			branchMins: map[string]float64{"main": 30},
+			total:      30,
+		},
+		{
+			name:        "span over a commit is split",
+			commits:     []Commit{commit("a", "main", 20)},
+			spans:       []Span{span("main", 0, 30)},
+			commitMins:  map[string]float64{"a": 20},
+			branchMins:  map[string]float64{"main": 30},
+			total:       30,
+			uncommitted: 10,
+		},
+		{
+			name:       "each commit gets the time since the one before",
+			commits:    []Commit{commit("b", "main", 50), commit("a", "main", 20)},
+			spans:      []Span{span("main", 0, 10), span("main", 15, 45)},
+			commitMins: map[string]float64{"a": 15, "b": 25},
+			branchMins: map[string]float64{"main": 40},
+			total:      40,
+		},
+		{
+			name:       "time stays on its branch",
+			commits:    []Commit{commit("a", "main", 30), commit("f", "feature", 60)},
+			spans:      []Span{span("feature", 0, 20), span("main", 20, 30)},
+			commitMins: map[string]float64{"a": 10, "f": 20},
+			branchMins: map[string]float64{"main": 10, "feature": 20},
+			total:      30,
+		},
+		{
+			name:       "spans without a branch go to the next commit",
+			commits:    []Commit{commit("a", "main", 30), commit("f", "feature", 60)},
+			spans:      []Span{span("", 0, 10), span("", 35, 50)},
+			commitMins: map[string]float64{"a": 10, "f": 15},
+			branchMins: map[string]float64{"main": 10, "feature": 15},
+			total:      25,
+		},
+		{
+			name:        "time after the last commit is uncommitted",
+			commits:     []Commit{commit("a", "main", 10)},
+			spans:       []Span{span("main", 20, 30), span("", 40, 45)},
+			commitMins:  map[string]float64{"a": 0},
+			branchMins:  map[string]float64{"main": 10, "unknown": 5},
+			total:       15,
+			uncommitted: 15,
+		},
+		{
+			name:        "branch without commits",
+			commits:     []Commit{commit("a", "main", 10)},
+			spans:       []Span{span("experiment", 0, 20)},
+			commitMins:  map[string]float64{"a": 0},
+			branchMins:  map[string]float64{"main": 0, "experiment": 20},
+			total:       20,
+			uncommitted: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Correlate(tt.commits, tt.spans)
+
+			commitMins := map[string]float64{}
+			for i, c := range report.Commits {
+				commitMins[c.Hash] = c.Seconds / 60
+				if i > 0 && c.Time.Before(report.Commits[i-1].Time) {
+					t.Errorf("commit %s is out of order", c.Hash)
+				}
+			}
+			if !maps.Equal(commitMins, tt.commitMins) {
+				t.Errorf("commit minutes = %v, want %v", commitMins, tt.commitMins)
+			}
+
+			branchMins := map[string]float64{}
+			for i, b := range report.Branches {
+				branchMins[b.Branch] = b.Seconds / 60
+				if i > 0 && b.Seconds > report.Branches[i-1].Seconds {
+					t.Errorf("branch %s is out of order", b.Branch)
+				}
+			}
+			if !maps.Equal(branchMins, tt.branchMins) {
+				t.Errorf("branch minutes = %v, want %v", branchMins, tt.branchMins)
+			}
+
+			if report.Total/60 != tt.total {
+				t.Errorf("total = %v minutes, want %v", report.Total/60, tt.total)
+			}
+			if report.Uncommitted/60 != tt.uncommitted {
+				t.Errorf("uncommitted = %v minutes, want %v", report.Uncommitted/60, tt.uncommitted)
+			}
+		})
+	}
+}
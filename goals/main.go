@@ -0,0 +1,207 @@
+// Package goals tracks coding targets like two hours a day or ten hours of Go a
+// week. Goals set on the server come from the goals api, and since hackatime and
+// wakapi don't have one akami can also keep goals of its own and work out how
+// they're going from daily summaries.
+package goals
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// DefaultPeriods is how many days or weeks of history goals are evaluated over
+const DefaultPeriods = 7
+
+// Where a goal comes from
+const (
+	SourceServer = "server"
+	SourceLocal  = "local"
+)
+
+// Period is how a goal went over one day or week
+type Period struct {
+	// Start is midnight at the start of the period
+	Start time.Time
+	// End is midnight at the start of the last day of the period
+	End time.Time
+	// Actual is the time spent towards the goal in seconds
+	Actual float64
+	// Target is the time the goal asks for in seconds
+	Target float64
+	// Status is one of wakatime.GoalSuccess, GoalFail, GoalPending or GoalIgnored
+	Status string
+}
+
+// Progress is a goal along with how it has gone lately, whether it's set on the server or locally
+type Progress struct {
+	// ID is the server's id for the goal and empty for local goals
+	ID string
+	// Name is the goal's title or local name
+	Name string
+	// Source is SourceServer or SourceLocal
+	Source string
+	// Delta is wakatime.GoalDaily or wakatime.GoalWeekly
+	Delta string
+	// Target is the time the goal asks for each period in seconds
+	Target float64
+	// Languages and Projects narrow down what time counts towards the goal
+	Languages []string
+	Projects  []string
+	// Periods is the goal's history with the current period last
+	Periods []Period
+}
+
+// Current returns the period that's still going, which is the last one
+func (p Progress) Current() (Period, bool) {
+	if len(p.Periods) == 0 {
+		return Period{}, false
+	}
+
+	return p.Periods[len(p.Periods)-1], true
+}
+
+// Describe sums up what a goal asks for like "2 hrs a day of Go in akami"
+func (p Progress) Describe(format func(seconds float64) string) string {
+	every := "a day"
+	if p.Delta == wakatime.GoalWeekly {
+		every = "a week"
+	}
+
+	text := format(p.Target) + " " + every
+	if len(p.Languages) > 0 {
+		text += " of " + strings.Join(p.Languages, ", ")
+	}
+	if len(p.Projects) > 0 {
+		text += " in " + strings.Join(p.Projects, ", ")
+	}
+
+	return text
+}
+
+// FromAPI turns a goal from the server into a Progress, reading its periods in loc
+func FromAPI(goal wakatime.Goal, loc *time.Location) Progress {
+	progress := Progress{
+		ID:        string(goal.ID),
+		Name:      goal.Title,
+		Source:    SourceServer,
+		Delta:     goal.Delta,
+		Target:    goal.Seconds,
+		Languages: goal.Languages,
+		Projects:  goal.Projects,
+	}
+
+	for _, point := range goal.ChartData {
+		period := Period{Actual: point.ActualSeconds, Target: point.GoalSeconds, Status: point.RangeStatus}
+		if start, err := time.Parse(time.RFC3339, point.Range.Start); err == nil {
+			period.Start = midnight(start.In(loc))
+		} else if date, err := time.ParseInLocation(wakatime.DateFormat, point.Range.Date, loc); err == nil {
+			period.Start = date
+		}
+		period.End = period.Start
+		if end, err := time.Parse(time.RFC3339, point.Range.End); err == nil {
+			period.End = midnight(end.In(loc))
+		}
+
+		progress.Periods = append(progress.Periods, period)
+	}
+
+	return progress
+}
+
+// Fetcher gets daily summaries between start and end, narrowed down to a project when it isn't empty
+type Fetcher func(ctx context.Context, start time.Time, end time.Time, project string) ([]wakatime.Summary, error)
+
+// NewFetcher returns a Fetcher backed by client that remembers what it has fetched
+// so evaluating several goals over the same days only asks the api once
+func NewFetcher(client *wakatime.Client) Fetcher {
+	fetched := map[string][]wakatime.Summary{}
+
+	return func(ctx context.Context, start time.Time, end time.Time, project string) ([]wakatime.Summary, error) {
+		key := start.Format(wakatime.DateFormat) + "/" + end.Format(wakatime.DateFormat) + "/" + project
+		if days, ok := fetched[key]; ok {
+			return days, nil
+		}
+
+		resp, err := client.GetSummariesContext(ctx, start, end, wakatime.SummariesOptions{Project: project})
+		if err != nil {
+			return nil, err
+		}
+
+		fetched[key] = resp.Data
+		return resp.Data, nil
+	}
+}
+
+// Evaluate works out how a local goal has gone over the last periods days or weeks
+// up to today. Weeks start on monday and the current period is pending until it's
+// either met or over.
+func Evaluate(ctx context.Context, goal Goal, fetch Fetcher, today time.Time, periods int) (Progress, error) {
+	today = midnight(today)
+	progress := goal.progress()
+
+	var bounds []Period
+	switch goal.Delta {
+	case wakatime.GoalWeekly:
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		for i := periods - 1; i >= 0; i-- {
+			start := monday.AddDate(0, 0, -7*i)
+			bounds = append(bounds, Period{Start: start, End: start.AddDate(0, 0, 6)})
+		}
+	case wakatime.GoalDaily:
+		for i := periods - 1; i >= 0; i-- {
+			day := today.AddDate(0, 0, -i)
+			bounds = append(bounds, Period{Start: day, End: day})
+		}
+	default:
+		return progress, fmt.Errorf("%q goals aren't supported; goals are tracked by %s or %s", goal.Delta, wakatime.GoalDaily, wakatime.GoalWeekly)
+	}
+	if len(bounds) == 0 {
+		return progress, nil
+	}
+
+	// a project and a language together need each project's own breakdown of languages
+	projects := []string{""}
+	if len(goal.Languages) > 0 && len(goal.Projects) > 0 {
+		projects = goal.Projects
+	}
+
+	seconds := map[string]float64{}
+	for _, project := range projects {
+		days, err := fetch(ctx, bounds[0].Start, today, project)
+		if err != nil {
+			return progress, err
+		}
+		for _, day := range days {
+			seconds[day.Range.Date] += goal.seconds(day)
+		}
+	}
+
+	for _, period := range bounds {
+		period.Target = goal.Target.Seconds()
+		for day := period.Start; !day.After(period.End) && !day.After(today); day = day.AddDate(0, 0, 1) {
+			period.Actual += seconds[day.Format(wakatime.DateFormat)]
+		}
+
+		switch {
+		case period.Actual >= period.Target:
+			period.Status = wakatime.GoalSuccess
+		case period.End.Before(today):
+			period.Status = wakatime.GoalFail
+		default:
+			period.Status = wakatime.GoalPending
+		}
+
+		progress.Periods = append(progress.Periods, period)
+	}
+
+	return progress, nil
+}
+
+// midnight returns the start of the day t is in
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,195 @@
+package goals
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// coding is how many minutes were spent in each language of each project on a day
+type coding map[string]map[string]map[string]float64
+
+// fetcher fakes the summaries api with the time in c, recording the project of every call
+func (c coding) fetcher(calls *[]string) Fetcher {
+	return func(_ context.Context, start time.Time, end time.Time, project string) ([]wakatime.Summary, error) {
+		*calls = append(*calls, project)
+
+		var days []wakatime.Summary
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			date := day.Format(wakatime.DateFormat)
+			summary := wakatime.Summary{Range: wakatime.SummaryRange{Date: date}}
+
+			languages := map[string]float64{}
+			for name, langs := range c[date] {
+				if project != "" && name != project {
+					continue
+				}
+
+				var total float64
+				for lang, minutes := range langs {
+					languages[lang] += minutes * 60
+					total += minutes * 60
+				}
+				summary.Projects = append(summary.Projects, wakatime.StatItem{Name: name, TotalSeconds: total})
+				summary.GrandTotal.TotalSeconds += total
+			}
+			for lang, seconds := range languages {
+				summary.Languages = append(summary.Languages, wakatime.StatItem{Name: lang, TotalSeconds: seconds})
+			}
+
+			days = append(days, summary)
+		}
+
+		return days, nil
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// a wednesday, so the week started on the 10th
+	today := time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC)
+
+	history := coding{
+		"2025-03-02": {"akami": {"Go": 600}},
+		"2025-03-08": {"akami": {"Go": 180}},
+		"2025-03-09": {"akami": {"Go": 120}, "site": {"CSS": 60}},
+		"2025-03-10": {"akami": {"Go": 120}},
+		"2025-03-11": {"akami": {"Go": 40, "Python": 30}, "other": {"Go": 50}},
+		"2025-03-12": {"akami": {"Go": 20}},
+	}
+
+	tests := []struct {
+		name     string
+		goal     Goal
+		periods  int
+		starts   []string
+		actual   []float64
+		statuses []string
+		calls    []string
+	}{
+		{
+			name:     "daily with today still pending",
+			goal:     Goal{Delta: wakatime.GoalDaily, Target: time.Hour},
+			periods:  3,
+			starts:   []string{"2025-03-10", "2025-03-11", "2025-03-12"},
+			actual:   []float64{120, 120, 20},
+			statuses: []string{wakatime.GoalSuccess, wakatime.GoalSuccess, wakatime.GoalPending},
+			calls:    []string{""},
+		},
+		{
+			name:     "daily met today",
+			goal:     Goal{Delta: wakatime.GoalDaily, Target: 15 * time.Minute},
+			periods:  1,
+			starts:   []string{"2025-03-12"},
+			actual:   []float64{20},
+			statuses: []string{wakatime.GoalSuccess},
+			calls:    []string{""},
+		},
+		{
+			name:     "weekly across a week boundary",
+			goal:     Goal{Delta: wakatime.GoalWeekly, Target: 5 * time.Hour},
+			periods:  2,
+			starts:   []string{"2025-03-03", "2025-03-10"},
+			actual:   []float64{360, 260},
+			statuses: []string{wakatime.GoalSuccess, wakatime.GoalPending},
+			calls:    []string{""},
+		},
+		{
+			name:     "weekly missed last week",
+			goal:     Goal{Delta: wakatime.GoalWeekly, Target: 10 * time.Hour},
+			periods:  2,
+			starts:   []string{"2025-03-03", "2025-03-10"},
+			actual:   []float64{360, 260},
+			statuses: []string{wakatime.GoalFail, wakatime.GoalPending},
+			calls:    []string{""},
+		},
+		{
+			name:     "language only",
+			goal:     Goal{Delta: wakatime.GoalDaily, Target: time.Hour, Languages: []string{"go"}},
+			periods:  2,
+			starts:   []string{"2025-03-11", "2025-03-12"},
+			actual:   []float64{90, 20},
+			statuses: []string{wakatime.GoalSuccess, wakatime.GoalPending},
+			calls:    []string{""},
+		},
+		{
+			name:     "language in a project",
+			goal:     Goal{Delta: wakatime.GoalDaily, Target: time.Hour, Languages: []string{"Go"}, Projects: []string{"akami"}},
+			periods:  2,
+			starts:   []string{"2025-03-11", "2025-03-12"},
+			actual:   []float64{40, 20},
+			statuses: []string{wakatime.GoalFail, wakatime.GoalPending},
+			calls:    []string{"akami"},
+		},
+		{
+			name:     "language in several projects",
+			goal:     Goal{Delta: wakatime.GoalWeekly, Target: 5 * time.Hour, Languages: []string{"Go"}, Projects: []string{"akami", "site"}},
+			periods:  1,
+			starts:   []string{"2025-03-10"},
+			actual:   []float64{180},
+			statuses: []string{wakatime.GoalPending},
+			calls:    []string{"akami", "site"},
+		},
+		{
+			name:     "project only",
+			goal:     Goal{Delta: wakatime.GoalDaily, Target: time.Hour, Projects: []string{"site"}},
+			periods:  1,
+			starts:   []string{"2025-03-12"},
+			actual:   []float64{0},
+			statuses: []string{wakatime.GoalPending},
+			calls:    []string{""},
+		},
+		{
+			name:    "no periods",
+			goal:    Goal{Delta: wakatime.GoalDaily, Target: time.Hour},
+			periods: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			progress, err := Evaluate(context.Background(), tt.goal, history.fetcher(&calls), today, tt.periods)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var starts, statuses []string
+			var actual []float64
+			for _, period := range progress.Periods {
+				starts = append(starts, period.Start.Format(wakatime.DateFormat))
+				actual = append(actual, period.Actual/60)
+				statuses = append(statuses, period.Status)
+				if period.Target != tt.goal.Target.Seconds() {
+					t.Errorf("period %s has target %v, want %v", period.Start, period.Target, tt.goal.Target.Seconds())
+				}
+			}
+
+			if !slices.Equal(starts, tt.starts) {
+				t.Errorf("periods start on %q, want %q", starts, tt.starts)
+			}
+			if !slices.Equal(actual, tt.actual) {
+				t.Errorf("actual minutes = %v, want %v", actual, tt.actual)
+			}
+			if !slices.Equal(statuses, tt.statuses) {
+				t.Errorf("statuses = %q, want %q", statuses, tt.statuses)
+			}
+			if !slices.Equal(calls, tt.calls) {
+				t.Errorf("fetched projects %q, want %q", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestEvaluateUnknownDelta(t *testing.T) {
+	var calls []string
+	_, err := Evaluate(context.Background(), Goal{Delta: "monthly", Target: time.Hour}, coding{}.fetcher(&calls), time.Now(), DefaultPeriods)
+	if err == nil {
+		t.Error("got no error for a monthly goal")
+	}
+	if len(calls) > 0 {
+		t.Errorf("fetched %d times, want none", len(calls))
+	}
+}
@@ -0,0 +1,203 @@
+package goals
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrNotFound occurs when a local goal doesn't exist
+	ErrNotFound = errors.New("goal not found")
+	// ErrExists occurs when adding a goal whose name is taken
+	ErrExists = errors.New("goal already exists")
+	// ErrInvalid occurs when a goal can't be tracked as written
+	ErrInvalid = errors.New("invalid goal")
+)
+
+// Goal is a target akami keeps and evaluates itself
+type Goal struct {
+	// Delta is wakatime.GoalDaily or wakatime.GoalWeekly
+	Delta string `yaml:"delta"`
+	// Target is how long has to be spent each period
+	Target time.Duration `yaml:"target"`
+	// Languages only counts time spent in these languages when set
+	Languages []string `yaml:"languages,omitempty"`
+	// Projects only counts time spent in these projects when set
+	Projects []string `yaml:"projects,omitempty"`
+
+	name string
+}
+
+// Name returns the name the goal is stored under
+func (g Goal) Name() string {
+	return g.name
+}
+
+// progress returns the goal as a Progress without any history
+func (g Goal) progress() Progress {
+	return Progress{
+		Name:      g.name,
+		Source:    SourceLocal,
+		Delta:     g.Delta,
+		Target:    g.Target.Seconds(),
+		Languages: g.Languages,
+		Projects:  g.Projects,
+	}
+}
+
+// seconds is how much of a day's summary counts towards the goal. When the goal has
+// both projects and languages the summary is expected to be for a single project.
+func (g Goal) seconds(day wakatime.Summary) float64 {
+	switch {
+	case len(g.Languages) > 0:
+		return matching(day.Languages, g.Languages)
+	case len(g.Projects) > 0:
+		return matching(day.Projects, g.Projects)
+	}
+
+	return day.GrandTotal.TotalSeconds
+}
+
+// matching adds up the items whose names are in names ignoring case
+func matching(items []wakatime.StatItem, names []string) float64 {
+	var total float64
+	for _, item := range items {
+		if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, item.Name) }) {
+			total += item.TotalSeconds
+		}
+	}
+
+	return total
+}
+
+// Store is the file akami keeps local goals in
+type Store struct {
+	// Goals are the local goals by name
+	Goals map[string]Goal `yaml:"goals,omitempty"`
+
+	path string
+}
+
+// DefaultPath returns where akami keeps local goals
+func DefaultPath() (string, error) {
+	dir, err := utils.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "goals.yaml"), nil
+}
+
+// Load reads the store at path; a missing file is an empty store
+func Load(path string) (*Store, error) {
+	store := &Store{Goals: map[string]Goal{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	if store.Goals == nil {
+		store.Goals = map[string]Goal{}
+	}
+
+	return store, nil
+}
+
+// Path returns where the store is saved
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Save writes the store back to disk
+func (s *Store) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// Names returns every goal name sorted
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.Goals))
+	for name := range s.Goals {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// List returns every goal sorted by name
+func (s *Store) List() []Goal {
+	goals := make([]Goal, 0, len(s.Goals))
+	for _, name := range s.Names() {
+		goal := s.Goals[name]
+		goal.name = name
+		goals = append(goals, goal)
+	}
+
+	return goals
+}
+
+// Get returns the named goal
+func (s *Store) Get(name string) (Goal, error) {
+	goal, ok := s.Goals[name]
+	if !ok {
+		return Goal{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	goal.name = name
+	return goal, nil
+}
+
+// Add stores a new goal, replacing an existing one only when overwrite is set
+func (s *Store) Add(name string, goal Goal, overwrite bool) error {
+	if name == "" || strings.ContainsAny(name, "\t\n/\\,") {
+		return fmt.Errorf("%w: goals need a name without tabs, slashes or commas", ErrInvalid)
+	}
+	if goal.Delta != wakatime.GoalDaily && goal.Delta != wakatime.GoalWeekly {
+		return fmt.Errorf("%w: %q goals aren't supported; goals are tracked by %s or %s", ErrInvalid, goal.Delta, wakatime.GoalDaily, wakatime.GoalWeekly)
+	}
+	if goal.Target <= 0 {
+		return fmt.Errorf("%w: the target has to be more than 0", ErrInvalid)
+	}
+	if _, ok := s.Goals[name]; ok && !overwrite {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	}
+
+	goal.name = ""
+	s.Goals[name] = goal
+	return nil
+}
+
+// Remove deletes a goal
+func (s *Store) Remove(name string) error {
+	if _, ok := s.Goals[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	delete(s.Goals, name)
+	return nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/dash"
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/goals"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// Dash opens the interactive dashboard
+func Dash(c *cobra.Command, _ []string) error {
+	printTask(c, "Validating arguments")
+
+	refresh, _ := c.Flags().GetDuration("refresh")
+	if refresh < 5*time.Second {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("refreshing every %s would hammer the api; use at least 5s", refresh)
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	name, active, err := activeProfile(c)
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+	target := doctorTarget{name: name, profile: active}
+
+	completeTask(c, "Arguments look fine!")
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	return dash.Run(c.Context(), dash.Options{
+		Client:  client,
+		Refresh: refresh,
+		Goals: func(ctx context.Context) ([]goals.Progress, error) {
+			progress, _, err := loadGoals(ctx, client, time.Now())
+			return progress, err
+		},
+		Doctor: func(ctx context.Context) ([]doctor.Outcome, error) {
+			// the dashboard checks again whenever it's asked to so it shouldn't send test heartbeats
+			return doctor.Default().Run(ctx, doctorEnv(c, target), nil, []string{"heartbeat"}, doctor.Hooks{})
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// Explain walks through how the wakatime config treats a file and prints the heartbeat
+// an editor plugin would send for it without sending anything
+func Explain(c *cobra.Command, args []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Loading your config")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		errorTask(c, "Loading your config")
+		return err
+	}
+
+	completeTask(c, "Loading your config")
+
+	printTask(c, "Looking at "+args[0])
+
+	opts := fileOptions(c, cfg)
+	info, err := wakatime.DetectFile(args[0], opts)
+	if err != nil {
+		errorTask(c, "Looking at "+args[0])
+		return err
+	}
+	if info.IsDir {
+		errorTask(c, "Looking at "+args[0])
+		return fmt.Errorf("%s is a folder; pass a file an editor would have open", args[0])
+	}
+
+	filtered := wakatime.Filter(info, cfg)
+	heartbeat := filtered.Apply(info.Heartbeat(opts), info)
+
+	completeTask(c, "Looking at "+args[0])
+
+	report := ExplainReport{
+		File:       newFileReport(info),
+		Skipped:    filtered.Skipped,
+		SkipReason: filtered.Reason,
+		ExcludedBy: filtered.ExcludedBy,
+		IncludedBy: filtered.IncludedBy,
+		Hidden:     hiddenSettings(filtered),
+		Heartbeat:  heartbeat,
+	}
+	if cfg != nil {
+		report.ConfigPath = cfg.Path
+	}
+
+	if isStructured(c) {
+		return writeOutput(c, format, report)
+	}
+
+	c.Println()
+	printFileInfo(c, info)
+
+	if cfg == nil {
+		c.Println(styles.Muted.Render("there's no wakatime config so nothing is excluded or hidden"))
+	} else {
+		c.Println("Config:", styles.Muted.Render(cfg.Path))
+		switch {
+		case filtered.IncludedBy != "":
+			c.Printf("  %s included by %s, which beats any exclude pattern\n", styles.Success.Render("✔"), styles.Fancy.Render(filtered.IncludedBy))
+		case filtered.ExcludedBy != "":
+			c.Printf("  %s excluded by %s\n", styles.Bad.Render("✘"), styles.Fancy.Render(filtered.ExcludedBy))
+		default:
+			c.Printf("  %s no exclude pattern matches\n", styles.Success.Render("✔"))
+		}
+		if cfg.Settings.ExcludeUnknownProject {
+			c.Printf("  %s exclude_unknown_project is on\n", checkIcon(info.Project != ""))
+		}
+		if cfg.Settings.IncludeOnlyWithProjectFile {
+			c.Printf("  %s include_only_with_project_file is on\n", checkIcon(info.ProjectSource == wakatime.FromProjectFile))
+		}
+
+		if filtered.HideFileName {
+			c.Printf("  %s hide_file_names sends it as %s\n", styles.Warn.Render("!"), styles.Fancy.Render(heartbeat.Entity))
+		} else if filtered.HideProjectFolder {
+			c.Printf("  %s hide_project_folder sends it as %s\n", styles.Warn.Render("!"), styles.Fancy.Render(heartbeat.Entity))
+		}
+		if filtered.HideProjectName {
+			c.Printf("  %s hide_project_names swaps the project for a made up name saved in .wakatime-project\n", styles.Warn.Render("!"))
+		}
+		if filtered.HideBranchName {
+			c.Printf("  %s hide_branch_names leaves the branch out\n", styles.Warn.Render("!"))
+		}
+	}
+	c.Println()
+
+	pretty, err := json.MarshalIndent(heartbeat, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if filtered.Skipped {
+		c.Printf("🚫 nothing would be sent for this file because %s\n\n", filtered.Reason)
+		c.Println(styles.Muted.Render("This is the heartbeat it would have been:"))
+	} else {
+		c.Println("This is the heartbeat an editor would send:")
+	}
+	c.Println(string(pretty))
+
+	return nil
+}
+
+// hiddenSettings names the hide_* settings that cover a file
+func hiddenSettings(filtered wakatime.Filtered) []string {
+	var hidden []string
+	for _, setting := range []struct {
+		name string
+		on   bool
+	}{
+		{"hide_file_names", filtered.HideFileName},
+		{"hide_project_names", filtered.HideProjectName},
+		{"hide_branch_names", filtered.HideBranchName},
+		{"hide_project_folder", filtered.HideProjectFolder},
+	} {
+		if setting.on {
+			hidden = append(hidden, setting.name)
+		}
+	}
+
+	return hidden
+}
+
+// checkIcon is a tick when ok and a cross otherwise
+func checkIcon(ok bool) string {
+	if ok {
+		return styles.Success.Render("✔")
+	}
+
+	return styles.Bad.Render("✘")
+}
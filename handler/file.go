@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// fileOptions reads the flags that override what's detected about a file
+func fileOptions(c *cobra.Command, cfg *config.Config) wakatime.FileOptions {
+	project, _ := c.Flags().GetString("project")
+	language, _ := c.Flags().GetString("language")
+	write, _ := c.Flags().GetBool("write")
+
+	return wakatime.FileOptions{Config: cfg, Project: project, Language: language, IsWrite: write}
+}
+
+// printFileInfo lists what was detected about a file and where each part came from
+func printFileInfo(c *cobra.Command, info wakatime.FileInfo) {
+	c.Println(styles.Fancy.Render(info.Path))
+
+	row := func(label string, value string, source string) {
+		if value == "" {
+			value = styles.Muted.Render("none")
+		}
+		if source != "" {
+			value += styles.Muted.Render(" (" + source + ")")
+		}
+		c.Printf("  %-10s %s\n", label, value)
+	}
+
+	project := info.ProjectSource
+	if info.ProjectDetail != "" && info.ProjectSource != wakatime.FromGit {
+		project += ": " + info.ProjectDetail
+	}
+	row("project", info.Project, project)
+	if info.ProjectRoot != "" {
+		row("root", info.ProjectRoot, fmt.Sprintf("%d folders deep", info.ProjectRootCount))
+	}
+	row("branch", info.Branch, info.BranchSource)
+	if !info.IsDir {
+		row("language", info.Language, info.LanguageSource)
+		row("lines", fmt.Sprint(info.LineCount), "")
+	}
+	c.Println()
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/styles"
+)
+
+// hasFixes reports whether any of the outcomes came with a fix
+func hasFixes(outcomes []doctor.Outcome) bool {
+	for _, outcome := range outcomes {
+		if len(outcome.Result.Fixes) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyFixes shows the fixes the checks proposed as a diff and writes them to the
+// config file once the user agrees, backing up the original first. It returns the
+// descriptions of the fixes that were written and where the backup went.
+func applyFixes(c *cobra.Command, env *doctor.Env, outcomes []doctor.Outcome, yes bool) ([]string, string, error) {
+	var fixes []doctor.Fix
+	for _, outcome := range outcomes {
+		fixes = append(fixes, outcome.Result.Fixes...)
+	}
+	if len(fixes) == 0 || env.ConfigPath == "" {
+		return nil, "", nil
+	}
+
+	info, err := os.Stat(env.ConfigPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw, err := os.ReadFile(env.ConfigPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	before := string(raw)
+	after := doctor.ApplyFixes(before, fixes)
+	if before == after {
+		return nil, "", nil
+	}
+
+	descriptions := make([]string, 0, len(fixes))
+	for _, fix := range fixes {
+		descriptions = append(descriptions, fix.Description)
+	}
+
+	c.Println(styles.Fancy.Render("Here's what we'd change in " + env.ConfigPath + ":"))
+	for _, description := range descriptions {
+		c.Printf("  • %s\n", description)
+	}
+	c.Println()
+
+	for _, line := range doctor.Diff(before, after) {
+		text := maskSecret(line.Text)
+		switch line.Op {
+		case '-':
+			c.Println(styles.Bad.Render("- " + text))
+		case '+':
+			c.Println(styles.Success.Render("+ " + text))
+		default:
+			c.Println(styles.Muted.Render("  " + text))
+		}
+	}
+	c.Println()
+
+	if !yes {
+		c.Print("Apply these changes? [y/N] ")
+
+		answer, err := readLine(c.InOrStdin())
+		if err != nil && answer == "" {
+			return nil, "", errors.New("couldn't read your answer; pass --yes to apply the fixes without asking")
+		}
+
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			c.Println("👍 leaving your config alone")
+			return nil, "", nil
+		}
+	}
+
+	backup := env.ConfigPath + "." + time.Now().Format("20060102-150405") + ".bak"
+	if err := os.WriteFile(backup, raw, info.Mode().Perm()); err != nil {
+		return nil, "", err
+	}
+
+	if err := os.WriteFile(env.ConfigPath, []byte(after), info.Mode().Perm()); err != nil {
+		return nil, "", err
+	}
+
+	c.Printf("❇️ fixed your config! the old one is backed up at %s\n\n", styles.Muted.Render(backup))
+
+	return descriptions, backup, nil
+}
+
+// readLine reads a single line without buffering past it so later prompts still get their input
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// maskSecret hides most of the value on api_key lines so diffs are safe to share
+func maskSecret(line string) string {
+	sep := strings.IndexAny(line, "=:")
+	if sep == -1 || !strings.EqualFold(strings.TrimSpace(line[:sep]), "api_key") {
+		return line
+	}
+	name, value := line[:sep], strings.TrimSpace(line[sep+1:])
+
+	if len(value) <= 4 {
+		return line
+	}
+
+	return name + line[sep:sep+1] + " " + strings.Repeat("•", 8) + value[len(value)-4:]
+}
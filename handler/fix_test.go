@@ -0,0 +1,28 @@
+package handler
+
+import "testing"
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "api key", line: "api_key = waka_12345678", want: "api_key = ••••••••5678"},
+		{name: "no spaces", line: "api_key=waka_12345678", want: "api_key= ••••••••5678"},
+		{name: "colon separator", line: "api_key: waka_12345678", want: "api_key: ••••••••5678"},
+		{name: "upper case", line: "API_KEY = waka_12345678", want: "API_KEY = ••••••••5678"},
+		{name: "short value", line: "api_key = abc", want: "api_key = abc"},
+		{name: "vault command", line: "api_key_vault_cmd = pass show wakatime", want: "api_key_vault_cmd = pass show wakatime"},
+		{name: "other setting", line: "api_url = https://hackatime.hackclub.com/api/hackatime/v1", want: "api_url = https://hackatime.hackclub.com/api/hackatime/v1"},
+		{name: "heading", line: "[settings]", want: "[settings]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskSecret(tt.line); got != tt.want {
+				t.Errorf("maskSecret(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/git"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// gitPlugin is added to the user agent of heartbeats sent by the git hooks
+const gitPlugin = "akami-git/1.0.0"
+
+// GitInstallHooks adds hooks to a repository that send heartbeats on commits and branch switches
+func GitInstallHooks(c *cobra.Command, args []string) error {
+	if err := refuseKeyFlag(c, "the git hooks"); err != nil {
+		return err
+	}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	printTask(c, "Finding the repository")
+
+	repo, err := git.Open(c.Context(), dir)
+	if err != nil {
+		errorTask(c, "Finding the repository")
+		return err
+	}
+
+	completeTask(c, "Found "+repo.Root)
+
+	printTask(c, "Installing hooks")
+
+	exe, err := os.Executable()
+	if err != nil {
+		errorTask(c, "Installing hooks")
+		return err
+	}
+
+	// the hooks run akami for the account install-hooks was run for
+	installed, err := repo.InstallHooks(c.Context(), append([]string{exe}, accountFlags(c)...))
+	if err != nil {
+		errorTask(c, "Installing hooks")
+		return err
+	}
+
+	completeTask(c, "Installing hooks")
+
+	c.Println()
+	for _, hook := range installed {
+		c.Printf("🪝 %s %s\n", styles.Fancy.Render(hook.Name), styles.Muted.Render(hook.Path))
+		if hook.Chained != "" {
+			c.Printf("   your old one was moved to %s and still runs first\n", styles.Muted.Render(hook.Chained))
+		}
+	}
+	c.Println("\n❇️ commits and branch switches will now show up with the right branch!")
+
+	return nil
+}
+
+// GitHook sends heartbeats for the files a git hook touched. The hooks run it in the
+// background with its output thrown away, so it doesn't print anything itself.
+func GitHook(c *cobra.Command, args []string) error {
+	repo, err := git.Open(c.Context(), ".")
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	isWrite := false
+	switch args[0] {
+	case "post-commit":
+		files, err = repo.CommitFiles(c.Context(), "HEAD")
+		isWrite = true
+	case "post-checkout":
+		// git passes the old and new HEAD and whether a branch was checked out rather than a file
+		if len(args) < 4 || args[3] != "1" {
+			return nil
+		}
+		files, err = repo.ChangedFiles(c.Context(), args[1], args[2])
+	default:
+		return fmt.Errorf("there's no %s hook; pick one of %s", args[0], strings.Join(git.HookNames, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	// a huge commit like a vendored dependency would otherwise flood the api
+	files = files[:min(len(files), wakatime.MaxBulkHeartbeats)]
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var heartbeats []wakatime.Heartbeat
+	for _, file := range files {
+		opts := wakatime.FileOptions{Config: cfg, IsWrite: isWrite, Time: now, Plugin: gitPlugin}
+		info, err := wakatime.DetectFile(file, opts)
+		if err != nil || info.IsDir {
+			continue
+		}
+
+		filtered := wakatime.Filter(info, cfg)
+		if filtered.Skipped {
+			continue
+		}
+		heartbeats = append(heartbeats, filtered.Apply(info.Heartbeat(opts), info))
+	}
+	if len(heartbeats) == 0 {
+		return nil
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	if client.Queue, err = openQueue(); err != nil {
+		return err
+	}
+
+	_, err = client.SendHeartbeatsContext(c.Context(), heartbeats)
+	return err
+}
+
+// GitReport lines up a repository's commits with the coding time the server recorded for its project
+func GitReport(c *cobra.Command, args []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	printTask(c, "Validating arguments")
+
+	days, _ := c.Flags().GetInt("days")
+	project, _ := c.Flags().GetString("project")
+	author, _ := c.Flags().GetString("author")
+	allAuthors, _ := c.Flags().GetBool("all-authors")
+	if days < 1 {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("can't report on %d days; use at least 1", days)
+	}
+
+	repo, err := git.Open(c.Context(), dir)
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+
+	if project == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			errorTask(c, "Validating arguments")
+			return err
+		}
+		info, err := wakatime.DetectFile(repo.Root, wakatime.FileOptions{Config: cfg})
+		if err != nil {
+			errorTask(c, "Validating arguments")
+			return err
+		}
+		project = info.Project
+	}
+	if author == "" && !allAuthors {
+		author = repo.Email(c.Context())
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	printTask(c, "Reading the git log")
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+	commits, err := repo.Log(c.Context(), since, author)
+	if err != nil {
+		errorTask(c, "Reading the git log")
+		return err
+	}
+
+	completeTask(c, fmt.Sprintf("Found %d commits", len(commits)))
+
+	printTask(c, "Fetching durations for "+project)
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	spans, err := branchSpans(c.Context(), client, project, since, now)
+	if err != nil {
+		errorTask(c, "Fetching durations for "+project)
+		return err
+	}
+
+	completeTask(c, "Fetching durations for "+project)
+
+	correlated := git.Correlate(commits, spans)
+
+	if isStructured(c) {
+		report := GitTimeReport{
+			Repo:        repo.Root,
+			Project:     project,
+			Since:       since.Format(time.DateOnly),
+			Author:      author,
+			Total:       correlated.Total,
+			Uncommitted: correlated.Uncommitted,
+			Branches:    []GitBranchReport{},
+			Commits:     []GitCommitReport{},
+		}
+		for _, b := range correlated.Branches {
+			report.Branches = append(report.Branches, GitBranchReport{Branch: b.Branch, Seconds: b.Seconds, Commits: b.Commits, Uncommitted: b.Uncommitted})
+		}
+		for _, commit := range correlated.Commits {
+			report.Commits = append(report.Commits, GitCommitReport{Commit: commit.Commit, Seconds: commit.Seconds})
+		}
+		return writeOutput(c, format, report)
+	}
+
+	c.Printf("\nYou coded for %s on %s over the last %s across %s\n\n",
+		styles.Fancy.Render(utils.PrettyPrintTime(int(correlated.Total))),
+		styles.Fancy.Render(project),
+		pluralDays(days),
+		styles.Fancy.Render(plural(len(commits), "commit")),
+	)
+
+	if correlated.Total == 0 && len(commits) == 0 {
+		c.Println("🌱 nothing to line up yet; code and commit a bit and try again")
+		return nil
+	}
+
+	width := render.TerminalWidth(c.OutOrStderr())
+	chart := render.Chart{Title: "Branches:", Width: width}
+	for _, b := range correlated.Branches {
+		chart.Items = append(chart.Items, render.Item{
+			Label: b.Branch,
+			Value: b.Seconds,
+			Text:  fmt.Sprintf("%s · %s", utils.PrettyPrintTime(int(b.Seconds)), plural(b.Commits, "commit")),
+		})
+	}
+	c.Println(chart.String())
+
+	if len(correlated.Commits) > 0 {
+		c.Println(styles.Fancy.Render("Commits:"))
+		// newest first like git log
+		for _, commit := range slices.Backward(correlated.Commits) {
+			spent := styles.Muted.Render(render.Pad("-", 8))
+			if commit.Seconds >= 60 {
+				spent = render.Pad(utils.ShortTime(commit.Seconds), 8)
+			}
+			line := fmt.Sprintf("  %s %s %s %s %s",
+				styles.Warn.Render(commit.Short()),
+				spent,
+				styles.Muted.Render(commit.Time.Format("Jan 2 15:04")),
+				styles.Success.Render(commit.Branch),
+				commit.Subject,
+			)
+			c.Println(render.Truncate(line, width))
+		}
+		c.Println()
+	}
+
+	if correlated.Uncommitted >= 60 {
+		c.Printf("%s of it hasn't been committed yet\n", styles.Fancy.Render(utils.PrettyPrintTime(int(correlated.Uncommitted))))
+	}
+
+	return nil
+}
+
+// branchFetches caps how many durations requests branchSpans has in flight at once
+const branchFetches = 4
+
+// branchSpans fetches a project's durations day by day and works out which branch
+// each was on. The durations endpoint can't slice by branch, so each day is fetched
+// again for every branch it lists, a few requests at a time through the client's
+// retries and backoff.
+func branchSpans(ctx context.Context, client *wakatime.Client, project string, since time.Time, until time.Time) ([]git.Span, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	slots := make(chan struct{}, branchFetches)
+	fetch := func(day time.Time, branches []string) (wakatime.DurationsResponse, error) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return wakatime.DurationsResponse{}, ctx.Err()
+		}
+		defer func() { <-slots }()
+
+		return client.GetDurationsWithOptionsContext(ctx, day, wakatime.DurationsOptions{Project: project, Branches: branches})
+	}
+
+	var dates []time.Time
+	for day := since; !day.After(until); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day)
+	}
+
+	days := make([][]git.Span, len(dates))
+	var wg sync.WaitGroup
+	for i, day := range dates {
+		spans := &days[i]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := fetch(day, nil)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			if len(resp.Branches) == 0 {
+				for _, d := range resp.Data {
+					*spans = append(*spans, git.Span{Start: d.Start(), End: d.End()})
+				}
+				return
+			}
+
+			for _, branch := range resp.Branches {
+				byBranch, err := fetch(day, []string{branch})
+				if err != nil {
+					fail(err)
+					return
+				}
+				for _, d := range byBranch.Data {
+					*spans = append(*spans, git.Span{Branch: branch, Start: d.Start(), End: d.End()})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return slices.Concat(days...), nil
+}
+
+// plural formats a count with a noun that gets an s when there isn't exactly one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
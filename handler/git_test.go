@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+func TestBranchSpans(t *testing.T) {
+	since := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(day int, clock string) float64 {
+		d, err := time.ParseDuration(clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return float64(since.AddDate(0, 0, day).Add(d).Unix())
+	}
+	heartbeat := func(branch string, day int, clock string) wakatime.Heartbeat {
+		return wakatime.Heartbeat{Entity: "/code/akami/main.go", Type: "file", Project: "akami", Branch: branch, Time: at(day, clock)}
+	}
+
+	tests := []struct {
+		name     string
+		failure  wakatimetest.Failure
+		branches []string
+		starts   []float64
+		requests int
+		wantErr  bool
+	}{
+		{
+			name: "ok",
+			// days stay in order and each day follows the branches the api lists
+			branches: []string{"feature", "main", "", "feature"},
+			starts:   []float64{at(0, "10h"), at(0, "9h"), at(2, "9h"), at(3, "12h")},
+			// a request for each of the four days and one for each branch on a day
+			requests: 4 + 2 + 1,
+		},
+		{name: "server error", failure: wakatimetest.FailureServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := wakatimetest.NewServer(
+				wakatimetest.WithFailure(tt.failure),
+				wakatimetest.WithClock(func() time.Time { return since.AddDate(0, 0, 3) }),
+				wakatimetest.WithHeartbeats(
+					heartbeat("main", 0, "9h"), heartbeat("main", 0, "9h1m"), heartbeat("main", 0, "9h2m"),
+					heartbeat("feature", 0, "10h"), heartbeat("feature", 0, "10h1m"),
+					heartbeat("", 2, "9h"), heartbeat("", 2, "9h1m"),
+					heartbeat("feature", 3, "12h"), heartbeat("feature", 3, "12h5m"),
+				),
+			)
+			defer server.Close()
+
+			client := server.Client()
+			client.Retry = wakatime.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+			spans, err := branchSpans(context.Background(), client, "akami", since, since.AddDate(0, 0, 3))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var branches []string
+			var starts []float64
+			for _, span := range spans {
+				branches = append(branches, span.Branch)
+				starts = append(starts, float64(span.Start.Unix()))
+			}
+			if !slices.Equal(branches, tt.branches) {
+				t.Errorf("branches = %q, want %q", branches, tt.branches)
+			}
+			if !slices.Equal(starts, tt.starts) {
+				t.Errorf("starts = %v, want %v", starts, tt.starts)
+			}
+			if got := len(server.Requests()); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
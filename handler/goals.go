@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/goals"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// GoalsReport is the machine-readable result of akami goals
+type GoalsReport struct {
+	// ServerGoals is false when the server doesn't have the goals api
+	ServerGoals bool         `json:"server_goals" yaml:"server_goals"`
+	Goals       []GoalReport `json:"goals" yaml:"goals"`
+}
+
+// GoalReport is a goal and how it has gone lately
+type GoalReport struct {
+	ID            string             `json:"id,omitempty" yaml:"id,omitempty"`
+	Name          string             `json:"name" yaml:"name"`
+	Source        string             `json:"source" yaml:"source"`
+	Delta         string             `json:"delta" yaml:"delta"`
+	TargetSeconds float64            `json:"target_seconds" yaml:"target_seconds"`
+	Languages     []string           `json:"languages,omitempty" yaml:"languages,omitempty"`
+	Projects      []string           `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Status        string             `json:"status" yaml:"status"`
+	Periods       []GoalPeriodReport `json:"periods" yaml:"periods"`
+}
+
+// GoalPeriodReport is how a goal went over one day or week
+type GoalPeriodReport struct {
+	Start         string  `json:"start" yaml:"start"`
+	End           string  `json:"end" yaml:"end"`
+	ActualSeconds float64 `json:"actual_seconds" yaml:"actual_seconds"`
+	TargetSeconds float64 `json:"target_seconds" yaml:"target_seconds"`
+	Status        string  `json:"status" yaml:"status"`
+}
+
+// newGoalReport turns a goal's progress into its machine-readable form
+func newGoalReport(progress goals.Progress) GoalReport {
+	report := GoalReport{
+		ID:            progress.ID,
+		Name:          progress.Name,
+		Source:        progress.Source,
+		Delta:         progress.Delta,
+		TargetSeconds: progress.Target,
+		Languages:     progress.Languages,
+		Projects:      progress.Projects,
+		Periods:       []GoalPeriodReport{},
+	}
+	if current, ok := progress.Current(); ok {
+		report.Status = current.Status
+	}
+	for _, period := range progress.Periods {
+		report.Periods = append(report.Periods, GoalPeriodReport{
+			Start:         period.Start.Format(wakatime.DateFormat),
+			End:           period.End.Format(wakatime.DateFormat),
+			ActualSeconds: period.Actual,
+			TargetSeconds: period.Target,
+			Status:        period.Status,
+		})
+	}
+
+	return report
+}
+
+// goalReports turns every goal's progress into its machine-readable form
+func goalReports(progress []goals.Progress) []GoalReport {
+	var reports []GoalReport
+	for _, goal := range progress {
+		reports = append(reports, newGoalReport(goal))
+	}
+
+	return reports
+}
+
+// openGoals loads akami's local goals from their default location
+func openGoals() (*goals.Store, error) {
+	path, err := goals.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return goals.Load(path)
+}
+
+// loadGoals fetches the goals set on the server and works out how the local goals
+// are doing. Servers without the goals api aren't an error; supported reports
+// whether the server had one.
+func loadGoals(ctx context.Context, client *wakatime.Client, now time.Time) (progress []goals.Progress, supported bool, err error) {
+	supported = true
+	resp, err := client.GetGoalsContext(ctx)
+	if errors.Is(err, wakatime.ErrGoalsUnsupported) {
+		supported = false
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	for _, goal := range resp.Data {
+		if goal.IsEnabled && !goal.IsSnoozed {
+			progress = append(progress, goals.FromAPI(goal, now.Location()))
+		}
+	}
+
+	store, err := openGoals()
+	if err != nil {
+		return nil, supported, err
+	}
+
+	fetch := goals.NewFetcher(client)
+	for _, goal := range store.List() {
+		evaluated, err := goals.Evaluate(ctx, goal, fetch, now, goals.DefaultPeriods)
+		if err != nil {
+			return nil, supported, fmt.Errorf("couldn't work out how %s is going: %w", goal.Name(), err)
+		}
+		progress = append(progress, evaluated)
+	}
+
+	return progress, supported, nil
+}
+
+// goalProgress draws the current period of every goal as a progress bar followed by its recent history
+func goalProgress(title string, progress []goals.Progress, width int) render.Progress {
+	chart := render.Progress{Title: title, Width: width}
+	for _, goal := range progress {
+		current, ok := goal.Current()
+		if !ok {
+			continue
+		}
+
+		every := "today"
+		if goal.Delta == wakatime.GoalWeekly {
+			every = "this week"
+		}
+
+		chart.Bars = append(chart.Bars, render.ProgressBar{
+			Label:  goal.Name,
+			Value:  current.Actual,
+			Target: current.Target,
+			Text:   fmt.Sprintf("%s of %s %s", utils.ShortTime(current.Actual), utils.ShortTime(current.Target), every),
+			Suffix: goalHistory(goal.Periods),
+		})
+	}
+
+	return chart
+}
+
+// goalHistory draws a goal's periods oldest first as a row of status icons
+func goalHistory(periods []goals.Period) string {
+	var icons []string
+	for _, period := range periods {
+		icons = append(icons, goalIcon(period.Status))
+	}
+
+	return strings.Join(icons, "")
+}
+
+// goalIcon is the icon for how a period went
+func goalIcon(status string) string {
+	switch status {
+	case wakatime.GoalSuccess:
+		return styles.Success.Render("✔")
+	case wakatime.GoalFail:
+		return styles.Bad.Render("✘")
+	case wakatime.GoalIgnored:
+		return styles.Muted.Render("-")
+	}
+
+	return styles.Muted.Render("·")
+}
+
+// GoalsList shows every goal with its progress this period and how the last few went
+func GoalsList(c *cobra.Command, _ []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Fetching goals")
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	progress, supported, err := loadGoals(c.Context(), client, time.Now())
+	if err != nil {
+		errorTask(c, "Fetching goals")
+		return err
+	}
+
+	if supported {
+		completeTask(c, "Fetching goals")
+	} else {
+		warnTask(c, "The server doesn't support goals so only local ones are shown")
+	}
+
+	if isStructured(c) {
+		report := GoalsReport{ServerGoals: supported, Goals: goalReports(progress)}
+		if report.Goals == nil {
+			report.Goals = []GoalReport{}
+		}
+		return writeOutput(c, format, report)
+	}
+
+	if len(progress) == 0 {
+		c.Printf("\n🌱 you don't have any goals yet; add one with %s\n", styles.Muted.Render("akami goals add <name> --daily 1h"))
+		return nil
+	}
+
+	c.Println()
+	c.Print(goalProgress("Goals:", progress, render.TerminalWidth(c.OutOrStderr())).String())
+	c.Printf("\n%s\n", styles.Muted.Render(fmt.Sprintf("history covers the last %d days or weeks, oldest first", goals.DefaultPeriods)))
+
+	return nil
+}
+
+// GoalShow shows how a single goal went day by day or week by week
+func GoalShow(c *cobra.Command, args []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Fetching goals")
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	progress, _, err := loadGoals(c.Context(), client, time.Now())
+	if err != nil {
+		errorTask(c, "Fetching goals")
+		return err
+	}
+
+	completeTask(c, "Fetching goals")
+
+	var goal *goals.Progress
+	for i := range progress {
+		if progress[i].Name == args[0] || (progress[i].ID != "" && progress[i].ID == args[0]) {
+			goal = &progress[i]
+			break
+		}
+	}
+	if goal == nil {
+		return fmt.Errorf("%w: %s; run akami goals to see the ones you have", goals.ErrNotFound, args[0])
+	}
+
+	if isStructured(c) {
+		return writeOutput(c, format, newGoalReport(*goal))
+	}
+
+	c.Printf("\n🎯 %s %s\n", styles.Fancy.Render(goal.Name), styles.Muted.Render("("+goal.Source+" goal)"))
+	c.Printf("%s\n\n", styles.Muted.Render(goal.Describe(utils.ShortTime)))
+
+	met := 0
+	for i := len(goal.Periods) - 1; i >= 0; i-- {
+		period := goal.Periods[i]
+		if period.Status == wakatime.GoalSuccess {
+			met++
+		}
+
+		when := period.Start.Format("Mon Jan 2")
+		if goal.Delta == wakatime.GoalWeekly {
+			when = "week of " + period.Start.Format("Jan 2")
+		}
+		c.Printf("  %s %s  %s %s\n",
+			goalIcon(period.Status),
+			styles.Fancy.Render(render.Pad(when, 16)),
+			utils.ShortTime(period.Actual),
+			styles.Muted.Render("of "+utils.ShortTime(period.Target)),
+		)
+	}
+
+	c.Printf("\nYou met it %s of the last %s\n", styles.Fancy.Render(fmt.Sprint(met)), styles.Fancy.Render(fmt.Sprintf("%d %ss", len(goal.Periods), goal.Delta)))
+
+	return nil
+}
+
+// GoalAdd saves a local goal that akami evaluates itself
+func GoalAdd(c *cobra.Command, args []string) error {
+	store, err := openGoals()
+	if err != nil {
+		return err
+	}
+
+	daily, _ := c.Flags().GetDuration("daily")
+	weekly, _ := c.Flags().GetDuration("weekly")
+	force, _ := c.Flags().GetBool("force")
+
+	goal := goals.Goal{}
+	goal.Languages, _ = c.Flags().GetStringSlice("language")
+	goal.Projects, _ = c.Flags().GetStringSlice("project")
+
+	switch {
+	case daily > 0 && weekly > 0:
+		return errors.New("a goal is either daily or weekly; pass only one of --daily or --weekly")
+	case daily > 0:
+		goal.Delta, goal.Target = wakatime.GoalDaily, daily
+	case weekly > 0:
+		goal.Delta, goal.Target = wakatime.GoalWeekly, weekly
+	default:
+		return errors.New("a goal needs a target; pass how long to code with --daily or --weekly")
+	}
+
+	if err := store.Add(args[0], goal, force); errors.Is(err, goals.ErrExists) {
+		return fmt.Errorf("%w; pass --force to replace it", err)
+	} else if err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	goal, _ = store.Get(args[0])
+	described := goals.Progress{Delta: goal.Delta, Target: goal.Target.Seconds(), Languages: goal.Languages, Projects: goal.Projects}
+	c.Printf("🎯 saved the %s goal: %s\n", styles.Fancy.Render(args[0]), described.Describe(utils.ShortTime))
+
+	return nil
+}
+
+// GoalRemove deletes a local goal
+func GoalRemove(c *cobra.Command, args []string) error {
+	store, err := openGoals()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Remove(args[0]); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	c.Printf("🗑️ removed the %s goal\n", styles.Fancy.Render(args[0]))
+
+	return nil
+}
+
+// CompleteGoals suggests local goal names for shell completion
+func CompleteGoals(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	store, err := openGoals()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return store.Names(), cobra.ShellCompDirectiveNoFileComp
+}
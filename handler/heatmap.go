@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// HeatmapReport is the machine-readable result of akami heatmap
+type HeatmapReport struct {
+	From         string       `json:"from" yaml:"from"`
+	To           string       `json:"to" yaml:"to"`
+	TotalSeconds float64      `json:"total_seconds" yaml:"total_seconds"`
+	ActiveDays   int          `json:"active_days" yaml:"active_days"`
+	Days         []HeatmapDay `json:"days" yaml:"days"`
+}
+
+// HeatmapDay is the time coded on a single day
+type HeatmapDay struct {
+	Date         string  `json:"date" yaml:"date"`
+	TotalSeconds float64 `json:"total_seconds" yaml:"total_seconds"`
+}
+
+// Heatmap draws a year of coding as a GitHub style contribution grid and can save it as an image
+func Heatmap(c *cobra.Command, _ []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Validating arguments")
+
+	year, _ := c.Flags().GetInt("year")
+	svgPath, _ := c.Flags().GetString("svg")
+	pngPath, _ := c.Flags().GetString("png")
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start, end := today.AddDate(-1, 0, 1), today
+	if year != 0 {
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(1, 0, -1)
+		if start.After(today) {
+			errorTask(c, "Validating arguments")
+			return fmt.Errorf("%d hasn't happened yet", year)
+		}
+		if end.After(today) {
+			end = today
+		}
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	printTask(c, "Fetching daily totals")
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	summaries, err := client.GetSummariesContext(c.Context(), start, end, wakatime.SummariesOptions{})
+	if err != nil {
+		errorTask(c, "Fetching daily totals")
+		return err
+	}
+
+	completeTask(c, "Fetching daily totals")
+
+	report := HeatmapReport{From: start.Format(wakatime.DateFormat), To: end.Format(wakatime.DateFormat), Days: []HeatmapDay{}}
+	// the ends of the range go in first so the grid covers all of it even when the
+	// api leaves off empty days, and the real days that follow take their place
+	heatmap := render.Heatmap{Width: render.TerminalWidth(c.OutOrStderr()), Days: []render.HeatmapDay{{Date: start}, {Date: end}}}
+	for _, day := range summaries.Data {
+		date, err := time.ParseInLocation(wakatime.DateFormat, day.Range.Date, now.Location())
+		if err != nil {
+			continue
+		}
+
+		seconds := day.GrandTotal.TotalSeconds
+		report.Days = append(report.Days, HeatmapDay{Date: day.Range.Date, TotalSeconds: seconds})
+		report.TotalSeconds += seconds
+		if seconds > 0 {
+			report.ActiveDays++
+		}
+
+		heatmap.Days = append(heatmap.Days, render.HeatmapDay{Date: date, Value: seconds, Text: utils.PrettyPrintTime(int(seconds))})
+	}
+
+	for _, export := range []struct {
+		path  string
+		write func(*bytes.Buffer) error
+	}{
+		{svgPath, func(b *bytes.Buffer) error { return heatmap.WriteSVG(b) }},
+		{pngPath, func(b *bytes.Buffer) error { return heatmap.WritePNG(b) }},
+	} {
+		if export.path == "" {
+			continue
+		}
+
+		printTask(c, "Saving "+export.path)
+
+		var buf bytes.Buffer
+		if err := export.write(&buf); err != nil {
+			errorTask(c, "Saving "+export.path)
+			return err
+		}
+		if err := os.WriteFile(export.path, buf.Bytes(), 0644); err != nil {
+			errorTask(c, "Saving "+export.path)
+			return err
+		}
+
+		completeTask(c, "Saving "+export.path)
+	}
+
+	if isStructured(c) {
+		return writeOutput(c, format, report)
+	}
+
+	c.Printf("\nYou coded on %s of %s days for a total of %s\n\n",
+		styles.Fancy.Render(fmt.Sprint(report.ActiveDays)),
+		styles.Fancy.Render(fmt.Sprint(utils.DaysBetween(start, end)+1)),
+		styles.Fancy.Render(utils.PrettyPrintTime(int(report.TotalSeconds))),
+	)
+	c.Println(heatmap.String())
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/shell"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// ShellInit prints the hooks that make a shell call akami hook around every command
+func ShellInit(c *cobra.Command, args []string) error {
+	if err := refuseKeyFlag(c, "the shell hooks"); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "akami"
+	}
+
+	// the hooks run akami for the account shell-init was run for
+	script, err := shell.Script(args[0], append([]string{exe, "hook"}, accountFlags(c)...))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(c.OutOrStdout(), script)
+	return nil
+}
+
+// Hook records a command run in a terminal as an app heartbeat. Shell hooks run it
+// around every command so it never touches the network: the heartbeat goes into a
+// spool and a sender is started in the background to send it.
+func Hook(c *cobra.Command, _ []string) error {
+	dir, err := shell.Dir()
+	if err != nil {
+		return err
+	}
+	if send, _ := c.Flags().GetBool("send"); send {
+		return sendHookHeartbeats(c, dir)
+	}
+
+	command, _ := c.Flags().GetString("command")
+	cwd, _ := c.Flags().GetString("cwd")
+	throttle, _ := c.Flags().GetDuration("throttle")
+	program := shell.Program(command)
+	if program == "" {
+		return nil
+	}
+	if cwd == "" {
+		if cwd, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// a broken state only loses the throttle so it's simply started again
+	state, _ := shell.LoadState(filepath.Join(dir, "state.json"))
+
+	now := time.Now()
+	category := shell.Categorize(command)
+	opts := wakatime.FileOptions{Config: cfg, Category: category, Time: now, Plugin: shell.Plugin}
+	info, err := wakatime.DetectFile(cwd, opts)
+	if err != nil {
+		return err
+	}
+
+	// time outside of projects would all pile up under unknown so it's left out
+	filtered := wakatime.Filter(info, cfg)
+	if info.Project == "" || filtered.Skipped {
+		return nil
+	}
+	if !state.Allow(program, info.Project, category, now, throttle) {
+		return nil
+	}
+
+	heartbeat := filtered.Apply(info.Heartbeat(opts), info)
+	heartbeat.Entity, heartbeat.Type = program, "app"
+
+	spool, err := wakatime.OpenQueue(filepath.Join(dir, "spool.jsonl"))
+	if err != nil {
+		return err
+	}
+	if err := spool.Push(heartbeat); err != nil {
+		return err
+	}
+
+	if state.NeedsSender(now) {
+		state.Sending = now
+		if err := state.Save(); err != nil {
+			return err
+		}
+		startInBackground(c, "hook", "--send")
+		return nil
+	}
+
+	return state.Save()
+}
+
+// sendHookHeartbeats sends everything in the spool. Heartbeats that can't be sent
+// because the api is unreachable move to the offline queue so they're sent by the
+// next akami queue flush instead of piling up in the spool. Ones the server has a
+// problem with stay in the spool for the next send.
+func sendHookHeartbeats(c *cobra.Command, dir string) error {
+	spool, err := wakatime.OpenQueue(filepath.Join(dir, "spool.jsonl"))
+	if err != nil {
+		return err
+	}
+
+	queued, err := spool.List()
+	if err != nil {
+		return err
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err == nil && len(queued) > 0 {
+		client := wakatime.NewClientWithOptions(api_key, api_url)
+		if client.Queue, err = openQueue(); err == nil {
+			heartbeats := make([]wakatime.Heartbeat, len(queued))
+			ids := make([]string, len(queued))
+			for i, item := range queued {
+				heartbeats[i], ids[i] = item.Heartbeat, item.ID
+			}
+
+			var results []wakatime.HeartbeatResult
+			results, err = client.SendHeartbeatsContext(c.Context(), heartbeats)
+
+			// only drop what the server accepted or will never accept; the rest waits for the next send
+			done := []string{}
+			for i, result := range results {
+				switch {
+				case result.OK():
+					done = append(done, ids[i])
+				case result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests:
+					done = append(done, ids[i])
+				}
+			}
+			// anything that didn't get a result was moved to the offline queue
+			if errors.Is(err, wakatime.ErrQueued) {
+				done = append(done, ids[len(results):]...)
+			}
+
+			if removeErr := spool.Remove(done...); removeErr != nil && err == nil {
+				err = removeErr
+			}
+		}
+	}
+
+	// the state is read again since hooks may have changed it while this was sending
+	state, _ := shell.LoadState(filepath.Join(dir, "state.json"))
+	state.Sending = time.Time{}
+	state.Error = ""
+	if err != nil && !errors.Is(err, wakatime.ErrQueued) {
+		state.Error = strings.TrimSpace(err.Error())
+	}
+	if saveErr := state.Save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return err
+}
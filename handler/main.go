@@ -4,18 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/goals"
+	"github.com/taciturnaxolotl/akami/profile"
+	"github.com/taciturnaxolotl/akami/render"
 	"github.com/taciturnaxolotl/akami/styles"
 	"github.com/taciturnaxolotl/akami/utils"
 	"github.com/taciturnaxolotl/akami/wakatime"
-	"gopkg.in/ini.v1"
 )
 
 // Task status indicators
@@ -26,10 +30,19 @@ var TaskCompleted = "[*]"
 type taskState struct {
 	cancel  context.CancelFunc
 	message string
+	// structured is set when the command is emitting json or yaml so the spinner stays quiet
+	structured bool
+	// out is where the structured document goes, kept from before the pretty output was silenced
+	out io.Writer
 }
 
 // printTask prints a task with a spinning animation
 func printTask(c *cobra.Command, message string) {
+	// Nothing to animate when the output is meant for a script
+	if isStructured(c) {
+		return
+	}
+
 	// Create a cancellable context for this spinner
 	ctx, cancel := context.WithCancel(c.Context())
 
@@ -114,6 +127,19 @@ func warnTask(c *cobra.Command, message string) {
 	c.Printf("\r\033[K%s %s\n", styles.Warn.Render("[?]"), message)
 }
 
+// skipTask marks a task as skipped
+func skipTask(c *cobra.Command, message string) {
+	// Cancel spinner
+	if state, ok := c.Context().Value("taskState").(*taskState); ok && state.cancel != nil {
+		state.cancel()
+		// Small delay to ensure spinner is stopped
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Clear line and display skipped message
+	c.Printf("\r\033[K%s %s\n", styles.Muted.Render("[-]"), message)
+}
+
 var user_dir, err = os.UserHomeDir()
 
 var testHeartbeat = wakatime.Heartbeat{
@@ -131,178 +157,338 @@ var testHeartbeat = wakatime.Heartbeat{
 	Time:             float64(time.Now().Unix()),
 }
 
+// loadConfig loads the wakatime config, returning nil when there isn't one since
+// flags and the environment can cover everything it would
+func loadConfig() (*config.Config, error) {
+	wakatimePath, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := config.Load(wakatimePath)
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
 func getClientStuff(c *cobra.Command) (key string, url string, err error) {
-	configApiKey, _ := c.Flags().GetString("key")
-	configApiURL, _ := c.Flags().GetString("url")
+	flagAPIKey, _ := c.Flags().GetString("key")
+	flagAPIURL, _ := c.Flags().GetString("url")
 
-	// If either value is missing, try to load from config file
-	if configApiKey == "" || configApiURL == "" {
-		userDir, err := os.UserHomeDir()
+	name, active, err := activeProfile(c)
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return flagAPIKey, flagAPIURL, err
+	}
+	if active != nil {
+		creds, err := active.Credentials(c.Context(), name, config.Overrides{APIKey: flagAPIKey, APIURL: flagAPIURL})
 		if err != nil {
 			errorTask(c, "Validating arguments")
-			return configApiKey, configApiURL, err
+			return flagAPIKey, flagAPIURL, err
 		}
-		wakatimePath := filepath.Join(userDir, ".wakatime.cfg")
 
-		cfg, err := ini.Load(wakatimePath)
-		if err != nil {
+		if creds.APIKey.Value == "" || creds.APIURL.Value == "" {
 			errorTask(c, "Validating arguments")
-			return configApiKey, configApiURL, errors.New("config file not found and you haven't passed all arguments")
+			return creds.APIKey.Value, creds.APIURL.Value, fmt.Errorf("the %s profile needs both an api key and an api url; re-add it with akami profile add %s --force", name, name)
 		}
 
-		settings, err := cfg.GetSection("settings")
-		if err != nil {
-			errorTask(c, "Validating arguments")
-			return configApiKey, configApiURL, errors.New("no settings section in your config")
-		}
+		return creds.APIKey.Value, creds.APIURL.Value, nil
+	}
 
-		// Only load from config if not provided as parameter
-		if configApiKey == "" {
-			configApiKey = settings.Key("api_key").String()
-			if configApiKey == "" {
-				errorTask(c, "Validating arguments")
-				return configApiKey, configApiURL, errors.New("couldn't find an api_key in your config")
-			}
-		}
+	cfg, err := loadConfig()
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return flagAPIKey, flagAPIURL, err
+	}
 
-		if configApiURL == "" {
-			configApiURL = settings.Key("api_url").String()
-			if configApiURL == "" {
-				errorTask(c, "Validating arguments")
-				return configApiKey, configApiURL, errors.New("couldn't find an api_url in your config")
-			}
+	creds, err := config.Resolve(c.Context(), cfg, config.Overrides{APIKey: flagAPIKey, APIURL: flagAPIURL})
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return flagAPIKey, flagAPIURL, err
+	}
+	key, url = creds.APIKey.Value, creds.APIURL.Value
+
+	if key == "" || url == "" {
+		errorTask(c, "Validating arguments")
+		switch {
+		case cfg == nil:
+			return key, url, errors.New("config file not found and you haven't passed all arguments")
+		case cfg.File.Section("settings") == nil:
+			return key, url, errors.New("no settings section in your config")
+		case key == "":
+			return key, url, errors.New("couldn't find an api_key in your config")
+		default:
+			return key, url, errors.New("couldn't find an api_url in your config")
 		}
 	}
 
-	return configApiKey, configApiURL, nil
+	return key, url, nil
 }
 
-func Doctor(c *cobra.Command, _ []string) error {
-	// Initialize a new context with task state
-	c.SetContext(context.WithValue(context.Background(), "taskState", &taskState{}))
+func Doctor(c *cobra.Command, _ []string) (err error) {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
 
-	// check our os
-	printTask(c, "Checking operating system")
+	only, _ := c.Flags().GetStringSlice("only")
+	skip, _ := c.Flags().GetStringSlice("skip")
+	fix, _ := c.Flags().GetBool("fix")
+	yes, _ := c.Flags().GetBool("yes")
+	all, _ := c.Flags().GetBool("all-profiles")
 
-	os_name := runtime.GOOS
+	if fix && isStructured(c) && !yes {
+		return errors.New("--fix can't ask before writing your config when the output is json or yaml; pass --yes as well if you're sure")
+	}
 
-	user_dir, err := os.UserHomeDir()
+	// work out which profiles to check; the default one is the wakatime config
+	name, active, err := activeProfile(c)
 	if err != nil {
-		errorTask(c, "Checking operating system")
-		return errors.New("somehow your user doesn't exist? fairly sure this should never happen; plz report this to @krn on slack or via email at me@dunkirk.sh")
+		return err
 	}
-	hackatime_path := filepath.Join(user_dir, ".wakatime.cfg")
+	targets := []doctorTarget{{name: name, profile: active}}
+	if all {
+		store, err := openProfiles()
+		if err != nil {
+			return err
+		}
 
-	if os_name != "linux" && os_name != "darwin" && os_name != "windows" {
-		errorTask(c, "Checking operating system")
-		return errors.New("hmm you don't seem to be running a recognized os? you are listed as running " + styles.Fancy.Render(os_name) + "; can you plz report this to @krn on slack or via email at me@dunkirk.sh?")
+		targets = []doctorTarget{{name: profile.Default}}
+		for _, name := range store.Names() {
+			p := store.Profiles[name]
+			targets = append(targets, doctorTarget{name: name, profile: &p})
+		}
 	}
-	completeTask(c, "Checking operating system")
 
-	c.Printf("Looks like you are running %s so lets take a look at %s for your config\n\n", styles.Fancy.Render(os_name), styles.Muted.Render(hackatime_path))
+	var reports []*DoctorReport
+	failed := 0
+	for _, target := range targets {
+		if len(targets) > 1 {
+			c.Println(styles.Fancy.Render("🔍 checking the "+target.name+" profile") + "\n")
+		}
 
-	printTask(c, "Checking wakatime config file")
+		// later profiles only ask for the backend checks so config-lint and the offline
+		// queue aren't repeated; os and config still run again since the others depend on them
+		targetOnly := only
+		if len(reports) > 0 && len(only) == 0 {
+			targetOnly = profileChecks
+		}
 
-	rawCfg, err := os.ReadFile(hackatime_path)
-	if errors.Is(err, os.ErrNotExist) {
-		errorTask(c, "Checking wakatime config file")
-		return errors.New("you don't have a wakatime config file! go check " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + " for the instructions and then try this again")
+		report, outcomes, err := doctorProfile(c, target, targetOnly, skip, fix, yes)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+		failed += report.Summary[string(doctor.Fail)]
+
+		if !isStructured(c) {
+			c.Printf("\n%s passed, %s warnings, %s failed, %s skipped\n\n",
+				styles.Success.Render(fmt.Sprint(report.Summary[string(doctor.Pass)])),
+				styles.Warn.Render(fmt.Sprint(report.Summary[string(doctor.Warn)])),
+				styles.Bad.Render(fmt.Sprint(report.Summary[string(doctor.Fail)])),
+				styles.Muted.Render(fmt.Sprint(report.Summary[string(doctor.Skip)])),
+			)
+
+			if !fix && hasFixes(outcomes) {
+				c.Printf("Some of these can be fixed automatically; run %s to see what would change\n\n", styles.Muted.Render("akami doc --fix"))
+			}
+		}
 	}
 
-	cfg, err := ini.Load(rawCfg)
-	if err != nil {
-		errorTask(c, "Checking wakatime config file")
-		return errors.New(err.Error())
+	if isStructured(c) {
+		report := reports[0]
+		if all {
+			report.Profiles = reports[1:]
+			report.OK = failed == 0
+		}
+		if err := writeOutput(c, format, report); err != nil {
+			return err
+		}
 	}
 
-	settings, err := cfg.GetSection("settings")
-	if err != nil {
-		errorTask(c, "Checking wakatime config file")
-		return errors.New("wow! your config file seems to be messed up and doesn't have a settings heading; can you follow the instructions at " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + " to regenerate it?\n\nThe raw error we got was: " + err.Error())
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed; scroll up for how to fix them", failed)
 	}
-	completeTask(c, "Checking wakatime config file")
 
-	printTask(c, "Verifying API credentials")
+	c.Println("🥳 it worked! you are good to go! Happy coding 👋")
 
-	api_key := settings.Key("api_key").String()
-	api_url := settings.Key("api_url").String()
-	if api_key == "" {
-		errorTask(c, "Verifying API credentials")
-		return errors.New("hmm 🤔 looks like you don't have an api_key in your config file? are you sure you have followed the setup instructions at " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + " correctly?")
-	}
-	if api_url == "" {
-		errorTask(c, "Verifying API credentials")
-		return errors.New("hmm 🤔 looks like you don't have an api_url in your config file? are you sure you have followed the setup instructions at " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + " correctly?")
-	}
-	completeTask(c, "Verifying API credentials")
+	return nil
+}
 
-	printTask(c, "Validating API URL")
+// profileChecks are the checks run against each extra profile with --all-profiles
+var profileChecks = []string{"credentials", "api-url", "statusbar", "heartbeat"}
 
-	correctApiUrl := "https://hackatime.hackclub.com/api/hackatime/v1"
-	if api_url != correctApiUrl {
-		if api_url == "https://api.wakatime.com/api/v1" {
-			client := wakatime.NewClient(api_key)
-			_, err := client.GetStatusBar()
+// doctorTarget is a profile akami doc checks; a nil profile means the wakatime config
+type doctorTarget struct {
+	name    string
+	profile *profile.Profile
+}
 
-			if !errors.Is(err, wakatime.ErrUnauthorized) {
-				errorTask(c, "Validating API URL")
-				return errors.New("turns out you were connected to wakatime.com instead of hackatime; since your key seems to work if you would like to keep syncing data to wakatime.com as well as to hackatime you can either setup a realy serve like " + styles.Muted.Render("https://github.com/JasonLovesDoggo/multitime") + " or you can wait for " + styles.Muted.Render("https://github.com/hackclub/hackatime/issues/85") + " to get merged in hackatime and have it synced there :)\n\nIf you want to import your wakatime.com data into hackatime then you can use hackatime v1 temporarily to connect your wakatime account and import (in settings under integrations at " + styles.Muted.Render("https://waka.hackclub.com") + ") and then click the import from hackatime v1 button at " + styles.Muted.Render("https://hackatime.hackclub.com/my/settings") + ".\n\n If you have more questions feel free to reach out to me (hackatime v1 creator) on slack (at @krn) or via email at me@dunkirk.sh")
-			} else {
-				errorTask(c, "Validating API URL")
-				return errors.New("turns out your config is connected to the wrong api url and is trying to use wakatime.com to sync time but you don't have a working api key from them. Go to " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + " to run the setup script and fix your config file")
-			}
-		}
-		warnTask(c, "Validating API URL")
-		c.Printf("\nYour api url %s doesn't match the expected url of %s however if you are using a custom forwarder or are sure you know what you are doing then you are probably fine\n\n", styles.Muted.Render(api_url), styles.Muted.Render(correctApiUrl))
-	} else {
-		completeTask(c, "Validating API URL")
+// doctorProfile runs the checks against a single profile, applying fixes if asked
+func doctorProfile(c *cobra.Command, target doctorTarget, only []string, skip []string, fix bool, yes bool) (*DoctorReport, []doctor.Outcome, error) {
+	env, outcomes, err := runDoctor(c, target, only, skip)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	client := wakatime.NewClientWithOptions(api_key, api_url)
-	printTask(c, "Checking your coding stats for today")
+	report := &DoctorReport{Profile: target.name}
 
-	duration, err := client.GetStatusBar()
-	if err != nil {
-		errorTask(c, "Checking your coding stats for today")
-		if errors.Is(err, wakatime.ErrUnauthorized) {
-			return errors.New("Your config file looks mostly correct and you have the correct api url but when we tested your api_key it looks like it is invalid? Can you double check if the key in your config file is the same as at " + styles.Muted.Render("https://hackatime.hackclub.com/my/wakatime_setup") + "?")
+	// keep fixing until nothing else can be fixed; each round can unblock checks that were skipped
+	for round := 0; fix && round < 3; round++ {
+		applied, backup, err := applyFixes(c, env, outcomes, yes)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(applied) == 0 {
+			break
 		}
 
-		return errors.New("Something weird happened with the hackatime api; if the error doesn't make sense then please contact @krn on slack or via email at me@dunkirk.sh\n\n" + styles.Bad.Render("Full error: "+err.Error()))
+		report.FixesApplied = append(report.FixesApplied, applied...)
+		if report.Backup == "" {
+			report.Backup = backup
+		}
+
+		c.Println(styles.Fancy.Render("Checking again with the fixes applied...") + "\n")
+
+		env, outcomes, err = runDoctor(c, target, only, skip)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	completeTask(c, "Checking your coding stats for today")
 
-	c.Printf("Sweet!!! Looks like your hackatime is configured properly! Looks like you have coded today for %s\n\n", styles.Fancy.Render(utils.PrettyPrintTime(duration.Data.GrandTotal.TotalSeconds)))
+	report.OS = env.OS
+	report.ConfigPath = env.ConfigPath
+	report.Summary = map[string]int{}
+	for _, outcome := range outcomes {
+		report.add(outcome)
+	}
+	report.OK = report.Summary[string(doctor.Fail)] == 0
 
-	printTask(c, "Sending test heartbeat")
+	return report, outcomes, nil
+}
 
-	err = client.SendHeartbeat(testHeartbeat)
-	if err != nil {
-		errorTask(c, "Sending test heartbeat")
-		return errors.New("oh dear; looks like something went wrong when sending that heartbeat. " + styles.Bad.Render("Full error: \""+strings.TrimSpace(err.Error())+"\""))
+// doctorEnv sets up the environment the checks run in for a profile
+func doctorEnv(c *cobra.Command, target doctorTarget) *doctor.Env {
+	flagAPIKey, _ := c.Flags().GetString("key")
+	flagAPIURL, _ := c.Flags().GetString("url")
+	return &doctor.Env{
+		ProfileName:   target.name,
+		Profile:       target.profile,
+		Overrides:     config.Overrides{APIKey: flagAPIKey, APIURL: flagAPIURL},
+		TestHeartbeat: testHeartbeat,
 	}
-	completeTask(c, "Sending test heartbeat")
+}
 
-	c.Println("🥳 it worked! you are good to go! Happy coding 👋")
+func runDoctor(c *cobra.Command, target doctorTarget, only []string, skip []string) (*doctor.Env, []doctor.Outcome, error) {
+	env := doctorEnv(c, target)
+	outcomes, err := doctor.Default().Run(c.Context(), env, only, skip, doctor.Hooks{
+		Start: func(check doctor.Check) {
+			printTask(c, check.Description())
+		},
+		Done: func(check doctor.Check, result doctor.Result) {
+			switch result.Status {
+			case doctor.Pass:
+				completeTask(c, check.Description())
+			case doctor.Warn:
+				warnTask(c, check.Description())
+				for _, finding := range result.Findings {
+					if finding.Line > 0 {
+						warnTask(c, styles.Muted.Render(fmt.Sprintf("  line %d:", finding.Line))+" "+finding.Message)
+					} else {
+						warnTask(c, "  "+finding.Message)
+					}
+				}
+			case doctor.Fail:
+				errorTask(c, check.Description())
+			case doctor.Skip:
+				skipTask(c, check.Description()+" "+styles.Muted.Render("("+result.Message+")"))
+			}
 
-	return nil
+			if result.Detail != "" {
+				c.Printf("%s\n\n", result.Detail)
+			}
+			if result.Remediation != "" && result.Status != doctor.Pass {
+				c.Printf("\n%s\n\n", result.Remediation)
+			}
+		},
+	})
+
+	return env, outcomes, err
 }
 
 func TestHeartbeat(c *cobra.Command, args []string) error {
-	// Initialize a new context with task state
-	c.SetContext(context.WithValue(context.Background(), "taskState", &taskState{}))
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
 
 	printTask(c, "Validating arguments")
 
 	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
 
 	completeTask(c, "Arguments look fine!")
 
+	heartbeat := testHeartbeat
+	var report *FileReport
+	if file, _ := c.Flags().GetString("file"); file != "" {
+		printTask(c, "Looking at "+file)
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorTask(c, "Looking at "+file)
+			return err
+		}
+
+		opts := fileOptions(c, cfg)
+		info, err := wakatime.DetectFile(file, opts)
+		if err != nil {
+			errorTask(c, "Looking at "+file)
+			return err
+		}
+		if info.IsDir {
+			errorTask(c, "Looking at "+file)
+			return fmt.Errorf("%s is a folder; pass a file an editor would have open", file)
+		}
+
+		// send what a plugin would, which means following the config's exclude and hide settings
+		filtered := wakatime.Filter(info, cfg)
+		if filtered.Skipped {
+			errorTask(c, "Looking at "+file)
+			return fmt.Errorf("an editor wouldn't send anything for %s because %s; run akami explain %s for the details", file, filtered.Reason, file)
+		}
+
+		heartbeat = filtered.Apply(info.Heartbeat(opts), info)
+		report = newFileReport(info)
+
+		completeTask(c, "Looking at "+file)
+		printFileInfo(c, info)
+		if hidden := hiddenSettings(filtered); len(hidden) > 0 {
+			c.Println("🙈", styles.Muted.Render(strings.Join(hidden, ", ")+" will keep some of this from the server"))
+			c.Println()
+		}
+	}
+
 	printTask(c, "Loading api client")
 
 	client := wakatime.NewClientWithOptions(api_key, api_url)
-	_, err = client.GetStatusBar()
-	if err != nil {
+
+	// a heartbeat for a real file is worth keeping if the server can't be reached, but the
+	// made up one would only end up in someone's stats later so that one just fails
+	if report != nil {
+		client.Queue, err = openQueue()
+		if err != nil {
+			errorTask(c, "Loading api client")
+			return err
+		}
+	}
+
+	_, err = client.GetStatusBarContext(c.Context())
+	if err != nil && (client.Queue == nil || !errors.Is(err, wakatime.ErrSendingRequest)) {
 		errorTask(c, "Loading api client")
 		return err
 	}
@@ -313,9 +499,13 @@ func TestHeartbeat(c *cobra.Command, args []string) error {
 
 	printTask(c, "Sending test heartbeat")
 
-	err = client.SendHeartbeat(testHeartbeat)
+	err = client.SendHeartbeatContext(c.Context(), heartbeat)
 
-	if err != nil {
+	if errors.Is(err, wakatime.ErrQueued) {
+		warnTask(c, "Sending test heartbeat")
+		c.Println("📦 couldn't reach the server so the heartbeat was saved; run", styles.Muted.Render("akami queue flush"), "once you're back online")
+		return writeOutput(c, format, TestReport{APIURL: api_url, Queued: true, File: report})
+	} else if err != nil {
 		errorTask(c, "Sending test heartbeat")
 		return err
 	}
@@ -324,23 +514,51 @@ func TestHeartbeat(c *cobra.Command, args []string) error {
 
 	c.Println("❇️ test heartbeat sent!")
 
-	return nil
+	return writeOutput(c, format, TestReport{APIURL: api_url, Sent: true, File: report})
 }
 
 func Status(c *cobra.Command, args []string) error {
-	// Initialize a new context with task state
-	c.SetContext(context.WithValue(context.Background(), "taskState", &taskState{}))
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
 
 	printTask(c, "Validating arguments")
 
+	rangeName, _ := c.Flags().GetString("range")
+	from, _ := c.Flags().GetString("from")
+	to, _ := c.Flags().GetString("to")
+	rng, err := parseStatusRange(rangeName, from, to, time.Now())
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+
+	show, _ := c.Flags().GetStringSlice("show")
+	for _, section := range show {
+		if _, ok := statusSections[section]; !ok {
+			errorTask(c, "Validating arguments")
+			return fmt.Errorf("can't show %q; pick from %s", section, strings.Join(statusSectionNames, ", "))
+		}
+	}
+
+	chart, err := chartFlags(c)
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+
 	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
 
 	completeTask(c, "Arguments look fine!")
 
 	printTask(c, "Loading api client")
 
 	client := wakatime.NewClientWithOptions(api_key, api_url)
-	status, err := client.GetStatusBar()
+	status, err := client.GetStatusBarContext(c.Context())
 	if err != nil {
 		errorTask(c, "Loading api client")
 		return err
@@ -350,126 +568,199 @@ func Status(c *cobra.Command, args []string) error {
 
 	c.Printf("\nLooks like you have coded today for %s today!\n", styles.Fancy.Render(utils.PrettyPrintTime(status.Data.GrandTotal.TotalSeconds)))
 
-	summary, err := client.GetLast7Days()
+	period, err := loadPeriod(c, client, &rng)
 	if err != nil {
 		return err
 	}
 
-	c.Printf("You have averaged %s over the last 7 days\n\n", styles.Fancy.Render(utils.PrettyPrintTime(int(summary.Data.DailyAverage))))
-
-	// Display top 5 projects with progress bars
-	if len(summary.Data.Projects) > 0 {
-		c.Println(styles.Fancy.Render("Top Projects:"))
+	var goalProgresses []goals.Progress
+	if showGoals, _ := c.Flags().GetBool("goals"); showGoals {
+		printTask(c, "Fetching goals")
 
-		// Determine how many projects to show (up to 5)
-		count := min(5, len(summary.Data.Projects))
+		var supported bool
+		goalProgresses, supported, err = loadGoals(c.Context(), client, time.Now())
+		if err != nil {
+			errorTask(c, "Fetching goals")
+			return err
+		}
 
-		// Find the longest project name for formatting
-		longestName := 0
-		longestTime := 0
+		if supported {
+			completeTask(c, "Fetching goals")
+		} else {
+			warnTask(c, "The server doesn't support goals so only local ones are shown")
+		}
+	}
 
-		for i := range count {
-			project := summary.Data.Projects[i]
-			if len(project.Name) > longestName {
-				longestName = len(project.Name)
-			}
+	if isStructured(c) {
+		return writeOutput(c, format, StatusReport{
+			TodaySeconds:        status.Data.GrandTotal.TotalSeconds,
+			Range:               rng.Name,
+			From:                rng.Start.Format(wakatime.DateFormat),
+			To:                  rng.End.Format(wakatime.DateFormat),
+			TotalSeconds:        period.TotalSeconds,
+			DailyAverageSeconds: period.DailyAverage,
+			Projects:            rankedItems(period.Projects),
+			Languages:           rankedItems(period.Languages),
+			Editors:             rankedItems(period.Editors),
+			OperatingSystems:    rankedItems(period.OperatingSystems),
+			Machines:            rankedItems(period.Machines),
+			Categories:          rankedItems(period.Categories),
+			Dependencies:        rankedItems(period.Dependencies),
+			BestDay:             period.BestDay.Date,
+			BestDaySeconds:      period.BestDay.TotalSeconds,
+			Goals:               goalReports(goalProgresses),
+		})
+	}
 
-			timeStr := utils.PrettyPrintTime(int(project.TotalSeconds))
-			if len(timeStr) > longestTime {
-				longestTime = len(timeStr)
-			}
+	if rng.days() == 1 {
+		c.Printf("You coded for %s %s\n\n", styles.Fancy.Render(utils.PrettyPrintTime(int(period.TotalSeconds))), rng.Label)
+	} else {
+		c.Printf("You have averaged %s %s", styles.Fancy.Render(utils.PrettyPrintTime(int(period.DailyAverage))), rng.Label)
+		if period.BestDay.Date != "" {
+			c.Printf(" and your best day was %s with %s", styles.Fancy.Render(period.BestDay.Date), styles.Fancy.Render(utils.PrettyPrintTime(int(period.BestDay.TotalSeconds))))
 		}
+		c.Print("\n\n")
+	}
 
-		// Display each project with a bar
-		for i := range count {
-			project := summary.Data.Projects[i]
-
-			// Format the project name and time with padding
-			paddedName := fmt.Sprintf("%-*s", longestName+2, project.Name)
-			timeStr := utils.PrettyPrintTime(int(project.TotalSeconds))
-			paddedTime := fmt.Sprintf("%-*s", longestTime+2, timeStr)
-
-			// Create the progress bar
-			barWidth := 25
-			bar := ""
-			percentage := project.Percent
-			for j := range barWidth {
-				if float64(j) < percentage/(100/float64(barWidth)) {
-					bar += "█"
-				} else {
-					bar += "░"
-				}
-			}
+	printStatItems(c, chart, "Top Projects:", period.Projects, period.TotalSeconds)
+	printStatItems(c, chart, "Top Languages:", period.Languages, period.TotalSeconds)
+	for _, section := range show {
+		printStatItems(c, chart, statusSections[section].title, statusSections[section].items(period), period.TotalSeconds)
+	}
+	if len(goalProgresses) > 0 {
+		c.Println(goalProgress("Goals:", goalProgresses, chart.Width).String())
+	}
+
+	return nil
+}
+
+// statusSection is an extra breakdown akami status --show can add
+type statusSection struct {
+	title string
+	items func(statusPeriod) []wakatime.StatItem
+}
 
-			// Use different styles for different components
-			styledName := styles.Fancy.Render(paddedName)
-			styledTime := styles.Muted.Render(paddedTime)
-			styledBar := styles.Success.Render(bar)
-			styledPercent := styles.Warn.Render(fmt.Sprintf("%.2f%%", percentage))
+// statusSections are the breakdowns --show accepts
+var statusSections = map[string]statusSection{
+	"editors":      {"Top Editors:", func(p statusPeriod) []wakatime.StatItem { return p.Editors }},
+	"os":           {"Top Operating Systems:", func(p statusPeriod) []wakatime.StatItem { return p.OperatingSystems }},
+	"machines":     {"Top Machines:", func(p statusPeriod) []wakatime.StatItem { return p.Machines }},
+	"categories":   {"Top Categories:", func(p statusPeriod) []wakatime.StatItem { return p.Categories }},
+	"dependencies": {"Top Dependencies:", func(p statusPeriod) []wakatime.StatItem { return p.Dependencies }},
+}
 
-			// Print the formatted line
-			c.Printf("  %s %s %s  %s\n", styledName, styledTime, styledBar, styledPercent)
-		}
+// statusSectionNames are the --show values in the order they're listed in errors
+var statusSectionNames = []string{"editors", "os", "machines", "categories", "dependencies"}
+
+// chartFlags reads the --chart, --sort and --top flags into a chart to fill in with items
+func chartFlags(c *cobra.Command) (render.Chart, error) {
+	modeName, _ := c.Flags().GetString("chart")
+	sortName, _ := c.Flags().GetString("sort")
+	top, _ := c.Flags().GetInt("top")
+
+	mode, err := render.ParseMode(modeName)
+	if err != nil {
+		return render.Chart{}, err
+	}
+	order, err := render.ParseSort(sortName)
+	if err != nil {
+		return render.Chart{}, err
+	}
+	if top < 0 {
+		return render.Chart{}, fmt.Errorf("can't show the top %d; use 0 to show everything", top)
+	}
+
+	return render.Chart{Mode: mode, Sort: order, Top: top, Width: render.TerminalWidth(c.OutOrStderr())}, nil
+}
 
-		c.Println()
+// printStatItems draws a breakdown such as projects or languages as a chart
+func printStatItems(c *cobra.Command, chart render.Chart, title string, items []wakatime.StatItem, total float64) {
+	if len(items) == 0 {
+		return
 	}
 
-	// Display top 5 languages with progress bars
-	if len(summary.Data.Languages) > 0 {
-		c.Println(styles.Fancy.Render("Top Languages:"))
+	chart.Title = title
+	chart.Total = total
+	chart.Items = make([]render.Item, 0, len(items))
+	for _, item := range items {
+		chart.Items = append(chart.Items, render.Item{Label: item.Name, Value: item.TotalSeconds, Text: utils.PrettyPrintTime(int(item.TotalSeconds))})
+	}
 
-		// Determine how many languages to show (up to 5)
-		count := min(5, len(summary.Data.Languages))
+	c.Println(chart.String())
+}
 
-		// Find the longest language name for formatting
-		longestName := 0
-		longestTime := 0
+// statusPeriod is the coding activity over the range akami status reports on
+type statusPeriod struct {
+	TotalSeconds     float64
+	DailyAverage     float64
+	BestDay          wakatime.BestDay
+	Projects         []wakatime.StatItem
+	Languages        []wakatime.StatItem
+	Editors          []wakatime.StatItem
+	OperatingSystems []wakatime.StatItem
+	Machines         []wakatime.StatItem
+	Categories       []wakatime.StatItem
+	Dependencies     []wakatime.StatItem
+}
 
-		for i := range count {
-			language := summary.Data.Languages[i]
-			if len(language.Name) > longestName {
-				longestName = len(language.Name)
-			}
+// loadPeriod fetches the activity for a range. Ranges the stats endpoint precomputes
+// come from there, and since the api knows best when they start and end rng is
+// updated to match. Every other range is added up from the summaries endpoint.
+func loadPeriod(c *cobra.Command, client *wakatime.Client, rng *statusRange) (statusPeriod, error) {
+	if rng.Stats {
+		stats, err := client.GetStatsContext(c.Context(), rng.Name)
+		if err != nil {
+			return statusPeriod{}, err
+		}
 
-			timeStr := utils.PrettyPrintTime(int(language.TotalSeconds))
-			if len(timeStr) > longestTime {
-				longestTime = len(timeStr)
-			}
+		if start, err := time.ParseInLocation(wakatime.DateFormat, prefix(stats.Data.Start, len(wakatime.DateFormat)), rng.Start.Location()); err == nil {
+			rng.Start = start
+		}
+		if end, err := time.ParseInLocation(wakatime.DateFormat, prefix(stats.Data.End, len(wakatime.DateFormat)), rng.End.Location()); err == nil && !end.Before(rng.Start) {
+			rng.End = end
 		}
 
-		// Display each language with a bar
-		for i := range count {
-			language := summary.Data.Languages[i]
-
-			// Format the language name and time with padding
-			paddedName := fmt.Sprintf("%-*s", longestName+2, language.Name)
-			timeStr := utils.PrettyPrintTime(int(language.TotalSeconds))
-			paddedTime := fmt.Sprintf("%-*s", longestTime+2, timeStr)
-
-			// Create the progress bar
-			barWidth := 25
-			bar := ""
-			percentage := language.Percent
-			for j := range barWidth {
-				if float64(j) < percentage/(100/float64(barWidth)) {
-					bar += "█"
-				} else {
-					bar += "░"
-				}
-			}
+		return statusPeriod{
+			TotalSeconds:     stats.Data.TotalSeconds,
+			DailyAverage:     stats.Data.DailyAverage,
+			BestDay:          stats.Data.BestDay,
+			Projects:         stats.Data.Projects,
+			Languages:        stats.Data.Languages,
+			Editors:          stats.Data.Editors,
+			OperatingSystems: stats.Data.OperatingSystems,
+			Machines:         stats.Data.Machines,
+			Categories:       stats.Data.Categories,
+			Dependencies:     stats.Data.Dependencies,
+		}, nil
+	}
 
-			// Use different styles for different components
-			styledName := styles.Fancy.Render(paddedName)
-			styledTime := styles.Muted.Render(paddedTime)
-			styledBar := styles.Success.Render(bar)
-			styledPercent := styles.Warn.Render(fmt.Sprintf("%.2f%%", percentage))
+	summaries, err := client.GetSummariesContext(c.Context(), rng.Start, rng.End, wakatime.SummariesOptions{})
+	if err != nil {
+		return statusPeriod{}, err
+	}
 
-			// Print the formatted line
-			c.Printf("  %s %s %s  %s\n", styledName, styledTime, styledBar, styledPercent)
+	total := summaries.Total()
+	period := statusPeriod{
+		TotalSeconds:     total.GrandTotal.TotalSeconds,
+		DailyAverage:     total.GrandTotal.TotalSeconds / float64(rng.days()),
+		Projects:         total.Projects,
+		Languages:        total.Languages,
+		Editors:          total.Editors,
+		OperatingSystems: total.OperatingSystems,
+		Machines:         total.Machines,
+		Categories:       total.Categories,
+		Dependencies:     total.Dependencies,
+	}
+	for _, day := range summaries.Data {
+		if day.GrandTotal.TotalSeconds > period.BestDay.TotalSeconds {
+			period.BestDay = wakatime.BestDay{Date: day.Range.Date, TotalSeconds: day.GrandTotal.TotalSeconds, Text: day.GrandTotal.Text}
 		}
-
-		c.Println()
 	}
 
-	return nil
+	return period, nil
+}
+
+// prefix returns the first n bytes of s or all of s when it's shorter
+func prefix(s string, n int) string {
+	return s[:min(n, len(s))]
 }
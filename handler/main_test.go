@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+// setupHome points everything akami and wakatime-cli read at a fresh temp dir and
+// writes cfg as the wakatime config when it isn't empty
+func setupHome(t *testing.T, cfg string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("WAKATIME_HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("WAKATIME_API_KEY", "")
+
+	if cfg != "" {
+		if err := os.WriteFile(filepath.Join(dir, ".wakatime.cfg"), []byte(cfg), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// retry like the default policy without making tests wait
+	policy := wakatime.DefaultRetryPolicy
+	wakatime.DefaultRetryPolicy = wakatime.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	t.Cleanup(func() { wakatime.DefaultRetryPolicy = policy })
+
+	return dir
+}
+
+// syncBuffer is a bytes.Buffer the spinner goroutines can print into safely
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// runCommand runs a handler with the same global flags main.go sets up, pointed at
+// server, and returns what it wrote to stdout
+func runCommand(ctx context.Context, server *wakatimetest.Server, run func(*cobra.Command, []string) error, flags func(*pflag.FlagSet), args ...string) (string, error) {
+	c := &cobra.Command{Use: "akami", RunE: run, SilenceErrors: true, SilenceUsage: true}
+	c.PersistentFlags().StringP("url", "u", "", "")
+	c.PersistentFlags().StringP("key", "k", "", "")
+	c.PersistentFlags().StringP("output", "o", OutputText, "")
+	c.PersistentFlags().StringP("profile", "p", "", "")
+	if flags != nil {
+		flags(c.Flags())
+	}
+
+	// cobra prints the pretty text to the out writer too when one is set
+	var stdout syncBuffer
+	c.SetOut(&stdout)
+	c.SetErr(io.Discard)
+	c.SetArgs(append([]string{"--url", server.URL, "--key", server.APIKey}, args...))
+
+	err := c.ExecuteContext(ctx)
+
+	return stdout.String(), err
+}
+
+// failureCase is a way the server can misbehave and whether the command should survive it
+type failureCase struct {
+	name    string
+	failure wakatimetest.Failure
+	delay   time.Duration
+	timeout time.Duration
+	wantErr bool
+}
+
+var failureCases = []failureCase{
+	{name: "ok"},
+	{name: "unauthorized", failure: wakatimetest.FailureUnauthorized, wantErr: true},
+	{name: "server error", failure: wakatimetest.FailureServerError, wantErr: true},
+	{name: "slow", failure: wakatimetest.FailureSlow, delay: 20 * time.Millisecond},
+	{name: "slower than the deadline", failure: wakatimetest.FailureSlow, delay: time.Minute, timeout: 200 * time.Millisecond, wantErr: true},
+	{name: "malformed", failure: wakatimetest.FailureMalformed, wantErr: true},
+}
+
+// start starts a server failing the way the case asks and returns the context to run with
+func (fc failureCase) start(t *testing.T, opts ...wakatimetest.Option) (*wakatimetest.Server, context.Context) {
+	t.Helper()
+
+	server := wakatimetest.NewServer(append(opts, wakatimetest.WithFailure(fc.failure), wakatimetest.WithDelay(fc.delay))...)
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	if fc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fc.timeout)
+		t.Cleanup(cancel)
+	}
+
+	return server, ctx
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	coded := wakatimetest.WithHeartbeats(
+		wakatime.Heartbeat{Entity: "/code/akami/main.go", Type: "file", Project: "akami", Language: "Go", Time: float64(now.Add(-10 * time.Minute).Unix())},
+		wakatime.Heartbeat{Entity: "/code/akami/main.go", Type: "file", Project: "akami", Language: "Go", Time: float64(now.Add(-5 * time.Minute).Unix())},
+	)
+
+	for _, tt := range failureCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setupHome(t, "")
+			server, ctx := tt.start(t, coded, wakatimetest.WithClock(func() time.Time { return now }))
+
+			out, err := runCommand(ctx, server, Status, func(f *pflag.FlagSet) {
+				f.String("range", "", "")
+				f.String("from", "", "")
+				f.String("to", "", "")
+				f.StringSlice("show", nil, "")
+				f.Int("top", 5, "")
+				f.String("sort", "value", "")
+				f.String("chart", "horizontal", "")
+				f.Bool("goals", false, "")
+			}, "--output", OutputJSON)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got no error, want one (output %q)", out)
+				}
+				if out != "" {
+					t.Errorf("wrote %q to stdout, want nothing", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var report StatusReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("stdout isn't a json report: %v\n%s", err, out)
+			}
+			if report.TodaySeconds != 300 {
+				t.Errorf("today_seconds = %d, want 300", report.TodaySeconds)
+			}
+			if report.Range == "" || report.From == "" || report.To == "" {
+				t.Errorf("report is missing its range: %+v", report)
+			}
+		})
+	}
+}
+
+// testFlags are the flags akami test has
+func testFlags(f *pflag.FlagSet) {
+	f.String("file", "", "")
+	f.String("project", "", "")
+	f.String("language", "", "")
+	f.Bool("write", false, "")
+}
+
+func TestTestHeartbeat(t *testing.T) {
+	for _, tt := range failureCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setupHome(t, "")
+			server, ctx := tt.start(t)
+
+			out, err := runCommand(ctx, server, TestHeartbeat, testFlags, "--output", OutputJSON)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got no error, want one (output %q)", out)
+				}
+				if n := len(server.Heartbeats()); n != 0 {
+					t.Errorf("server has %d heartbeats, want none", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var report TestReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("stdout isn't a json report: %v\n%s", err, out)
+			}
+			if !report.Sent || report.Queued || report.APIURL != server.URL {
+				t.Errorf("report = %+v, want it sent to %s", report, server.URL)
+			}
+
+			hbs := server.Heartbeats()
+			if len(hbs) != 1 || hbs[0].Entity != testHeartbeat.Entity {
+				t.Errorf("server has %+v, want just the test heartbeat", hbs)
+			}
+		})
+	}
+}
+
+func TestTestHeartbeatOffline(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   bool
+		queued int
+	}{
+		// the made up heartbeat would only pollute someone's stats once it's flushed
+		{name: "made up heartbeat", file: false, queued: 0},
+		{name: "real file", file: true, queued: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupHome(t, "")
+			server := wakatimetest.NewServer()
+			// a closed server refuses connections like an unreachable one
+			server.Close()
+
+			args := []string{"--output", OutputJSON}
+			if tt.file {
+				path := filepath.Join(dir, "main.go")
+				if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--file", path)
+			}
+
+			out, err := runCommand(context.Background(), server, TestHeartbeat, testFlags, args...)
+			if tt.queued == 0 && err == nil {
+				t.Errorf("got no error, want one (output %q)", out)
+			}
+			if tt.queued > 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var report TestReport
+				if err := json.Unmarshal([]byte(out), &report); err != nil {
+					t.Fatalf("stdout isn't a json report: %v\n%s", err, out)
+				}
+				if report.Sent || !report.Queued {
+					t.Errorf("report = %+v, want it queued", report)
+				}
+			}
+
+			queue, err := openQueue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			queued, err := queue.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) != tt.queued {
+				t.Errorf("queued %d heartbeats, want %d", len(queued), tt.queued)
+			}
+		})
+	}
+}
+
+// doctorFlags are the flags akami doc has
+func doctorFlags(f *pflag.FlagSet) {
+	f.StringSlice("only", nil, "")
+	f.StringSlice("skip", nil, "")
+	f.Bool("fix", false, "")
+	f.BoolP("yes", "y", false, "")
+	f.Bool("all-profiles", false, "")
+}
+
+func TestDoctor(t *testing.T) {
+	for _, tt := range failureCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setupHome(t, "[settings]\ndebug = false\n")
+			server, ctx := tt.start(t)
+
+			out, err := runCommand(ctx, server, Doctor, doctorFlags, "--output", OutputJSON, "--only", "heartbeat")
+			if tt.wantErr != (err != nil) {
+				t.Errorf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+
+			// the report is written even when checks fail
+			var report DoctorReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("stdout isn't a json report: %v\n%s", err, out)
+			}
+			if report.OK == tt.wantErr {
+				t.Errorf("report ok = %v, want %v", report.OK, !tt.wantErr)
+			}
+
+			statuses := map[string]string{}
+			for _, check := range report.Checks {
+				statuses[check.ID] = check.Status
+			}
+
+			want := map[string]string{"statusbar": "pass", "heartbeat": "pass"}
+			if tt.wantErr {
+				want = map[string]string{"statusbar": "fail", "heartbeat": "skip"}
+			}
+			for id, status := range want {
+				if statuses[id] != status {
+					t.Errorf("%s is %q, want %q (checks %v)", id, statuses[id], status, statuses)
+				}
+			}
+
+			if n := len(server.Heartbeats()); tt.wantErr == (n > 0) {
+				t.Errorf("server has %d heartbeats", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+// ServeMock runs the fake hackatime api on localhost until interrupted
+func ServeMock(c *cobra.Command, _ []string) error {
+	addr, _ := c.Flags().GetString("addr")
+	key, _ := c.Flags().GetString("key")
+	failName, _ := c.Flags().GetString("fail")
+	delay, _ := c.Flags().GetDuration("delay")
+	empty, _ := c.Flags().GetBool("empty")
+	goals, _ := c.Flags().GetBool("goals")
+
+	failure, err := wakatimetest.ParseFailure(failName)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		key = wakatimetest.DefaultAPIKey
+	}
+
+	opts := []wakatimetest.Option{
+		wakatimetest.WithAPIKey(key),
+		wakatimetest.WithFailure(failure),
+		wakatimetest.WithDelay(delay),
+		wakatimetest.WithRequestLog(c.OutOrStderr()),
+	}
+	if !empty {
+		opts = append(opts, wakatimetest.WithHeartbeats(wakatimetest.DemoHeartbeats(time.Now(), 7, 1)...))
+	}
+	if goals {
+		opts = append(opts, wakatimetest.WithGoals(wakatimetest.DemoGoals()...))
+	}
+	mock := wakatimetest.New(opts...)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	url := "http://" + listener.Addr().String() + "/api/hackatime/v1"
+
+	c.Printf("🌷 mock hackatime is running at %s\n\n", styles.Fancy.Render(url))
+	c.Printf("api key: %s\n", styles.Muted.Render(key))
+	if failure != wakatimetest.FailureNone {
+		c.Printf("failing every request with: %s\n", styles.Warn.Render(string(failure)))
+	}
+	c.Printf("\ntry it with %s\n", styles.Muted.Render("akami -u "+url+" -k "+key+" status"))
+	c.Printf("or save it with %s\n\n", styles.Muted.Render("akami profile add mock -u "+url+" -k "+key))
+
+	ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Handler: mock}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	c.Println("\n👋 mock server stopped")
+	return nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+func OfflineFlush(c *cobra.Command, _ []string) error {
+	// Initialize a new context with task state
+	c.SetContext(context.WithValue(c.Context(), "taskState", &taskState{}))
+
+	printTask(c, "Validating arguments")
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	retries, _ := c.Flags().GetInt("retries")
+
+	printTask(c, "Reading wakatime-cli offline queue")
+
+	path, err := wakatime.OfflineQueuePath()
+	if err != nil {
+		errorTask(c, "Reading wakatime-cli offline queue")
+		return err
+	}
+
+	heartbeats, err := wakatime.ReadOfflineQueue(path)
+	if errors.Is(err, os.ErrNotExist) {
+		completeTask(c, "Reading wakatime-cli offline queue")
+		c.Println("🌱 wakatime-cli doesn't have an offline queue; nothing to flush!")
+		return nil
+	} else if errors.Is(err, wakatime.ErrOfflineQueueBusy) {
+		errorTask(c, "Reading wakatime-cli offline queue")
+		return errors.New("wakatime-cli is busy with its offline queue right now; give it a second and try again")
+	} else if err != nil {
+		errorTask(c, "Reading wakatime-cli offline queue")
+		return err
+	}
+
+	completeTask(c, "Reading wakatime-cli offline queue")
+
+	if len(heartbeats) == 0 {
+		c.Println("🌱 wakatime-cli's offline queue is empty; nothing to flush!")
+		return nil
+	}
+
+	c.Printf("Found %s heartbeats in %s\n\n", styles.Fancy.Render(fmt.Sprint(len(heartbeats))), styles.Muted.Render(path))
+
+	client := flushClient(api_key, api_url, retries)
+
+	sent, rejected := 0, 0
+	for start := 0; start < len(heartbeats); start += wakatime.MaxBulkHeartbeats {
+		batch := heartbeats[start:min(start+wakatime.MaxBulkHeartbeats, len(heartbeats))]
+		message := fmt.Sprintf("Sending heartbeats %d-%d of %d", start+1, start+len(batch), len(heartbeats))
+
+		printTask(c, message)
+
+		toSend := make([]wakatime.Heartbeat, len(batch))
+		for i, item := range batch {
+			toSend[i] = item.Heartbeat
+		}
+
+		results, err := client.SendHeartbeatsContext(c.Context(), toSend)
+		if err != nil {
+			errorTask(c, message)
+			return err
+		}
+
+		// only delete what the server accepted or will never accept
+		done := []string{}
+		for i, result := range results {
+			switch {
+			case result.OK():
+				sent++
+				done = append(done, batch[i].Key)
+			case result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests:
+				rejected++
+				done = append(done, batch[i].Key)
+			}
+		}
+
+		if err := wakatime.RemoveOfflineHeartbeats(path, done...); err != nil {
+			errorTask(c, message)
+			if errors.Is(err, wakatime.ErrOfflineQueueBusy) {
+				return errors.New("the heartbeats were sent but wakatime-cli grabbed its offline queue before we could clear them out; they'll be deduplicated by the server when it sends them again")
+			}
+			return err
+		}
+
+		completeTask(c, message)
+	}
+
+	c.Printf("\n❇️ flushed %s heartbeats from wakatime-cli's offline queue", styles.Fancy.Render(fmt.Sprint(sent)))
+	if rejected > 0 {
+		c.Printf(" and dropped %s that the server rejected", styles.Bad.Render(fmt.Sprint(rejected)))
+	}
+	c.Println()
+
+	return nil
+}
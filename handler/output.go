@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/doctor"
+	"github.com/taciturnaxolotl/akami/git"
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by the --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// CheckReport is the machine-readable result of a single doctor check
+type CheckReport struct {
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description" yaml:"description"`
+	Status      string `json:"status" yaml:"status"`
+	Message     string `json:"message" yaml:"message"`
+	Remediation string `json:"remediation,omitempty" yaml:"remediation,omitempty"`
+	// Findings are the individual problems behind a warning such as bad config lines
+	Findings []FindingReport `json:"findings,omitempty" yaml:"findings,omitempty"`
+}
+
+// FindingReport is a single problem found by a doctor check
+type FindingReport struct {
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// DoctorReport is the machine-readable result of akami doc
+type DoctorReport struct {
+	OK         bool           `json:"ok" yaml:"ok"`
+	Profile    string         `json:"profile" yaml:"profile"`
+	OS         string         `json:"os" yaml:"os"`
+	ConfigPath string         `json:"config_path" yaml:"config_path"`
+	Summary    map[string]int `json:"summary" yaml:"summary"`
+	Checks     []CheckReport  `json:"checks" yaml:"checks"`
+	// FixesApplied lists the fixes --fix wrote to the config file
+	FixesApplied []string `json:"fixes_applied,omitempty" yaml:"fixes_applied,omitempty"`
+	// Backup is where the config file was backed up to before it was fixed
+	Backup string `json:"backup,omitempty" yaml:"backup,omitempty"`
+	// Profiles are the reports for the other profiles when every profile was checked
+	Profiles []*DoctorReport `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// add records the outcome of a check with any terminal styling stripped from the text
+func (r *DoctorReport) add(outcome doctor.Outcome) {
+	r.Summary[string(outcome.Result.Status)]++
+
+	var findings []FindingReport
+	for _, finding := range outcome.Result.Findings {
+		findings = append(findings, FindingReport{Line: finding.Line, Message: finding.Message})
+	}
+
+	r.Checks = append(r.Checks, CheckReport{
+		ID:          outcome.Check.ID(),
+		Description: outcome.Check.Description(),
+		Status:      string(outcome.Result.Status),
+		Message:     ansi.Strip(outcome.Result.Message),
+		Remediation: ansi.Strip(outcome.Result.Remediation),
+		Findings:    findings,
+	})
+}
+
+// RankedItem is a single named total such as a project or language
+type RankedItem struct {
+	Name         string  `json:"name" yaml:"name"`
+	TotalSeconds float64 `json:"total_seconds" yaml:"total_seconds"`
+	Percent      float64 `json:"percent" yaml:"percent"`
+	Text         string  `json:"text" yaml:"text"`
+}
+
+// StatusReport is the machine-readable result of akami status
+type StatusReport struct {
+	TodaySeconds        int          `json:"today_seconds" yaml:"today_seconds"`
+	Range               string       `json:"range" yaml:"range"`
+	From                string       `json:"from" yaml:"from"`
+	To                  string       `json:"to" yaml:"to"`
+	TotalSeconds        float64      `json:"total_seconds" yaml:"total_seconds"`
+	DailyAverageSeconds float64      `json:"daily_average_seconds" yaml:"daily_average_seconds"`
+	Projects            []RankedItem `json:"projects" yaml:"projects"`
+	Languages           []RankedItem `json:"languages" yaml:"languages"`
+	Editors             []RankedItem `json:"editors" yaml:"editors"`
+	OperatingSystems    []RankedItem `json:"operating_systems" yaml:"operating_systems"`
+	Machines            []RankedItem `json:"machines" yaml:"machines"`
+	Categories          []RankedItem `json:"categories" yaml:"categories"`
+	Dependencies        []RankedItem `json:"dependencies" yaml:"dependencies"`
+	BestDay             string       `json:"best_day,omitempty" yaml:"best_day,omitempty"`
+	BestDaySeconds      float64      `json:"best_day_seconds,omitempty" yaml:"best_day_seconds,omitempty"`
+	// Goals are only filled in when --goals is passed
+	Goals []GoalReport `json:"goals,omitempty" yaml:"goals,omitempty"`
+}
+
+// TestReport is the machine-readable result of akami test
+type TestReport struct {
+	APIURL string `json:"api_url" yaml:"api_url"`
+	Sent   bool   `json:"sent" yaml:"sent"`
+	Queued bool   `json:"queued" yaml:"queued"`
+	// File is what was detected about the file passed with --file
+	File *FileReport `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// FileReport is what akami worked out about a file and where each part came from
+type FileReport struct {
+	Path             string `json:"path" yaml:"path"`
+	Project          string `json:"project" yaml:"project"`
+	ProjectSource    string `json:"project_source,omitempty" yaml:"project_source,omitempty"`
+	ProjectDetail    string `json:"project_detail,omitempty" yaml:"project_detail,omitempty"`
+	ProjectRoot      string `json:"project_root,omitempty" yaml:"project_root,omitempty"`
+	ProjectRootCount int    `json:"project_root_count" yaml:"project_root_count"`
+	Branch           string `json:"branch" yaml:"branch"`
+	BranchSource     string `json:"branch_source,omitempty" yaml:"branch_source,omitempty"`
+	Language         string `json:"language" yaml:"language"`
+	LanguageSource   string `json:"language_source,omitempty" yaml:"language_source,omitempty"`
+	LineCount        int    `json:"lines" yaml:"lines"`
+}
+
+// ExplainReport is the machine-readable result of akami explain
+type ExplainReport struct {
+	// ConfigPath is the wakatime config that was checked or empty when there isn't one
+	ConfigPath string      `json:"config_path" yaml:"config_path"`
+	File       *FileReport `json:"file" yaml:"file"`
+	Skipped    bool        `json:"skipped" yaml:"skipped"`
+	SkipReason string      `json:"skip_reason,omitempty" yaml:"skip_reason,omitempty"`
+	ExcludedBy string      `json:"excluded_by,omitempty" yaml:"excluded_by,omitempty"`
+	IncludedBy string      `json:"included_by,omitempty" yaml:"included_by,omitempty"`
+	// Hidden lists the hide_* settings that cover the file
+	Hidden []string `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	// Heartbeat is what would be sent, or would have been if the file weren't skipped
+	Heartbeat wakatime.Heartbeat `json:"heartbeat" yaml:"heartbeat"`
+}
+
+// TrackReport is the machine-readable summary akami track writes when it stops
+type TrackReport struct {
+	Root       string         `json:"root" yaml:"root"`
+	Folders    int            `json:"folders" yaml:"folders"`
+	Events     int            `json:"events" yaml:"events"`
+	Ignored    int            `json:"ignored" yaml:"ignored"`
+	Throttled  int            `json:"throttled" yaml:"throttled"`
+	Heartbeats int            `json:"heartbeats" yaml:"heartbeats"`
+	Sent       int            `json:"sent" yaml:"sent"`
+	Queued     int            `json:"queued" yaml:"queued"`
+	Failed     int            `json:"failed" yaml:"failed"`
+	Projects   map[string]int `json:"projects" yaml:"projects"`
+}
+
+// GitTimeReport is the machine-readable result of akami git report
+type GitTimeReport struct {
+	Repo        string            `json:"repo" yaml:"repo"`
+	Project     string            `json:"project" yaml:"project"`
+	Since       string            `json:"since" yaml:"since"`
+	Author      string            `json:"author,omitempty" yaml:"author,omitempty"`
+	Total       float64           `json:"total_seconds" yaml:"total_seconds"`
+	Uncommitted float64           `json:"uncommitted_seconds" yaml:"uncommitted_seconds"`
+	Branches    []GitBranchReport `json:"branches" yaml:"branches"`
+	Commits     []GitCommitReport `json:"commits" yaml:"commits"`
+}
+
+// GitBranchReport is the coding time spent on a branch
+type GitBranchReport struct {
+	Branch      string  `json:"branch" yaml:"branch"`
+	Seconds     float64 `json:"total_seconds" yaml:"total_seconds"`
+	Commits     int     `json:"commits" yaml:"commits"`
+	Uncommitted float64 `json:"uncommitted_seconds" yaml:"uncommitted_seconds"`
+}
+
+// GitCommitReport is a commit and the coding time that led up to it
+type GitCommitReport struct {
+	git.Commit `yaml:",inline"`
+	Seconds    float64 `json:"total_seconds" yaml:"total_seconds"`
+}
+
+func newFileReport(info wakatime.FileInfo) *FileReport {
+	return &FileReport{
+		Path:             info.Path,
+		Project:          info.Project,
+		ProjectSource:    info.ProjectSource,
+		ProjectDetail:    info.ProjectDetail,
+		ProjectRoot:      info.ProjectRoot,
+		ProjectRootCount: info.ProjectRootCount,
+		Branch:           info.Branch,
+		BranchSource:     info.BranchSource,
+		Language:         info.Language,
+		LanguageSource:   info.LanguageSource,
+		LineCount:        info.LineCount,
+	}
+}
+
+// initOutput validates the --output flag and sets up the task state for a command.
+// When a structured format is picked the spinner and all the pretty text is silenced
+// so that only the final document ends up on stdout.
+func initOutput(c *cobra.Command) (string, error) {
+	format, _ := c.Flags().GetString("output")
+	if format == "" {
+		format = OutputText
+	}
+
+	switch format {
+	case OutputText, OutputJSON, OutputYAML:
+	default:
+		return format, fmt.Errorf("unknown output format %q; pick one of text, json or yaml", format)
+	}
+
+	structured := format != OutputText
+	c.SetContext(context.WithValue(c.Context(), "taskState", &taskState{structured: structured, out: c.OutOrStdout()}))
+
+	if structured {
+		c.SetOut(io.Discard)
+	}
+
+	return format, nil
+}
+
+// isStructured reports whether the current command is producing json or yaml
+func isStructured(c *cobra.Command) bool {
+	state, ok := c.Context().Value("taskState").(*taskState)
+	return ok && state.structured
+}
+
+// writeOutput writes v to the command's stdout in the requested structured format
+func writeOutput(c *cobra.Command, format string, v any) error {
+	out := c.OutOrStdout()
+	if state, ok := c.Context().Value("taskState").(*taskState); ok && state.out != nil {
+		out = state.out
+	}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(v)
+	}
+
+	return nil
+}
+
+// rankedItems converts stat items from the api into their report form
+func rankedItems(items []wakatime.StatItem) []RankedItem {
+	ranked := make([]RankedItem, 0, len(items))
+	for _, item := range items {
+		ranked = append(ranked, RankedItem{
+			Name:         item.Name,
+			TotalSeconds: item.TotalSeconds,
+			Percent:      item.Percent,
+			Text:         item.Text,
+		})
+	}
+
+	return ranked
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/profile"
+	"github.com/taciturnaxolotl/akami/styles"
+)
+
+// openProfiles loads akami's profile store from its default location
+func openProfiles() (*profile.Store, error) {
+	path, err := profile.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return profile.Load(path)
+}
+
+// activeProfile returns the profile picked with --profile or profile use. It returns
+// a nil profile when the wakatime config should be used instead.
+func activeProfile(c *cobra.Command) (string, *profile.Profile, error) {
+	store, err := openProfiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	name, _ := c.Flags().GetString("profile")
+	if name == "" {
+		name = store.Active
+	}
+	if name == "" || name == profile.Default {
+		return profile.Default, nil, nil
+	}
+
+	p, err := store.Get(name)
+	if err != nil {
+		return name, nil, fmt.Errorf("%w; run akami profile list to see the ones you have", err)
+	}
+
+	return name, &p, nil
+}
+
+// ProfileAdd saves a new profile
+func ProfileAdd(c *cobra.Command, args []string) error {
+	store, err := openProfiles()
+	if err != nil {
+		return err
+	}
+
+	// the persistent --url and --key flags double as the profile's settings
+	p := profile.Profile{}
+	p.APIURL, _ = c.Flags().GetString("url")
+	p.APIKey, _ = c.Flags().GetString("key")
+	p.APIKeyVaultCmd, _ = c.Flags().GetString("key-cmd")
+	force, _ := c.Flags().GetBool("force")
+	use, _ := c.Flags().GetBool("use")
+
+	if p.APIURL == "" {
+		return errors.New("a profile needs an api url; pass it with --url")
+	}
+	if p.APIKey == "" && p.APIKeyVaultCmd == "" {
+		return errors.New("a profile needs an api key; pass it with --key or a command that prints it with --key-cmd")
+	}
+	p.APIURL = strings.TrimRight(p.APIURL, "/")
+
+	if err := store.Add(args[0], p, force); errors.Is(err, profile.ErrExists) {
+		return fmt.Errorf("%w; pass --force to replace it", err)
+	} else if err != nil {
+		return err
+	}
+	if use {
+		store.Use(args[0])
+	}
+
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	c.Printf("🌷 saved the %s profile for %s\n", styles.Fancy.Render(args[0]), styles.Muted.Render(p.APIURL))
+	if use {
+		c.Println("and it's the active profile now")
+	}
+
+	return nil
+}
+
+// ProfileList shows every profile and which one is active
+func ProfileList(c *cobra.Command, _ []string) error {
+	store, err := openProfiles()
+	if err != nil {
+		return err
+	}
+
+	marker := func(name string) string {
+		if name == store.Active || (name == profile.Default && store.Active == "") {
+			return styles.Success.Render("*")
+		}
+		return " "
+	}
+
+	c.Printf("%s %s %s\n", marker(profile.Default), styles.Fancy.Render(profile.Default), styles.Muted.Render("(your wakatime config)"))
+	for _, name := range store.Names() {
+		p := store.Profiles[name]
+
+		key := styles.Muted.Render("key from " + p.APIKeyVaultCmd)
+		if p.APIKey != "" {
+			key = styles.Muted.Render(maskKey(p.APIKey))
+		}
+
+		c.Printf("%s %s %s %s\n", marker(name), styles.Fancy.Render(name), p.APIURL, key)
+	}
+
+	return nil
+}
+
+// ProfileRemove deletes a profile
+func ProfileRemove(c *cobra.Command, args []string) error {
+	store, err := openProfiles()
+	if err != nil {
+		return err
+	}
+
+	wasActive := store.Active == args[0]
+	if err := store.Remove(args[0]); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	c.Printf("🗑️ removed the %s profile\n", styles.Fancy.Render(args[0]))
+	if wasActive {
+		c.Println("it was the active profile so akami is back to using your wakatime config")
+	}
+
+	return nil
+}
+
+// ProfileUse makes a profile the one used when --profile isn't passed
+func ProfileUse(c *cobra.Command, args []string) error {
+	store, err := openProfiles()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Use(args[0]); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	if args[0] == profile.Default {
+		c.Println("🌷 akami will use your wakatime config again")
+	} else {
+		c.Printf("🌷 akami will use the %s profile from now on\n", styles.Fancy.Render(args[0]))
+	}
+
+	return nil
+}
+
+// CompleteProfiles suggests profile names for shell completion
+func CompleteProfiles(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	store, err := openProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return append([]string{profile.Default}, store.Names()...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// maskKey hides all but the last few characters of an api key
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("•", len(key))
+	}
+
+	return strings.Repeat("•", 8) + key[len(key)-4:]
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/prompt"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// waybarOutput is what waybar expects from a custom module with a json return type
+type waybarOutput struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+}
+
+// Prompt prints today's coding time for a shell prompt or status bar. It only ever
+// reads the cache and leaves fetching to a refresh started in the background, so
+// it's back before the prompt is drawn even when the api is slow or unreachable.
+func Prompt(c *cobra.Command, _ []string) error {
+	format, _ := c.Flags().GetString("format")
+	maxAge, _ := c.Flags().GetDuration("max-age")
+	waybar, _ := c.Flags().GetBool("waybar")
+	refresh, _ := c.Flags().GetBool("refresh")
+
+	path, err := promptCachePath(c)
+	if err != nil {
+		return err
+	}
+	// a broken cache just means there's nothing to show until the next refresh
+	cache, _ := prompt.LoadCache(path)
+
+	now := time.Now()
+	if refresh {
+		if err := refreshPrompt(c, cache, now); err != nil {
+			return err
+		}
+	} else if cache.NeedsRefresh(maxAge, now) {
+		cache.Refreshing = now
+		if err := cache.Save(); err == nil {
+			startInBackground(c, "prompt", "--refresh")
+		}
+	}
+
+	today := cache.Today(now)
+	text := ""
+	if !today.Updated.IsZero() {
+		text = today.Format(format)
+	}
+
+	if !waybar {
+		fmt.Fprintln(c.OutOrStdout(), text)
+		return nil
+	}
+
+	out := waybarOutput{Text: text, Class: "akami"}
+	tooltip := []string{utils.PrettyPrintTime(int(today.TodaySeconds)) + " today"}
+	if today.Project != "" {
+		tooltip = append(tooltip, fmt.Sprintf("mostly on %s in %s", today.Project, today.Language))
+	}
+	if !today.Updated.IsZero() {
+		tooltip = append(tooltip, "updated "+today.Updated.Format(time.TimeOnly))
+	}
+	if today.Error != "" {
+		out.Class = "error"
+		tooltip = append(tooltip, "couldn't refresh: "+today.Error)
+	}
+	out.Tooltip = strings.Join(tooltip, "\n")
+
+	return json.NewEncoder(c.OutOrStdout()).Encode(out)
+}
+
+// promptCachePath returns the cache for the account akami prompt is run for. Accounts
+// are told apart by the profile and the --url and --key flags rather than the api key
+// itself since resolving that can mean running a slow vault command.
+func promptCachePath(c *cobra.Command) (string, error) {
+	name, _ := c.Flags().GetString("profile")
+	url, _ := c.Flags().GetString("url")
+	key, _ := c.Flags().GetString("key")
+
+	if name == "" {
+		store, err := openProfiles()
+		if err != nil {
+			return "", err
+		}
+		name = store.Active
+	}
+
+	return prompt.CachePath(strings.Join([]string{name, url, key}, "\n"))
+}
+
+// startInBackground runs akami with args in the background for the same account,
+// passing along the flags that pick it
+func startInBackground(c *cobra.Command, args ...string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	// a --key can't be looked up again so it goes along with the rest; it was on this akami's command line already
+	args = append(args, accountFlags(c)...)
+	if key, _ := c.Flags().GetString("key"); key != "" {
+		args = append(args, "--key", key)
+	}
+
+	cmd := exec.Command(exe, args...)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	cmd.Process.Release()
+}
+
+// accountFlags returns the --profile and --url flags akami was run with so another
+// akami can be run for the same account. The key is left out since the flags end up
+// in hooks and scripts; the other akami looks it up again from the profile or config.
+func accountFlags(c *cobra.Command) []string {
+	var flags []string
+	for _, name := range []string{"profile", "url"} {
+		if value, _ := c.Flags().GetString(name); value != "" {
+			flags = append(flags, "--"+name, value)
+		}
+	}
+
+	return flags
+}
+
+// refuseKeyFlag errors when --key was passed to a command that would write it into
+// what, since anything that can read what or list processes would see the key
+func refuseKeyFlag(c *cobra.Command, what string) error {
+	if key, _ := c.Flags().GetString("key"); key != "" {
+		return fmt.Errorf("a --key would be saved in plain text in %s; add the key as a profile with akami profile add and pass --profile instead", what)
+	}
+
+	return nil
+}
+
+// refreshPrompt fetches today's summary into the cache. Failures are kept in the
+// cache too so status bars can show that the numbers are out of date, and they leave
+// the refresh marked as started so a down api isn't retried on every prompt.
+func refreshPrompt(c *cobra.Command, cache *prompt.Cache, now time.Time) error {
+	api_key, api_url, err := getClientStuff(c)
+	var summaries wakatime.SummariesResponse
+	if err == nil {
+		client := wakatime.NewClientWithOptions(api_key, api_url)
+		summaries, err = client.GetSummariesContext(c.Context(), now, now, wakatime.SummariesOptions{})
+	}
+	if err != nil {
+		cache.Error = strings.TrimSpace(err.Error())
+		cache.Refreshing = now
+		cache.Save()
+		return err
+	}
+
+	today := summaries.Total()
+	cache.Day = now.Format(time.DateOnly)
+	cache.TodaySeconds = today.GrandTotal.TotalSeconds
+	cache.Project, cache.Language = "", ""
+	if len(today.Projects) > 0 {
+		cache.Project = today.Projects[0].Name
+	}
+	if len(today.Languages) > 0 {
+		cache.Language = today.Languages[0].Name
+	}
+	cache.Updated = now
+	cache.Error = ""
+	cache.Refreshing = time.Time{}
+
+	return cache.Save()
+}
+
+// PromptSnippet prints a ready-made config for showing akami prompt in another tool
+func PromptSnippet(c *cobra.Command, args []string) error {
+	snippet, ok := prompt.Snippets[args[0]]
+	if !ok {
+		return fmt.Errorf("there's no snippet for %q; pick one of %s", args[0], strings.Join(prompt.SnippetNames, ", "))
+	}
+
+	fmt.Fprint(c.OutOrStdout(), snippet)
+	return nil
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// openQueue opens akami's offline heartbeat queue in the data dir
+func openQueue() (*wakatime.Queue, error) {
+	dataDir, err := utils.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return wakatime.OpenQueue(filepath.Join(dataDir, "queue.jsonl"))
+}
+
+// heartbeatTime converts a heartbeat's unix timestamp into a time.Time
+func heartbeatTime(heartbeat wakatime.Heartbeat) time.Time {
+	return time.Unix(0, int64(heartbeat.Time*float64(time.Second)))
+}
+
+func QueueList(c *cobra.Command, _ []string) error {
+	queue, err := openQueue()
+	if err != nil {
+		return err
+	}
+
+	queued, err := queue.List()
+	if err != nil {
+		return err
+	}
+
+	if len(queued) == 0 {
+		c.Println("🌱 your offline queue is empty!")
+		return nil
+	}
+
+	c.Printf("%s heartbeats waiting in %s\n\n", styles.Fancy.Render(fmt.Sprint(len(queued))), styles.Muted.Render(queue.Path()))
+
+	for _, item := range queued {
+		project := item.Heartbeat.Project
+		if project == "" {
+			project = "unknown"
+		}
+
+		c.Printf("  %s %s %s %s\n",
+			styles.Muted.Render(item.ID),
+			styles.Warn.Render(heartbeatTime(item.Heartbeat).Format(time.DateTime)),
+			styles.Fancy.Render(project),
+			item.Heartbeat.Entity,
+		)
+	}
+
+	return nil
+}
+
+func QueueStats(c *cobra.Command, _ []string) error {
+	queue, err := openQueue()
+	if err != nil {
+		return err
+	}
+
+	stats, err := queue.Stats()
+	if err != nil {
+		return err
+	}
+
+	if stats.Count == 0 {
+		c.Println("🌱 your offline queue is empty!")
+		return nil
+	}
+
+	c.Printf("Heartbeats queued: %s\n", styles.Fancy.Render(fmt.Sprint(stats.Count)))
+	c.Printf("Queue size:        %s\n", styles.Muted.Render(fmt.Sprintf("%.1f KiB", float64(stats.Size)/1024)))
+	c.Printf("Oldest heartbeat:  %s (%s ago)\n", styles.Warn.Render(stats.Oldest.Format(time.DateTime)), utils.PrettyPrintTime(int(time.Since(stats.Oldest).Seconds())))
+	c.Printf("Newest heartbeat:  %s\n\n", styles.Warn.Render(stats.Newest.Format(time.DateTime)))
+
+	// show the projects with the most queued heartbeats first
+	projects := make([]string, 0, len(stats.Projects))
+	for project := range stats.Projects {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return stats.Projects[projects[i]] > stats.Projects[projects[j]]
+	})
+
+	c.Println(styles.Fancy.Render("Projects:"))
+	for _, project := range projects {
+		name := project
+		if name == "" {
+			name = "unknown"
+		}
+		c.Printf("  %s %s\n", styles.Muted.Render(fmt.Sprintf("%5d", stats.Projects[project])), name)
+	}
+
+	return nil
+}
+
+func QueuePurge(c *cobra.Command, _ []string) error {
+	c.SetContext(context.WithValue(c.Context(), "taskState", &taskState{}))
+
+	queue, err := openQueue()
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Purging offline queue")
+
+	if err := queue.Purge(); err != nil {
+		errorTask(c, "Purging offline queue")
+		return err
+	}
+
+	completeTask(c, "Purging offline queue")
+
+	return nil
+}
+
+func QueueFlush(c *cobra.Command, _ []string) error {
+	// Initialize a new context with task state
+	c.SetContext(context.WithValue(c.Context(), "taskState", &taskState{}))
+
+	printTask(c, "Validating arguments")
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	retries, _ := c.Flags().GetInt("retries")
+
+	queue, err := openQueue()
+	if err != nil {
+		return err
+	}
+
+	queued, err := queue.List()
+	if err != nil {
+		return err
+	}
+
+	if len(queued) == 0 {
+		c.Println("🌱 your offline queue is empty; nothing to flush!")
+		return nil
+	}
+
+	client := flushClient(api_key, api_url, retries)
+
+	sent, rejected := 0, 0
+	for start := 0; start < len(queued); start += wakatime.MaxBulkHeartbeats {
+		batch := queued[start:min(start+wakatime.MaxBulkHeartbeats, len(queued))]
+		message := fmt.Sprintf("Sending heartbeats %d-%d of %d", start+1, start+len(batch), len(queued))
+
+		printTask(c, message)
+
+		heartbeats := make([]wakatime.Heartbeat, len(batch))
+		for i, item := range batch {
+			heartbeats[i] = item.Heartbeat
+		}
+
+		results, err := client.SendHeartbeatsContext(c.Context(), heartbeats)
+		if err != nil {
+			errorTask(c, message)
+			if sent > 0 {
+				c.Printf("\n%s heartbeats were sent before things went wrong\n", styles.Fancy.Render(fmt.Sprint(sent)))
+			}
+			return err
+		}
+
+		// drop accepted heartbeats and ones the server will never accept
+		done := []string{}
+		for i, result := range results {
+			switch {
+			case result.OK():
+				sent++
+				done = append(done, batch[i].ID)
+			case result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests:
+				rejected++
+				done = append(done, batch[i].ID)
+			}
+		}
+
+		if err := queue.Remove(done...); err != nil {
+			errorTask(c, message)
+			return err
+		}
+
+		completeTask(c, message)
+	}
+
+	c.Printf("\n❇️ flushed %s heartbeats", styles.Fancy.Render(fmt.Sprint(sent)))
+	if rejected > 0 {
+		c.Printf(" and dropped %s that the server rejected", styles.Bad.Render(fmt.Sprint(rejected)))
+	}
+	if left := len(queued) - sent - rejected; left > 0 {
+		c.Printf("; %s are still waiting for another try", styles.Warn.Render(fmt.Sprint(left)))
+	}
+	c.Println()
+
+	return nil
+}
+
+// flushClient returns a client for sending queued heartbeats that retries each
+// batch with exponential backoff when the server can't be reached or has a hiccup.
+// It deliberately has no queue so failures don't get queued twice.
+func flushClient(apiKey string, apiURL string, retries int) *wakatime.Client {
+	client := wakatime.NewClientWithOptions(apiKey, apiURL)
+	client.Retry = wakatime.RetryPolicy{MaxAttempts: retries + 1, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	return client
+}
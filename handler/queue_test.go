@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+func TestQueueFlush(t *testing.T) {
+	for _, tt := range failureCases {
+		t.Run(tt.name, func(t *testing.T) {
+			setupHome(t, "")
+			server, ctx := tt.start(t)
+
+			queue, err := openQueue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			hbs := make([]wakatime.Heartbeat, wakatime.MaxBulkHeartbeats+3)
+			for i := range hbs {
+				hbs[i] = wakatime.Heartbeat{Entity: fmt.Sprintf("/code/akami/file%d.go", i), Type: "file", Project: "akami", Time: float64(1700000000 + i)}
+			}
+			// the server turns this one away for good so it shouldn't stay queued
+			hbs[1].Entity = ""
+			if err := queue.Push(hbs...); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = runCommand(ctx, server, QueueFlush, func(f *pflag.FlagSet) {
+				f.Int("retries", 0, "")
+			})
+			if tt.wantErr != (err != nil) {
+				t.Errorf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+
+			queued, err := queue.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantQueued, wantSent := 0, len(hbs)-1
+			if tt.wantErr {
+				wantQueued, wantSent = len(hbs), 0
+			}
+			if len(queued) != wantQueued {
+				t.Errorf("%d heartbeats are still queued, want %d", len(queued), wantQueued)
+			}
+			if n := len(server.Heartbeats()); n != wantSent {
+				t.Errorf("server has %d heartbeats, want %d", n, wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// statusRange is the stretch of days akami status reports on
+type statusRange struct {
+	// Name is the range's name or from..to for custom dates
+	Name string
+	// Label finishes a sentence about the range like "over the last 30 days"
+	Label string
+	// Start is the first day of the range
+	Start time.Time
+	// End is the last day of the range
+	End time.Time
+	// Stats is set for ranges the stats endpoint precomputes
+	Stats bool
+}
+
+// days returns how many days the range covers
+func (r statusRange) days() int {
+	return utils.DaysBetween(r.Start, r.End) + 1
+}
+
+// rangeNames are the ranges --range accepts
+var rangeNames = []string{"today", "yesterday", "last_7_days", "last_30_days", "last_6_months", "last_year", "all_time", "this_week", "this_month", "last_month"}
+
+// parseStatusRange works out the days to report on from --range or --from and --to
+func parseStatusRange(name string, from string, to string, now time.Time) (statusRange, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if from != "" || to != "" {
+		if name != "" {
+			return statusRange{}, errors.New("use either --range or --from and --to, not both")
+		}
+		if from == "" {
+			return statusRange{}, errors.New("a --to date needs a --from to go with it")
+		}
+
+		start, err := time.ParseInLocation(wakatime.DateFormat, from, now.Location())
+		if err != nil {
+			return statusRange{}, fmt.Errorf("the --from date should look like %s but is %q", today.Format(wakatime.DateFormat), from)
+		}
+		end := today
+		if to != "" {
+			end, err = time.ParseInLocation(wakatime.DateFormat, to, now.Location())
+			if err != nil {
+				return statusRange{}, fmt.Errorf("the --to date should look like %s but is %q", today.Format(wakatime.DateFormat), to)
+			}
+		}
+		if end.Before(start) {
+			return statusRange{}, errors.New("the --to date is before the --from date")
+		}
+
+		return statusRange{
+			Name:  start.Format(wakatime.DateFormat) + ".." + end.Format(wakatime.DateFormat),
+			Label: "between " + start.Format(wakatime.DateFormat) + " and " + end.Format(wakatime.DateFormat),
+			Start: start,
+			End:   end,
+		}, nil
+	}
+
+	switch name {
+	case "today":
+		return statusRange{Name: name, Label: "today", Start: today, End: today}, nil
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return statusRange{Name: name, Label: "yesterday", Start: yesterday, End: yesterday}, nil
+	case "", wakatime.RangeLast7Days:
+		return statusRange{Name: wakatime.RangeLast7Days, Label: "over the last 7 days", Start: today.AddDate(0, 0, -6), End: today, Stats: true}, nil
+	case wakatime.RangeLast30Days:
+		return statusRange{Name: name, Label: "over the last 30 days", Start: today.AddDate(0, 0, -29), End: today, Stats: true}, nil
+	case wakatime.RangeLast6Months:
+		return statusRange{Name: name, Label: "over the last 6 months", Start: today.AddDate(0, -6, 1), End: today, Stats: true}, nil
+	case wakatime.RangeLastYear:
+		return statusRange{Name: name, Label: "over the last year", Start: today.AddDate(-1, 0, 1), End: today, Stats: true}, nil
+	case wakatime.RangeAllTime:
+		// the start is filled in from whatever the api says the first day was
+		return statusRange{Name: name, Label: "since you started", Start: today, End: today, Stats: true}, nil
+	case "this_week":
+		// weeks start on monday
+		offset := (int(today.Weekday()) + 6) % 7
+		return statusRange{Name: name, Label: "this week", Start: today.AddDate(0, 0, -offset), End: today}, nil
+	case "this_month":
+		return statusRange{Name: name, Label: "this month", Start: today.AddDate(0, 0, 1-today.Day()), End: today}, nil
+	case "last_month":
+		start := today.AddDate(0, 0, 1-today.Day()).AddDate(0, -1, 0)
+		return statusRange{Name: name, Label: "last month", Start: start, End: start.AddDate(0, 1, -1)}, nil
+	}
+
+	return statusRange{}, fmt.Errorf("unknown range %q; pick one of %s or use --from and --to", name, strings.Join(rangeNames, ", "))
+}
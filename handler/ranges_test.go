@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusRangeDays(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  int
+	}{
+		{name: "single day", start: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork), end: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork), want: 1},
+		{name: "spring forward", start: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), end: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork), want: 7},
+		{name: "fall back", start: time.Date(2025, 10, 27, 0, 0, 0, 0, newYork), end: time.Date(2025, 11, 2, 0, 0, 0, 0, newYork), want: 7},
+		{name: "month with spring forward", start: time.Date(2025, 3, 1, 0, 0, 0, 0, newYork), end: time.Date(2025, 3, 31, 0, 0, 0, 0, newYork), want: 31},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := statusRange{Start: tt.start, End: tt.end}
+			if got := r.days(); got != tt.want {
+				t.Errorf("days() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatusRange(t *testing.T) {
+	// wednesday the 12th of march
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rng   string
+		from  string
+		to    string
+		start string
+		end   string
+		stats bool
+		err   string
+	}{
+		{name: "default", start: "2025-03-06", end: "2025-03-12", stats: true},
+		{name: "today", rng: "today", start: "2025-03-12", end: "2025-03-12"},
+		{name: "yesterday", rng: "yesterday", start: "2025-03-11", end: "2025-03-11"},
+		{name: "last 30 days", rng: "last_30_days", start: "2025-02-11", end: "2025-03-12", stats: true},
+		{name: "last 6 months", rng: "last_6_months", start: "2024-09-13", end: "2025-03-12", stats: true},
+		{name: "last year", rng: "last_year", start: "2024-03-13", end: "2025-03-12", stats: true},
+		{name: "this week", rng: "this_week", start: "2025-03-10", end: "2025-03-12"},
+		{name: "this month", rng: "this_month", start: "2025-03-01", end: "2025-03-12"},
+		{name: "last month", rng: "last_month", start: "2025-02-01", end: "2025-02-28"},
+		{name: "from only", from: "2025-03-01", start: "2025-03-01", end: "2025-03-12"},
+		{name: "from and to", from: "2025-01-01", to: "2025-01-31", start: "2025-01-01", end: "2025-01-31"},
+		{name: "to only", to: "2025-01-31", err: "needs a --from"},
+		{name: "range and dates", rng: "today", from: "2025-01-01", err: "not both"},
+		{name: "bad from", from: "01/01/2025", err: "--from date should look like"},
+		{name: "bad to", from: "2025-01-01", to: "tomorrow", err: "--to date should look like"},
+		{name: "backwards", from: "2025-02-01", to: "2025-01-01", err: "before the --from"},
+		{name: "unknown range", rng: "fortnight", err: "unknown range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseStatusRange(tt.rng, tt.from, tt.to, now)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.Start.Format(time.DateOnly); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := r.End.Format(time.DateOnly); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+			if r.Stats != tt.stats {
+				t.Errorf("stats = %v, want %v", r.Stats, tt.stats)
+			}
+			if r.Name == "" || r.Label == "" {
+				t.Errorf("range %+v is missing its name or label", r)
+			}
+		})
+	}
+}
+
+func TestParseStatusRangeMonday(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	r, err := parseStatusRange("this_week", "", "", monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.days() != 1 || !r.Start.Equal(r.End) {
+		t.Errorf("this week on a monday is %s to %s", r.Start.Format(time.DateOnly), r.End.Format(time.DateOnly))
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/streak"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// streakWeekdays label the weekday averages starting with monday
+var streakWeekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// StreakReport is the machine-readable result of akami streak
+type StreakReport struct {
+	From            string      `json:"from" yaml:"from"`
+	To              string      `json:"to" yaml:"to"`
+	MinimumSeconds  float64     `json:"minimum_seconds" yaml:"minimum_seconds"`
+	Current         StreakRun   `json:"current" yaml:"current"`
+	Longest         StreakRun   `json:"longest" yaml:"longest"`
+	TodayMet        bool        `json:"today_met" yaml:"today_met"`
+	TodayRemaining  float64     `json:"today_remaining_seconds" yaml:"today_remaining_seconds"`
+	Days            int         `json:"days" yaml:"days"`
+	ActiveDays      int         `json:"active_days" yaml:"active_days"`
+	MissedDays      []string    `json:"missed_days" yaml:"missed_days"`
+	WeekdayAverages []StreakDay `json:"weekday_averages" yaml:"weekday_averages"`
+	CachePath       string      `json:"cache_path" yaml:"cache_path"`
+	DaysFromCache   int         `json:"days_from_cache" yaml:"days_from_cache"`
+}
+
+// StreakRun is a stretch of consecutive days that met the minimum
+type StreakRun struct {
+	Days  int    `json:"days" yaml:"days"`
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+	End   string `json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+// StreakDay is the average time coded on a weekday
+type StreakDay struct {
+	Weekday        string  `json:"weekday" yaml:"weekday"`
+	AverageSeconds float64 `json:"average_seconds" yaml:"average_seconds"`
+}
+
+// newStreakRun turns a streak.Run into its machine-readable form
+func newStreakRun(run streak.Run) StreakRun {
+	if run.Days == 0 {
+		return StreakRun{}
+	}
+
+	return StreakRun{Days: run.Days, Start: run.Start.Format(wakatime.DateFormat), End: run.End.Format(wakatime.DateFormat)}
+}
+
+// Streak reports the current and longest runs of days spent coding along with missed
+// days and weekday averages. Daily totals are cached so only the last few days are
+// fetched again on later runs.
+func Streak(c *cobra.Command, _ []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Validating arguments")
+
+	minimum, _ := c.Flags().GetDuration("min")
+	days, _ := c.Flags().GetInt("days")
+	refresh, _ := c.Flags().GetBool("refresh")
+
+	if minimum <= 0 {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("the minimum has to be more than 0 but was %s", minimum)
+	}
+	if days < 1 {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("at least 1 day has to be looked at but --days was %d", days)
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := today.AddDate(0, 0, 1-days)
+
+	printTask(c, "Loading cached daily totals")
+
+	path, err := streak.CachePath(api_url, api_key)
+	if err != nil {
+		errorTask(c, "Loading cached daily totals")
+		return err
+	}
+	cache, err := streak.LoadCache(path)
+	if err != nil {
+		errorTask(c, "Loading cached daily totals")
+		return err
+	}
+	if refresh {
+		cache.Days = map[string]float64{}
+	}
+
+	completeTask(c, "Loading cached daily totals")
+
+	cached := len(cache.Range(from, today))
+	if start, ok := cache.Missing(from, today); ok {
+		printTask(c, "Fetching daily totals")
+
+		client := wakatime.NewClientWithOptions(api_key, api_url)
+		summaries, err := client.GetSummariesContext(c.Context(), start, today, wakatime.SummariesOptions{})
+		if err != nil {
+			errorTask(c, "Fetching daily totals")
+			return err
+		}
+
+		// days the api leaves out had nothing coded on them
+		for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+			cache.Set(day, 0)
+		}
+		for _, day := range summaries.Data {
+			date, err := time.ParseInLocation(wakatime.DateFormat, day.Range.Date, now.Location())
+			if err != nil {
+				continue
+			}
+			cache.Set(date, day.GrandTotal.TotalSeconds)
+		}
+		cache.Fetched = now
+
+		completeTask(c, fmt.Sprintf("Fetched %d days", utils.DaysBetween(start, today)+1))
+
+		printTask(c, "Saving daily totals to "+cache.Path())
+		if err := cache.Save(); err != nil {
+			errorTask(c, "Saving daily totals to "+cache.Path())
+			return err
+		}
+		completeTask(c, "Saving daily totals to "+cache.Path())
+
+		cached = len(cache.Range(from, start.AddDate(0, 0, -1)))
+	}
+
+	result := streak.Compute(cache.Range(from, today), minimum, from, today)
+
+	report := StreakReport{
+		From:           from.Format(wakatime.DateFormat),
+		To:             today.Format(wakatime.DateFormat),
+		MinimumSeconds: minimum.Seconds(),
+		Current:        newStreakRun(result.Current),
+		Longest:        newStreakRun(result.Longest),
+		TodayMet:       result.TodayMet,
+		TodayRemaining: result.TodayRemaining.Seconds(),
+		Days:           result.Days,
+		ActiveDays:     result.ActiveDays,
+		MissedDays:     []string{},
+		CachePath:      cache.Path(),
+		DaysFromCache:  cached,
+	}
+	for _, day := range result.Missed {
+		report.MissedDays = append(report.MissedDays, day.Format(wakatime.DateFormat))
+	}
+	for i, average := range result.WeekdayAverages {
+		report.WeekdayAverages = append(report.WeekdayAverages, StreakDay{Weekday: streakWeekdays[i], AverageSeconds: average})
+	}
+
+	if isStructured(c) {
+		return writeOutput(c, format, report)
+	}
+
+	c.Println()
+	switch {
+	case result.Current.Days == 0:
+		c.Printf("🌱 You don't have a streak going right now; code for %s today to start one\n", styles.Fancy.Render(utils.PrettyPrintTime(int(result.TodayRemaining.Seconds()))))
+	case result.TodayMet:
+		c.Printf("🔥 You're on a %s streak since %s and today already counts\n", styles.Fancy.Render(pluralDays(result.Current.Days)), styles.Fancy.Render(result.Current.Start.Format("Mon Jan 2")))
+	default:
+		c.Printf("🔥 You're on a %s streak since %s; code for %s more today to keep it going\n", styles.Fancy.Render(pluralDays(result.Current.Days)), styles.Fancy.Render(result.Current.Start.Format("Mon Jan 2")), styles.Warn.Render(utils.PrettyPrintTime(int(result.TodayRemaining.Seconds()))))
+	}
+
+	if result.Longest.Days > 0 {
+		c.Printf("Your longest streak was %s from %s to %s\n", styles.Fancy.Render(pluralDays(result.Longest.Days)), styles.Fancy.Render(result.Longest.Start.Format("Jan 2 2006")), styles.Fancy.Render(result.Longest.End.Format("Jan 2 2006")))
+	}
+	c.Printf("You coded at least %s on %s of the last %s and missed %s\n",
+		styles.Fancy.Render(utils.PrettyPrintTime(int(minimum.Seconds()))),
+		styles.Fancy.Render(fmt.Sprint(result.ActiveDays)),
+		styles.Fancy.Render(pluralDays(result.Days)),
+		styles.Bad.Render(pluralDays(len(result.Missed))),
+	)
+
+	chart := render.Chart{Title: "Average by weekday:", Width: render.TerminalWidth(c.OutOrStderr()), Sort: render.Unsorted}
+	for i, average := range result.WeekdayAverages {
+		chart.Items = append(chart.Items, render.Item{Label: streakWeekdays[i], Value: average, Text: utils.PrettyPrintTime(int(average))})
+	}
+	c.Println()
+	c.Print(chart.String())
+
+	if len(result.Missed) > 0 {
+		recent := result.Missed[max(len(result.Missed)-5, 0):]
+		c.Println()
+		c.Println(styles.Muted.Render("Most recently missed:"))
+		for i := len(recent) - 1; i >= 0; i-- {
+			c.Println(styles.Muted.Render("  " + recent[i].Format("Mon Jan 2 2006")))
+		}
+	}
+
+	return nil
+}
+
+// pluralDays formats a number of days like "1 day" or "3 days"
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+
+	return fmt.Sprintf("%d days", n)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// keystrokeTimeout is the longest gap between heartbeats the api still counts as coding
+const keystrokeTimeout = 15 * time.Minute
+
+// TimelineReport is the machine-readable result of akami timeline
+type TimelineReport struct {
+	Date         string            `json:"date" yaml:"date"`
+	TotalSeconds float64           `json:"total_seconds" yaml:"total_seconds"`
+	Heartbeats   int               `json:"heartbeats" yaml:"heartbeats"`
+	Projects     []TimelineProject `json:"projects" yaml:"projects"`
+	// Gaps are stretches between heartbeats that were too long to count as coding
+	Gaps []TimelineSpan `json:"gaps" yaml:"gaps"`
+}
+
+// TimelineProject is the time spent in one project over the day
+type TimelineProject struct {
+	Name         string         `json:"name" yaml:"name"`
+	TotalSeconds float64        `json:"total_seconds" yaml:"total_seconds"`
+	Durations    []TimelineSpan `json:"durations" yaml:"durations"`
+}
+
+// TimelineSpan is a stretch of time on the timeline
+type TimelineSpan struct {
+	Start   time.Time `json:"start" yaml:"start"`
+	End     time.Time `json:"end" yaml:"end"`
+	Seconds float64   `json:"seconds" yaml:"seconds"`
+}
+
+// Timeline renders a day as a time of day bar per project next to the raw heartbeats
+// so it's easy to see where recorded time went and where it was lost
+func Timeline(c *cobra.Command, _ []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	printTask(c, "Validating arguments")
+
+	dateFlag, _ := c.Flags().GetString("date")
+	project, _ := c.Flags().GetString("project")
+	width, _ := c.Flags().GetInt("width")
+
+	date, err := parseDate(dateFlag, time.Now())
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+	if width < 24 {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("the timeline needs to be at least 24 columns wide")
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	printTask(c, "Fetching "+date.Format(wakatime.DateFormat))
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	durations, err := client.GetDurationsContext(c.Context(), date, project, wakatime.SliceByNone)
+	if err != nil {
+		errorTask(c, "Fetching "+date.Format(wakatime.DateFormat))
+		return err
+	}
+	heartbeats, err := client.GetHeartbeatsContext(c.Context(), date)
+	if err != nil {
+		errorTask(c, "Fetching "+date.Format(wakatime.DateFormat))
+		return err
+	}
+
+	completeTask(c, "Fetching "+date.Format(wakatime.DateFormat))
+
+	report := buildTimeline(date, project, durations.Data, heartbeats.Data)
+	if isStructured(c) {
+		return writeOutput(c, format, report)
+	}
+
+	c.Printf("\nYou coded for %s on %s across %s heartbeats\n\n",
+		styles.Fancy.Render(utils.PrettyPrintTime(int(report.TotalSeconds))),
+		styles.Fancy.Render(date.Format("Mon Jan 2 2006")),
+		styles.Fancy.Render(fmt.Sprint(report.Heartbeats)),
+	)
+
+	if len(report.Projects) == 0 && report.Heartbeats == 0 {
+		c.Println("🌱 nothing was recorded that day")
+		return nil
+	}
+
+	timeline := render.Timeline{Day: date, Width: width, MarksLabel: "heartbeats"}
+	for _, p := range report.Projects {
+		row := render.TimelineRow{Label: p.Name, Text: utils.PrettyPrintTime(int(p.TotalSeconds))}
+		for _, d := range p.Durations {
+			row.Spans = append(row.Spans, render.Span{Start: d.Start, End: d.End})
+		}
+		timeline.Rows = append(timeline.Rows, row)
+	}
+	for _, hb := range heartbeats.Data {
+		if project == "" || hb.Project == project {
+			timeline.Marks = append(timeline.Marks, hb.At())
+		}
+	}
+	c.Println(timeline.String())
+
+	if len(report.Gaps) > 0 {
+		c.Println(styles.Warn.Render(fmt.Sprintf("Gaps longer than %d minutes between heartbeats weren't counted:", int(keystrokeTimeout.Minutes()))))
+		for _, gap := range report.Gaps {
+			c.Printf("  %s → %s  %s\n", gap.Start.Format("15:04"), gap.End.Format("15:04"), styles.Muted.Render(utils.PrettyPrintTime(int(gap.Seconds))))
+		}
+		c.Println()
+	}
+
+	return nil
+}
+
+// parseDate reads a YYYY-MM-DD date, also accepting today and yesterday
+func parseDate(value string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	date, err := time.ParseInLocation(wakatime.DateFormat, value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("the date should look like %s but is %q", today.Format(wakatime.DateFormat), value)
+	}
+
+	return date, nil
+}
+
+// buildTimeline groups durations by project and finds the gaps between heartbeats
+// that were long enough to not count as coding
+func buildTimeline(date time.Time, project string, durations []wakatime.Duration, heartbeats []wakatime.RecordedHeartbeat) TimelineReport {
+	report := TimelineReport{Date: date.Format(wakatime.DateFormat), Projects: []TimelineProject{}, Gaps: []TimelineSpan{}}
+
+	byProject := map[string]*TimelineProject{}
+	for _, d := range durations {
+		p, ok := byProject[d.Project]
+		if !ok {
+			p = &TimelineProject{Name: d.Project}
+			byProject[d.Project] = p
+		}
+
+		p.TotalSeconds += d.Duration
+		p.Durations = append(p.Durations, TimelineSpan{Start: d.Start(), End: d.End(), Seconds: d.Duration})
+		report.TotalSeconds += d.Duration
+	}
+	for _, p := range byProject {
+		report.Projects = append(report.Projects, *p)
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		if report.Projects[i].TotalSeconds != report.Projects[j].TotalSeconds {
+			return report.Projects[i].TotalSeconds > report.Projects[j].TotalSeconds
+		}
+		return report.Projects[i].Name < report.Projects[j].Name
+	})
+
+	var times []time.Time
+	for _, hb := range heartbeats {
+		if project == "" || hb.Project == project {
+			times = append(times, hb.At())
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	report.Heartbeats = len(times)
+
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > keystrokeTimeout {
+			report.Gaps = append(report.Gaps, TimelineSpan{Start: times[i-1], End: times[i], Seconds: gap.Seconds()})
+		}
+	}
+
+	return report
+}
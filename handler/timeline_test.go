@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+func TestBuildTimelineProjectOrder(t *testing.T) {
+	date := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+	duration := func(project string, hour int, seconds float64) wakatime.Duration {
+		return wakatime.Duration{Project: project, Time: float64(date.Add(time.Duration(hour) * time.Hour).Unix()), Duration: seconds}
+	}
+
+	durations := []wakatime.Duration{
+		duration("zine", 8, 600),
+		duration("akami", 9, 1800),
+		duration("site", 10, 600),
+		duration("blog", 11, 600),
+		duration("zine", 12, 1200),
+	}
+	want := []string{"akami", "zine", "blog", "site"}
+
+	// map order changes from run to run so one pass could get lucky
+	for range 20 {
+		report := buildTimeline(date, "", durations, nil)
+
+		var names []string
+		for _, p := range report.Projects {
+			names = append(names, p.Name)
+		}
+		if !slices.Equal(names, want) {
+			t.Fatalf("projects are in the order %q, want %q", names, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/track"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// Track watches a folder and sends heartbeats for the files that change in it until interrupted
+func Track(c *cobra.Command, args []string) error {
+	format, err := initOutput(c)
+	if err != nil {
+		return err
+	}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	printTask(c, "Validating arguments")
+
+	throttle, _ := c.Flags().GetDuration("throttle")
+	flush, _ := c.Flags().GetDuration("flush")
+	reads, _ := c.Flags().GetBool("reads")
+	project, _ := c.Flags().GetString("project")
+	if throttle < 30*time.Second {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("a throttle of %s would send far more heartbeats than any editor; use at least 30s", throttle)
+	}
+	if flush < time.Second {
+		errorTask(c, "Validating arguments")
+		return fmt.Errorf("sending every %s would hammer the api; use at least 1s", flush)
+	}
+
+	api_key, api_url, err := getClientStuff(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		errorTask(c, "Validating arguments")
+		return err
+	}
+
+	completeTask(c, "Arguments look fine!")
+
+	printTask(c, "Loading api client")
+
+	client := wakatime.NewClientWithOptions(api_key, api_url)
+	// heartbeats made while offline wait in the queue instead of being dropped
+	client.Queue, err = openQueue()
+	if err != nil {
+		errorTask(c, "Loading api client")
+		return err
+	}
+
+	completeTask(c, "Loading api client")
+
+	printTask(c, "Watching "+dir)
+
+	tracker, err := track.New(dir, track.Options{
+		Client:   client,
+		Config:   cfg,
+		Project:  project,
+		Reads:    reads,
+		Throttle: throttle,
+		Flush:    flush,
+	})
+	if err != nil {
+		errorTask(c, "Watching "+dir)
+		return err
+	}
+	defer tracker.Close()
+
+	completeTask(c, fmt.Sprintf("Watching %d folders in %s", tracker.Tally().Folders, tracker.Root()))
+
+	// systemd stops services with SIGTERM so that's handled like ctrl-c
+	ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	live := false
+	if f, ok := c.OutOrStderr().(*os.File); ok {
+		live = term.IsTerminal(f.Fd())
+	}
+	if !isStructured(c) {
+		c.Printf("\n👀 tracking %s; press ctrl-c to stop\n\n", styles.Fancy.Render(tracker.Root()))
+		if live {
+			c.Print(trackLine(tracker.Tally()))
+		}
+	}
+
+	var last track.Tally
+	err = tracker.Run(ctx, func(tally track.Tally) {
+		switch {
+		case isStructured(c):
+		case live:
+			c.Printf("\r\033[K%s", trackLine(tally))
+		default:
+			logTrack(c, last, tally)
+		}
+		last = tally
+	})
+	if err != nil {
+		return err
+	}
+
+	tally := tracker.Tally()
+	if isStructured(c) {
+		return writeOutput(c, format, TrackReport{
+			Root:       tracker.Root(),
+			Folders:    tally.Folders,
+			Events:     tally.Events,
+			Ignored:    tally.Ignored,
+			Throttled:  tally.Throttled,
+			Heartbeats: tally.Heartbeats,
+			Sent:       tally.Sent,
+			Queued:     tally.Queued,
+			Failed:     tally.Failed,
+			Projects:   tally.Projects,
+		})
+	}
+
+	if live {
+		c.Println()
+	}
+	c.Printf("\n👋 stopped tracking after %s heartbeats: %s sent, %s queued and %s failed\n",
+		styles.Fancy.Render(fmt.Sprint(tally.Heartbeats)),
+		styles.Success.Render(fmt.Sprint(tally.Sent)),
+		styles.Warn.Render(fmt.Sprint(tally.Queued)),
+		styles.Bad.Render(fmt.Sprint(tally.Failed)),
+	)
+	if tally.Queued > 0 {
+		c.Println("📦 run", styles.Muted.Render("akami queue flush"), "once you're back online to send the queued ones")
+	}
+
+	return nil
+}
+
+// trackLine is the live tally shown on a terminal
+func trackLine(tally track.Tally) string {
+	line := fmt.Sprintf("%s heartbeats %s · %s sent · %s queued · %s waiting · %s",
+		styles.Fancy.Render("♥"),
+		styles.Fancy.Render(fmt.Sprint(tally.Heartbeats)),
+		styles.Success.Render(fmt.Sprint(tally.Sent)),
+		styles.Warn.Render(fmt.Sprint(tally.Queued)),
+		styles.Muted.Render(fmt.Sprint(tally.Pending)),
+		styles.Muted.Render(fmt.Sprintf("%d events, %d ignored, %d throttled", tally.Events, tally.Ignored, tally.Throttled)),
+	)
+	if tally.Failed > 0 {
+		line += " · " + styles.Bad.Render(fmt.Sprintf("%d failed", tally.Failed))
+	}
+
+	switch {
+	case tally.Err != nil:
+		line += " · " + styles.Bad.Render(tally.Err.Error())
+	case tally.Last != "":
+		line += " · " + styles.Muted.Render("last "+filepath.Base(tally.Last)+" at "+tally.LastAt.Format(time.TimeOnly))
+	}
+
+	return line
+}
+
+// logTrack prints a line for each new heartbeat and batch so the output reads well in a
+// log like the systemd journal, where rewriting a line doesn't work
+func logTrack(c *cobra.Command, last track.Tally, tally track.Tally) {
+	if tally.Heartbeats > last.Heartbeats {
+		c.Printf("%s heartbeat for %s\n", tally.LastAt.Format(time.TimeOnly), tally.Last)
+	}
+
+	sent, queued, failed := tally.Sent-last.Sent, tally.Queued-last.Queued, tally.Failed-last.Failed
+	if sent+queued+failed > 0 {
+		c.Printf("%s sent %d, queued %d and failed %d heartbeats\n", time.Now().Format(time.TimeOnly), sent, queued, failed)
+	}
+
+	if tally.Err != nil && (last.Err == nil || last.Err.Error() != tally.Err.Error()) {
+		c.Printf("%s %s\n", time.Now().Format(time.TimeOnly), tally.Err)
+	}
+}
@@ -3,10 +3,20 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/fang"
 	"github.com/spf13/cobra"
+	"github.com/taciturnaxolotl/akami/dash"
+	"github.com/taciturnaxolotl/akami/git"
 	"github.com/taciturnaxolotl/akami/handler"
+	"github.com/taciturnaxolotl/akami/prompt"
+	"github.com/taciturnaxolotl/akami/render"
+	"github.com/taciturnaxolotl/akami/shell"
+	"github.com/taciturnaxolotl/akami/streak"
+	"github.com/taciturnaxolotl/akami/track"
 )
 
 func main() {
@@ -26,35 +36,381 @@ func main() {
 	}
 
 	// diagnose command
-	cmd.AddCommand(&cobra.Command{
+	docCmd := &cobra.Command{
 		Use:   "doc",
 		Short: "diagnose potential hackatime issues",
 		RunE:  handler.Doctor,
 		Args:  cobra.NoArgs,
-	})
+	}
+	docCmd.Flags().StringSlice("only", nil, "Only run the checks with these ids (and whatever they depend on)")
+	docCmd.Flags().StringSlice("skip", nil, "Skip the checks with these ids")
+	docCmd.Flags().Bool("fix", false, "Offer to fix the problems found in your wakatime config")
+	docCmd.Flags().BoolP("yes", "y", false, "Apply fixes without asking first")
+	docCmd.Flags().Bool("all-profiles", false, "Check the wakatime config and every akami profile")
+	cmd.AddCommand(docCmd)
 
-	cmd.AddCommand(&cobra.Command{
+	testCmd := &cobra.Command{
 		Use:   "test",
 		Short: "send a test heartbeat to hackatime or whatever api url you provide",
 		RunE:  handler.TestHeartbeat,
 		Args:  cobra.NoArgs,
-	})
+	}
+	testCmd.Flags().String("file", "", "Send the heartbeat an editor plugin would for this file instead of a made up one")
+	testCmd.Flags().String("project", "", "Project to use instead of detecting one for --file")
+	testCmd.Flags().String("language", "", "Language to use instead of detecting one for --file")
+	testCmd.Flags().Bool("write", false, "Mark the --file heartbeat as a save")
+	cmd.AddCommand(testCmd)
 
-	cmd.AddCommand(&cobra.Command{
+	explainCmd := &cobra.Command{
+		Use:   "explain <path>",
+		Short: "see how your wakatime config treats a file and what heartbeat it would get",
+		Long:  "See how your wakatime config treats a file: which exclude and include patterns match it, what hide_file_names and friends hide, where its project, branch and language come from and the heartbeat an editor plugin would send for it. Nothing is sent.",
+		RunE:  handler.Explain,
+		Args:  cobra.ExactArgs(1),
+	}
+	explainCmd.Flags().String("project", "", "Project to use instead of detecting one")
+	explainCmd.Flags().String("language", "", "Language to use instead of detecting one")
+	explainCmd.Flags().Bool("write", false, "Explain the heartbeat sent when the file is saved")
+	cmd.AddCommand(explainCmd)
+
+	statusCmd := &cobra.Command{
 		Use:   "status",
 		Short: "get your hackatime stats",
 		RunE:  handler.Status,
 		Args:  cobra.NoArgs,
+	}
+	statusCmd.Flags().String("range", "", "Range to report on: today, yesterday, last_7_days, last_30_days, last_6_months, last_year, all_time, this_week, this_month or last_month")
+	statusCmd.Flags().String("from", "", "First day to report on as YYYY-MM-DD")
+	statusCmd.Flags().String("to", "", "Last day to report on as YYYY-MM-DD (defaults to today)")
+	statusCmd.Flags().StringSlice("show", nil, "Extra breakdowns to show: editors, os, machines, categories and dependencies")
+	statusCmd.Flags().Int("top", 5, "How many items to show in each breakdown; 0 shows them all")
+	statusCmd.Flags().String("sort", "value", "Order breakdowns by value, label or none")
+	statusCmd.Flags().String("chart", "horizontal", "Draw breakdowns as horizontal bars, one stacked bar or a sparkline")
+	statusCmd.Flags().Bool("goals", false, "Also show how your goals are going")
+	cmd.AddCommand(statusCmd)
+
+	timelineCmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "see a day of coding laid out hour by hour",
+		RunE:  handler.Timeline,
+		Args:  cobra.NoArgs,
+	}
+	timelineCmd.Flags().String("date", "today", "Day to show as YYYY-MM-DD, today or yesterday")
+	timelineCmd.Flags().String("project", "", "Only show time spent in this project")
+	timelineCmd.Flags().Int("width", render.DefaultTimelineWidth, "Number of columns the 24 hour timeline spans")
+	cmd.AddCommand(timelineCmd)
+
+	heatmapCmd := &cobra.Command{
+		Use:   "heatmap",
+		Short: "see a year of coding as a contribution graph",
+		RunE:  handler.Heatmap,
+		Args:  cobra.NoArgs,
+	}
+	heatmapCmd.Flags().Int("year", 0, "Calendar year to show instead of the last 12 months")
+	heatmapCmd.Flags().String("svg", "", "Also save the heatmap as an SVG image at this path")
+	heatmapCmd.Flags().String("png", "", "Also save the heatmap as a PNG image at this path")
+	cmd.AddCommand(heatmapCmd)
+
+	streakCmd := &cobra.Command{
+		Use:   "streak",
+		Short: "see how many days in a row you've been coding",
+		RunE:  handler.Streak,
+		Args:  cobra.NoArgs,
+	}
+	streakCmd.Flags().Duration("min", streak.DefaultMinimum, "How long you have to code on a day for it to count")
+	streakCmd.Flags().Int("days", 365, "How many days back to look for streaks")
+	streakCmd.Flags().Bool("refresh", false, "Fetch every day again instead of using the cached totals")
+	cmd.AddCommand(streakCmd)
+
+	// goal commands
+	goalsCmd := &cobra.Command{
+		Use:   "goals",
+		Short: "see how your coding goals are going",
+		RunE:  handler.GoalsList,
+		Args:  cobra.NoArgs,
+	}
+
+	goalsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list your goals with this period's progress and recent history",
+		RunE:  handler.GoalsList,
+		Args:  cobra.NoArgs,
+	})
+
+	goalsCmd.AddCommand(&cobra.Command{
+		Use:   "show <name>",
+		Short: "see how a goal went day by day or week by week",
+		RunE:  handler.GoalShow,
+		Args:  cobra.ExactArgs(1),
+	})
+
+	goalAddCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "save a local goal that akami tracks itself",
+		RunE:  handler.GoalAdd,
+		Args:  cobra.ExactArgs(1),
+	}
+	goalAddCmd.Flags().Duration("daily", 0, "How long to code every day, like 2h")
+	goalAddCmd.Flags().Duration("weekly", 0, "How long to code every week, like 10h")
+	goalAddCmd.Flags().StringSlice("language", nil, "Only count time spent in these languages")
+	goalAddCmd.Flags().StringSlice("project", nil, "Only count time spent in these projects")
+	goalAddCmd.Flags().Bool("force", false, "Replace the goal if it already exists")
+	goalsCmd.AddCommand(goalAddCmd)
+
+	goalsCmd.AddCommand(&cobra.Command{
+		Use:               "remove <name>",
+		Short:             "delete a local goal",
+		RunE:              handler.GoalRemove,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: handler.CompleteGoals,
+	})
+
+	cmd.AddCommand(goalsCmd)
+
+	dashCmd := &cobra.Command{
+		Use:   "dash",
+		Short: "keep an eye on your coding time in a live dashboard",
+		RunE:  handler.Dash,
+		Args:  cobra.NoArgs,
+	}
+	dashCmd.Flags().Duration("refresh", dash.DefaultRefresh, "How often to refresh today's time and the week's stats")
+	cmd.AddCommand(dashCmd)
+
+	trackCmd := &cobra.Command{
+		Use:   "track [dir]",
+		Short: "send heartbeats for files that change in a folder, for editors without a plugin",
+		Long: `Send heartbeats for the files that change in a folder, for editors without a wakatime plugin.
+
+Folders git ignores aren't watched and the exclude, include and hide settings in your wakatime config apply like they do for editor plugins. Heartbeats for the same file are throttled like plugins do and sent in batches.
+
+It runs until interrupted and logs a line per heartbeat when it isn't attached to a terminal, so it works as a systemd user service:
+
+  [Unit]
+  Description=akami track
+
+  [Service]
+  ExecStart=/usr/bin/akami track %h/code/project
+  Restart=on-failure
+
+  [Install]
+  WantedBy=default.target`,
+		RunE: handler.Track,
+		Args: cobra.MaximumNArgs(1),
+	}
+	trackCmd.Flags().Bool("reads", false, "Also send heartbeats for files that are opened without being changed (linux only)")
+	trackCmd.Flags().Duration("throttle", track.DefaultThrottle, "How long to wait before sending another heartbeat for the same file")
+	trackCmd.Flags().Duration("flush", track.DefaultFlush, "How often to send the heartbeats that have built up")
+	trackCmd.Flags().String("project", "", "Project to use for every file instead of detecting one")
+	cmd.AddCommand(trackCmd)
+
+	// shell integration commands
+	cmd.AddCommand(&cobra.Command{
+		Use:       "shell-init <shell>",
+		Short:     "print hooks that track time spent running commands in zsh, bash or fish",
+		Long:      "Print hooks that track time spent running commands in zsh, bash or fish.\n\nThe hooks call akami hook before and after every command, which sends an app heartbeat for the project the folder belongs to with a category like building, debugging or running tests worked out from the command. Load them from your shell's config with eval \"$(akami shell-init zsh)\" or akami shell-init fish | source.",
+		RunE:      handler.ShellInit,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: shell.Names,
 	})
 
+	hookCmd := &cobra.Command{
+		Use:   "hook",
+		Short: "record a terminal command as a heartbeat; the shell-init hooks run this for you",
+		RunE:  handler.Hook,
+		Args:  cobra.NoArgs,
+	}
+	hookCmd.Flags().String("command", "", "The command that's being run")
+	hookCmd.Flags().String("cwd", "", "The folder it's run in (defaults to the current one)")
+	hookCmd.Flags().Duration("throttle", shell.DefaultThrottle, "How long to wait before sending another heartbeat for the same command, project and category")
+	hookCmd.Flags().Bool("send", false, "Send the heartbeats waiting in the spool now instead of recording one")
+	cmd.AddCommand(hookCmd)
+
+	// git commands
+	gitCmd := &cobra.Command{
+		Use:   "git",
+		Short: "attribute commits and branches with git hooks and see time per commit",
+	}
+
+	gitCmd.AddCommand(&cobra.Command{
+		Use:   "install-hooks [repo]",
+		Short: "add post-commit and post-checkout hooks that send heartbeats with the right branch",
+		RunE:  handler.GitInstallHooks,
+		Args:  cobra.MaximumNArgs(1),
+	})
+
+	gitReportCmd := &cobra.Command{
+		Use:   "report [repo]",
+		Short: "line up your commits with the time you coded to see time per commit and branch",
+		RunE:  handler.GitReport,
+		Args:  cobra.MaximumNArgs(1),
+	}
+	gitReportCmd.Flags().Int("days", 14, "How many days back to report on; each day costs one durations request plus one for every branch coded on that day")
+	gitReportCmd.Flags().String("project", "", "Project the repository is tracked as instead of detecting it")
+	gitReportCmd.Flags().String("author", "", "Only count commits by this author (defaults to your git user.email)")
+	gitReportCmd.Flags().Bool("all-authors", false, "Count everyone's commits, not just yours")
+	gitCmd.AddCommand(gitReportCmd)
+
+	gitCmd.AddCommand(&cobra.Command{
+		Use:       "hook <name> [args...]",
+		Short:     "send heartbeats for a git hook; the installed hooks run this for you",
+		RunE:      handler.GitHook,
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: git.HookNames,
+		Hidden:    true,
+	})
+
+	cmd.AddCommand(gitCmd)
+
+	// prompt commands
+	promptCmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "print today's coding time for your shell prompt or status bar",
+		Long:  "Print today's coding time for your shell prompt or status bar.\n\nThe numbers come from a cache that's refreshed in the background once it's older than --max-age so this never waits on the api. The format can use " + strings.Join(prompt.Placeholders, ", ") + ".",
+		RunE:  handler.Prompt,
+		Args:  cobra.NoArgs,
+	}
+	promptCmd.Flags().String("format", prompt.DefaultFormat, "What to print, with placeholders like {today} and {project}")
+	promptCmd.Flags().Duration("max-age", prompt.DefaultMaxAge, "How old the cached numbers can get before they're refreshed in the background")
+	promptCmd.Flags().Bool("waybar", false, "Print json for a waybar custom module instead of plain text")
+	promptCmd.Flags().Bool("refresh", false, "Fetch today's numbers now instead of in the background")
+
+	promptCmd.AddCommand(&cobra.Command{
+		Use:       "snippet <tool>",
+		Short:     "print a config snippet for starship, tmux, polybar or waybar",
+		RunE:      handler.PromptSnippet,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: prompt.SnippetNames,
+	})
+
+	cmd.AddCommand(promptCmd)
+
+	// offline queue commands
+	queueCmd := &cobra.Command{
+		Use:   "queue",
+		Short: "manage heartbeats that couldn't be sent while you were offline",
+	}
+
+	queueCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the heartbeats waiting in the offline queue",
+		RunE:  handler.QueueList,
+		Args:  cobra.NoArgs,
+	})
+
+	flushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "send every queued heartbeat to hackatime",
+		RunE:  handler.QueueFlush,
+		Args:  cobra.NoArgs,
+	}
+	flushCmd.Flags().Int("retries", 5, "How many times to retry a batch before giving up")
+	queueCmd.AddCommand(flushCmd)
+
+	queueCmd.AddCommand(&cobra.Command{
+		Use:   "purge",
+		Short: "delete every heartbeat in the offline queue",
+		RunE:  handler.QueuePurge,
+		Args:  cobra.NoArgs,
+	})
+
+	queueCmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "summarise what is waiting in the offline queue",
+		RunE:  handler.QueueStats,
+		Args:  cobra.NoArgs,
+	})
+
+	cmd.AddCommand(queueCmd)
+
+	// wakatime-cli offline queue commands
+	offlineCmd := &cobra.Command{
+		Use:   "offline",
+		Short: "work with wakatime-cli's own offline heartbeat queue",
+	}
+
+	offlineFlushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "send the heartbeats stuck in wakatime-cli's offline queue",
+		RunE:  handler.OfflineFlush,
+		Args:  cobra.NoArgs,
+	}
+	offlineFlushCmd.Flags().Int("retries", 5, "How many times to retry a batch before giving up")
+	offlineCmd.AddCommand(offlineFlushCmd)
+
+	cmd.AddCommand(offlineCmd)
+
+	// profile commands
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "manage named profiles for switching between backends",
+	}
+
+	profileAddCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "save a profile from --url and --key or --key-cmd",
+		RunE:  handler.ProfileAdd,
+		Args:  cobra.ExactArgs(1),
+	}
+	profileAddCmd.Flags().String("key-cmd", "", "Command that prints the api key, like api_key_vault_cmd")
+	profileAddCmd.Flags().Bool("use", false, "Make the new profile the active one")
+	profileAddCmd.Flags().Bool("force", false, "Replace the profile if it already exists")
+	profileCmd.AddCommand(profileAddCmd)
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list your profiles and which one is active",
+		RunE:  handler.ProfileList,
+		Args:  cobra.NoArgs,
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:               "remove <name>",
+		Short:             "delete a profile",
+		RunE:              handler.ProfileRemove,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: handler.CompleteProfiles,
+	})
+
+	profileCmd.AddCommand(&cobra.Command{
+		Use:               "use <name>",
+		Short:             "pick the profile used when --profile isn't passed",
+		RunE:              handler.ProfileUse,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: handler.CompleteProfiles,
+	})
+
+	cmd.AddCommand(profileCmd)
+
+	// mock server for trying akami out without an account
+	serveMockCmd := &cobra.Command{
+		Use:   "serve-mock",
+		Short: "run a fake hackatime api on localhost for testing and demos",
+		RunE:  handler.ServeMock,
+		Args:  cobra.NoArgs,
+	}
+	serveMockCmd.Flags().String("addr", "127.0.0.1:8787", "Address to listen on")
+	serveMockCmd.Flags().String("fail", "none", "Make every request fail: none, 401, 429, 500, slow or malformed")
+	serveMockCmd.Flags().Duration("delay", 2*time.Second, "How long slow requests take")
+	serveMockCmd.Flags().Bool("empty", false, "Start without the week of demo heartbeats")
+	serveMockCmd.Flags().Bool("goals", false, "Serve the goals endpoints with a couple of demo goals like wakatime.com does")
+	cmd.AddCommand(serveMockCmd)
+
 	cmd.PersistentFlags().StringP("url", "u", "", "The base url for the hackatime client")
 	cmd.PersistentFlags().StringP("key", "k", "", "API key to use for authentication")
+	cmd.PersistentFlags().StringP("output", "o", "text", "Output format for status, doc, test, explain, track, streak, heatmap, goals, timeline and git report: text, json or yaml")
+	cmd.PersistentFlags().StringP("profile", "p", "", "Named profile to use instead of the active one")
+	cmd.RegisterFlagCompletionFunc("profile", handler.CompleteProfiles)
+
+	// cancel whatever is in flight when ctrl-c is pressed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	// this is where we get the fancy fang magic ✨
 	if err := fang.Execute(
-		context.Background(),
+		ctx,
 		cmd,
 	); err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
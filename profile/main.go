@@ -0,0 +1,207 @@
+// Package profile manages named api backends akami can switch between, like
+// hackatime, a self-hosted wakapi and wakatime.com. Profiles live in akami's own
+// config file rather than ~/.wakatime.cfg so wakatime-cli never sees them.
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Default is the name of the implicit profile backed by the wakatime config
+const Default = "default"
+
+var (
+	// ErrNotFound occurs when a profile doesn't exist
+	ErrNotFound = errors.New("profile not found")
+	// ErrExists occurs when adding a profile whose name is taken
+	ErrExists = errors.New("profile already exists")
+	// ErrInvalidName occurs when a profile name can't be used
+	ErrInvalidName = errors.New("invalid profile name")
+)
+
+// Profile is a single api backend
+type Profile struct {
+	// APIURL is the base url of the backend's api
+	APIURL string `yaml:"api_url"`
+	// APIKey is the key to authenticate with
+	APIKey string `yaml:"api_key,omitempty"`
+	// APIKeyVaultCmd is a command that prints the key, used when APIKey is empty
+	APIKeyVaultCmd string `yaml:"api_key_vault_cmd,omitempty"`
+}
+
+// Credentials resolves the profile's key and url. The --key and --url flags still
+// win but the environment and the wakatime config are ignored since picking a
+// profile means picking its backend.
+func (p Profile) Credentials(ctx context.Context, name string, overrides config.Overrides) (config.Credentials, error) {
+	var creds config.Credentials
+
+	switch {
+	case overrides.APIKey != "":
+		creds.APIKey = config.Value{Value: overrides.APIKey, Source: config.SourceFlag, Origin: "--key"}
+	case p.APIKey != "":
+		creds.APIKey = config.Value{Value: p.APIKey, Source: config.SourceProfile, Origin: name}
+	case p.APIKeyVaultCmd != "":
+		key, err := config.RunVaultCmd(ctx, p.APIKeyVaultCmd)
+		if err != nil {
+			return creds, err
+		}
+		creds.APIKey = config.Value{Value: key, Source: config.SourceVault, Origin: p.APIKeyVaultCmd}
+	}
+
+	switch {
+	case overrides.APIURL != "":
+		creds.APIURL = config.Value{Value: overrides.APIURL, Source: config.SourceFlag, Origin: "--url"}
+	case p.APIURL != "":
+		creds.APIURL = config.Value{Value: p.APIURL, Source: config.SourceProfile, Origin: name}
+	}
+
+	return creds, nil
+}
+
+// Store is akami's config file holding every profile
+type Store struct {
+	// Active is the profile used when --profile isn't passed; empty means the default
+	Active string `yaml:"active,omitempty"`
+	// Profiles are the named profiles
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+// DefaultPath returns where akami keeps its config file
+func DefaultPath() (string, error) {
+	dir, err := utils.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// Load reads the store at path; a missing file is an empty store
+func Load(path string) (*Store, error) {
+	store := &Store{Profiles: map[string]Profile{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	if store.Profiles == nil {
+		store.Profiles = map[string]Profile{}
+	}
+
+	return store, nil
+}
+
+// Path returns where the store is saved
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Save writes the store back to disk; it holds api keys so only the user can read it
+func (s *Store) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// Names returns every profile name sorted
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.Profiles))
+	for name := range s.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Get returns the named profile
+func (s *Store) Get(name string) (Profile, error) {
+	p, ok := s.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	return p, nil
+}
+
+// Add stores a new profile, replacing an existing one only when overwrite is set
+func (s *Store) Add(name string, p Profile, overwrite bool) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	if _, ok := s.Profiles[name]; ok && !overwrite {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	}
+
+	s.Profiles[name] = p
+	return nil
+}
+
+// Remove deletes a profile, falling back to the default if it was active
+func (s *Store) Remove(name string) error {
+	if _, ok := s.Profiles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	delete(s.Profiles, name)
+	if s.Active == name {
+		s.Active = ""
+	}
+
+	return nil
+}
+
+// Use makes a profile the active one; using Default goes back to the wakatime config
+func (s *Store) Use(name string) error {
+	if name == Default {
+		s.Active = ""
+		return nil
+	}
+	if _, ok := s.Profiles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	s.Active = name
+	return nil
+}
+
+// validName checks a name can be typed on the command line and doesn't shadow the default
+func validName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: profiles need a name", ErrInvalidName)
+	}
+	if name == Default {
+		return fmt.Errorf("%w: %q means the wakatime config", ErrInvalidName, name)
+	}
+	if strings.ContainsAny(name, " \t\n/\\,") {
+		return fmt.Errorf("%w: %q can't contain spaces, slashes or commas", ErrInvalidName, name)
+	}
+
+	return nil
+}
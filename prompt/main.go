@@ -0,0 +1,138 @@
+// Package prompt backs akami prompt, which puts today's coding time in shell prompts
+// and status bars. Prompts redraw constantly so nothing here touches the network:
+// the numbers come from a small cache file that akami refreshes in the background
+// once it's older than a minute or so.
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+)
+
+// DefaultFormat is what akami prompt prints when no format is given
+const DefaultFormat = "{today}"
+
+// DefaultMaxAge is how old the cache can get before a refresh is started
+const DefaultMaxAge = time.Minute
+
+// refreshTimeout is how long a refresh gets before another one may be started, so a
+// refresh that hangs or dies doesn't stop the cache from ever being refreshed again
+const refreshTimeout = 30 * time.Second
+
+// Placeholders lists every placeholder a format can use
+var Placeholders = []string{"{today}", "{today_long}", "{digital}", "{seconds}", "{project}", "{language}"}
+
+// Cache is the last known coding activity for today
+type Cache struct {
+	// Day is the day the numbers are for as YYYY-MM-DD
+	Day string `json:"day"`
+	// TodaySeconds is the time coded today
+	TodaySeconds float64 `json:"today_seconds"`
+	// Project is the project the most time was spent in today
+	Project string `json:"project,omitempty"`
+	// Language is the language the most time was spent in today
+	Language string `json:"language,omitempty"`
+	// Updated is when the numbers were last fetched
+	Updated time.Time `json:"updated,omitzero"`
+	// Error is why the last refresh failed, cleared by the next one that works
+	Error string `json:"error,omitempty"`
+	// Refreshing is when a background refresh was last started or last failed
+	Refreshing time.Time `json:"refreshing,omitzero"`
+
+	path string
+}
+
+// CachePath returns where the cache for an account is kept. account is anything
+// that tells accounts apart without having to resolve their api key, which can
+// mean running a vault command, like the profile name and any --url and --key flags.
+func CachePath(account string) (string, error) {
+	dir, err := utils.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(account))
+	return filepath.Join(dir, "prompt", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// LoadCache reads the cache at path; a missing cache is empty rather than an error
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return cache, err
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return cache, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+
+	return cache, nil
+}
+
+// Save writes the cache back to disk
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	// a prompt can read the cache at any moment so it's swapped in whole
+	tmp := fmt.Sprintf("%s.%d.tmp", c.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// NeedsRefresh reports whether the cache is older than maxAge, or from another day,
+// and no refresh has been started recently
+func (c *Cache) NeedsRefresh(maxAge time.Duration, now time.Time) bool {
+	if now.Sub(c.Refreshing) < refreshTimeout {
+		return false
+	}
+
+	return c.Day != now.Format(time.DateOnly) || now.Sub(c.Updated) > maxAge
+}
+
+// Today returns the cache's numbers as of now. Numbers cached on an earlier day are
+// for that day so today starts back at nothing.
+func (c *Cache) Today(now time.Time) Cache {
+	if c.Day == now.Format(time.DateOnly) {
+		return *c
+	}
+
+	return Cache{Day: now.Format(time.DateOnly), Updated: c.Updated, Error: c.Error}
+}
+
+// Format fills the placeholders in format in with the cache's numbers. Placeholders
+// akami doesn't know are left alone.
+func (c Cache) Format(format string) string {
+	seconds := int(c.TodaySeconds)
+
+	return strings.NewReplacer(
+		"{today}", utils.ShortTime(c.TodaySeconds),
+		"{today_long}", utils.PrettyPrintTime(seconds),
+		"{digital}", fmt.Sprintf("%d:%02d", seconds/3600, seconds%3600/60),
+		"{seconds}", fmt.Sprint(seconds),
+		"{project}", c.Project,
+		"{language}", c.Language,
+	).Replace(format)
+}
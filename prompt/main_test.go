@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNeedsRefresh(t *testing.T) {
+	now := time.Date(2025, 3, 12, 0, 0, 20, 0, time.UTC)
+	today, yesterday := "2025-03-12", "2025-03-11"
+
+	tests := []struct {
+		name  string
+		cache Cache
+		want  bool
+	}{
+		{name: "empty", cache: Cache{}, want: true},
+		{name: "fresh", cache: Cache{Day: today, Updated: now.Add(-10 * time.Second)}, want: false},
+		{name: "exactly max age", cache: Cache{Day: today, Updated: now.Add(-DefaultMaxAge)}, want: false},
+		{name: "stale", cache: Cache{Day: today, Updated: now.Add(-2 * time.Minute)}, want: true},
+		{name: "fresh but from yesterday", cache: Cache{Day: yesterday, Updated: now.Add(-30 * time.Second)}, want: true},
+		{name: "stale with a refresh running", cache: Cache{Day: today, Updated: now.Add(-2 * time.Minute), Refreshing: now.Add(-5 * time.Second)}, want: false},
+		{name: "yesterday with a refresh running", cache: Cache{Day: yesterday, Refreshing: now.Add(-5 * time.Second)}, want: false},
+		{name: "stale with a refresh that hung", cache: Cache{Day: today, Updated: now.Add(-2 * time.Minute), Refreshing: now.Add(-refreshTimeout - time.Second)}, want: true},
+		{name: "fresh after an old refresh", cache: Cache{Day: today, Updated: now.Add(-10 * time.Second), Refreshing: now.Add(-time.Hour)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cache.NeedsRefresh(DefaultMaxAge, now); got != tt.want {
+				t.Errorf("NeedsRefresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToday(t *testing.T) {
+	now := time.Date(2025, 3, 12, 0, 0, 20, 0, time.UTC)
+	updated := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		cache Cache
+		want  Cache
+	}{
+		{
+			name:  "same day",
+			cache: Cache{Day: "2025-03-12", TodaySeconds: 90, Project: "akami", Language: "Go", Updated: updated},
+			want:  Cache{Day: "2025-03-12", TodaySeconds: 90, Project: "akami", Language: "Go", Updated: updated},
+		},
+		{
+			name:  "yesterday starts over",
+			cache: Cache{Day: "2025-03-11", TodaySeconds: 7200, Project: "akami", Language: "Go", Updated: updated, Error: "offline"},
+			want:  Cache{Day: "2025-03-12", Updated: updated, Error: "offline"},
+		},
+		{
+			name:  "empty",
+			cache: Cache{},
+			want:  Cache{Day: "2025-03-12"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cache.Today(now); got != tt.want {
+				t.Errorf("Today() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
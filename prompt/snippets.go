@@ -0,0 +1,33 @@
+package prompt
+
+// Snippets are ready-made configs for showing akami prompt in other tools by name
+var Snippets = map[string]string{
+	"starship": `# ~/.config/starship.toml
+[custom.akami]
+command = "akami prompt --format '{today}'"
+when = true
+shell = ["sh"]
+format = "[⏱ $output]($style) "
+style = "bold purple"
+`,
+	"tmux": `# ~/.tmux.conf
+set -g status-interval 30
+set -g status-right "#(akami prompt --format '⏱ {today} {project}') %H:%M"
+`,
+	"polybar": `; ~/.config/polybar/config.ini
+[module/akami]
+type = custom/script
+exec = akami prompt --format "⏱ {today} {project}"
+interval = 30
+`,
+	"waybar": `// ~/.config/waybar/config, then add "custom/akami" to a modules list
+"custom/akami": {
+    "exec": "akami prompt --waybar --format '⏱ {today}'",
+    "return-type": "json",
+    "interval": 30
+}
+`,
+}
+
+// SnippetNames lists the tools there are snippets for in the order they're shown
+var SnippetNames = []string{"starship", "tmux", "polybar", "waybar"}
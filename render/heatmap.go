@@ -0,0 +1,347 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/taciturnaxolotl/akami/styles"
+	"github.com/taciturnaxolotl/akami/utils"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// heatmapLevels is how many shades of activity a heatmap has besides empty days
+const heatmapLevels = 4
+
+// heatmapTerminal are the colours of each level in the terminal, which are GitHub's
+// dark theme greens since they read well on dark and light backgrounds alike
+var heatmapTerminal = []color.Color{lipgloss.Color("#0e4429"), lipgloss.Color("#006d32"), lipgloss.Color("#26a641"), lipgloss.Color("#39d353")}
+
+// heatmapImage are the colours of each level in exported images starting with empty
+// days, which are GitHub's light theme so they sit nicely in a README
+var heatmapImage = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+// heatmapWeekdays label every other row of the grid; weeks start on monday
+var heatmapWeekdays = []string{"Mon", "", "Wed", "", "Fri", "", ""}
+
+// HeatmapDay is a single day's value in a heatmap
+type HeatmapDay struct {
+	Date  time.Time
+	Value float64
+	// Text describes the value in tooltips of exported images
+	Text string
+}
+
+// Heatmap lays days out as a calendar grid with a column per week, shading each day
+// by how its value compares to the rest like a GitHub contribution graph
+type Heatmap struct {
+	// Days are the days to draw; days between the first and last that are missing count as empty
+	Days []HeatmapDay
+	// Width is how many columns the terminal grid can use and defaults to DefaultWidth
+	Width int
+}
+
+// heatmapCell is a day's place in the grid
+type heatmapCell struct {
+	week    int
+	weekday int
+	day     HeatmapDay
+	level   int
+}
+
+// heatmapLayout is the grid worked out from the days
+type heatmapLayout struct {
+	weeks  int
+	cells  []heatmapCell
+	months map[int]string
+}
+
+// layout places every day from the first to the last in the grid and works out its level
+func (h Heatmap) layout() heatmapLayout {
+	layout := heatmapLayout{months: map[int]string{}}
+	if len(h.Days) == 0 {
+		return layout
+	}
+
+	byDate := map[string]HeatmapDay{}
+	first, last := h.Days[0].Date, h.Days[0].Date
+	for _, day := range h.Days {
+		byDate[day.Date.Format(time.DateOnly)] = day
+		if day.Date.Before(first) {
+			first = day.Date
+		}
+		if day.Date.After(last) {
+			last = day.Date
+		}
+	}
+
+	first = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+	monday := first.AddDate(0, 0, -weekday(first))
+
+	// levels split the days with any activity into quartiles like github does
+	var values []float64
+	for _, day := range h.Days {
+		if day.Value > 0 {
+			values = append(values, day.Value)
+		}
+	}
+	slices.Sort(values)
+	thresholds := make([]float64, heatmapLevels-1)
+	for i := range thresholds {
+		if len(values) > 0 {
+			thresholds[i] = values[len(values)*(i+1)/heatmapLevels]
+		}
+	}
+
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		day, ok := byDate[date.Format(time.DateOnly)]
+		if !ok {
+			day = HeatmapDay{Date: date}
+		}
+
+		cell := heatmapCell{week: utils.DaysBetween(monday, date) / 7, weekday: weekday(date), day: day}
+		if day.Value > 0 {
+			cell.level = 1
+			for _, threshold := range thresholds {
+				if day.Value >= threshold {
+					cell.level++
+				}
+			}
+			cell.level = min(cell.level, heatmapLevels)
+		}
+
+		layout.cells = append(layout.cells, cell)
+		layout.weeks = cell.week + 1
+
+		// label each month in the first column it starts in
+		if date.Day() == 1 || date.Equal(first) {
+			week := cell.week
+			if cell.weekday > 0 {
+				week++
+			}
+			if _, ok := layout.months[week]; !ok || date.Day() == 1 {
+				layout.months[week] = date.Format("Jan")
+			}
+		}
+	}
+
+	return layout
+}
+
+// monthWeeks returns the columns that have a month label in order
+func (l heatmapLayout) monthWeeks() []int {
+	weeks := make([]int, 0, len(l.months))
+	for week := range l.months {
+		weeks = append(weeks, week)
+	}
+	slices.Sort(weeks)
+
+	return weeks
+}
+
+// weekday returns how many days t is after monday
+func weekday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// String draws the heatmap for the terminal. Each day is two columns wide when the
+// grid fits and one column otherwise, and the oldest weeks are dropped when even
+// that doesn't fit.
+func (h Heatmap) String() string {
+	layout := h.layout()
+	if layout.weeks == 0 {
+		return ""
+	}
+
+	width := h.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	labelWidth := len("Mon ") + 2
+	cellWidth := 2
+	if labelWidth+layout.weeks*cellWidth > width {
+		cellWidth = 1
+	}
+	skip := max(layout.weeks-(width-labelWidth)/cellWidth, 0)
+	weeks := layout.weeks - skip
+
+	// month labels go above the column they start in as long as there's room
+	months := []rune(strings.Repeat(" ", weeks*cellWidth+3))
+	next := 0
+	for week := skip; week < layout.weeks; week++ {
+		name, ok := layout.months[week]
+		pos := (week - skip) * cellWidth
+		if !ok || pos < next {
+			continue
+		}
+		copy(months[pos:], []rune(name))
+		next = pos + len(name) + 1
+	}
+
+	grid := make([][]string, 7)
+	for row := range grid {
+		grid[row] = make([]string, weeks)
+		for col := range grid[row] {
+			grid[row][col] = strings.Repeat(" ", cellWidth)
+		}
+	}
+
+	block := "■" + strings.Repeat(" ", cellWidth-1)
+	for _, cell := range layout.cells {
+		if cell.week < skip {
+			continue
+		}
+
+		text := styles.Muted.Render("·" + strings.Repeat(" ", cellWidth-1))
+		if cell.level > 0 {
+			text = lipgloss.NewStyle().Foreground(heatmapTerminal[cell.level-1]).Render(block)
+		}
+		grid[cell.weekday][cell.week-skip] = text
+	}
+
+	var out strings.Builder
+	out.WriteString(strings.Repeat(" ", labelWidth) + styles.Muted.Render(strings.TrimRight(string(months), " ")) + "\n")
+	for row, cells := range grid {
+		out.WriteString("  " + styles.Muted.Render(Pad(heatmapWeekdays[row], labelWidth-2)) + strings.Join(cells, "") + "\n")
+	}
+
+	legend := []string{styles.Muted.Render("less ")}
+	legend = append(legend, styles.Muted.Render("· "))
+	for _, c := range heatmapTerminal {
+		legend = append(legend, lipgloss.NewStyle().Foreground(c).Render("■ "))
+	}
+	legend = append(legend, styles.Muted.Render("more"))
+	out.WriteString("\n" + strings.Repeat(" ", labelWidth) + strings.Join(legend, "") + "\n")
+
+	return out.String()
+}
+
+// the sizes of an exported grid in pixels before scaling
+const (
+	heatmapCellSize  = 10
+	heatmapGap       = 3
+	heatmapLeft      = 32
+	heatmapTop       = 20
+	heatmapBottom    = 30
+	heatmapFontSize  = 9
+	heatmapFontColor = "#767676"
+)
+
+// size returns the width and height of an exported grid in pixels before scaling
+func (l heatmapLayout) size() (int, int) {
+	step := heatmapCellSize + heatmapGap
+	return heatmapLeft + l.weeks*step, heatmapTop + 7*step + heatmapBottom
+}
+
+// position returns the top left corner of a cell in pixels before scaling
+func (c heatmapCell) position() (int, int) {
+	step := heatmapCellSize + heatmapGap
+	return heatmapLeft + c.week*step, heatmapTop + c.weekday*step
+}
+
+// WriteSVG writes the heatmap as an SVG image with a tooltip on every day
+func (h Heatmap) WriteSVG(w io.Writer) error {
+	layout := h.layout()
+	width, height := layout.size()
+
+	var out strings.Builder
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="-apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif" font-size="%d" fill="%s">`+"\n", width, height, width, height, heatmapFontSize, heatmapFontColor)
+
+	step := heatmapCellSize + heatmapGap
+	for _, week := range layout.monthWeeks() {
+		if name := layout.months[week]; week < layout.weeks {
+			fmt.Fprintf(&out, `  <text x="%d" y="%d">%s</text>`+"\n", heatmapLeft+week*step, heatmapTop-6, name)
+		}
+	}
+	for row, name := range heatmapWeekdays {
+		if name != "" {
+			fmt.Fprintf(&out, `  <text x="0" y="%d">%s</text>`+"\n", heatmapTop+row*step+heatmapCellSize-1, name)
+		}
+	}
+
+	for _, cell := range layout.cells {
+		x, y := cell.position()
+		title := cell.day.Date.Format("Mon Jan 2 2006")
+		if cell.day.Text != "" {
+			title += ": " + cell.day.Text
+		}
+		fmt.Fprintf(&out, `  <rect x="%d" y="%d" width="%d" height="%d" rx="2" ry="2" fill="%s"><title>%s</title></rect>`+"\n", x, y, heatmapCellSize, heatmapCellSize, heatmapImage[cell.level], title)
+	}
+
+	// the legend sits under the bottom right corner like github's
+	legendX := max(width-(len(heatmapImage)*step+60), heatmapLeft)
+	legendY := heatmapTop + 7*step + 10
+	fmt.Fprintf(&out, `  <text x="%d" y="%d">Less</text>`+"\n", legendX, legendY+heatmapCellSize-1)
+	for i, fill := range heatmapImage {
+		fmt.Fprintf(&out, `  <rect x="%d" y="%d" width="%d" height="%d" rx="2" ry="2" fill="%s"/>`+"\n", legendX+28+i*step, legendY, heatmapCellSize, heatmapCellSize, fill)
+	}
+	fmt.Fprintf(&out, `  <text x="%d" y="%d">More</text>`+"\n", legendX+28+len(heatmapImage)*step+4, legendY+heatmapCellSize-1)
+	out.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// heatmapScale is how much exported PNGs are scaled up so they stay crisp
+const heatmapScale = 2
+
+// WritePNG writes the heatmap as a PNG image on a white background
+func (h Heatmap) WritePNG(w io.Writer) error {
+	layout := h.layout()
+	width, height := layout.size()
+
+	img := image.NewRGBA(image.Rect(0, 0, width*heatmapScale, height*heatmapScale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	rect := func(x, y int, fill string) {
+		r := image.Rect(x*heatmapScale, y*heatmapScale, (x+heatmapCellSize)*heatmapScale, (y+heatmapCellSize)*heatmapScale)
+		draw.Draw(img, r, image.NewUniform(hexColor(fill)), image.Point{}, draw.Src)
+	}
+	text := func(x, y int, s string) {
+		d := font.Drawer{Dst: img, Src: image.NewUniform(hexColor(heatmapFontColor)), Face: basicfont.Face7x13, Dot: fixed.P(x*heatmapScale, y*heatmapScale)}
+		d.DrawString(s)
+	}
+
+	step := heatmapCellSize + heatmapGap
+	for _, week := range layout.monthWeeks() {
+		if name := layout.months[week]; week < layout.weeks {
+			text(heatmapLeft+week*step, heatmapTop-6, name)
+		}
+	}
+	for row, name := range heatmapWeekdays {
+		if name != "" {
+			text(0, heatmapTop+row*step+heatmapCellSize-1, name)
+		}
+	}
+	for _, cell := range layout.cells {
+		x, y := cell.position()
+		rect(x, y, heatmapImage[cell.level])
+	}
+
+	legendX := max(width-(len(heatmapImage)*step+60), heatmapLeft)
+	legendY := heatmapTop + 7*step + 10
+	text(legendX, legendY+heatmapCellSize-1, "Less")
+	for i, fill := range heatmapImage {
+		rect(legendX+28+i*step, legendY, fill)
+	}
+	text(legendX+28+len(heatmapImage)*step+4, legendY+heatmapCellSize-1, "More")
+
+	return png.Encode(w, img)
+}
+
+// hexColor turns a #rrggbb colour into a color.Color
+func hexColor(hex string) color.Color {
+	var r, g, b uint8
+	fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
+	return color.RGBA{r, g, b, 0xff}
+}
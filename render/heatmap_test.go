@@ -0,0 +1,50 @@
+package render
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeatmapLayoutWeeks(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+
+	tests := []struct {
+		name  string
+		first time.Time
+		days  int
+		weeks int
+	}{
+		{name: "one week utc", first: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), days: 7, weeks: 1},
+		{name: "starts midweek", first: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), days: 7, weeks: 2},
+		{name: "spring forward", first: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), days: 14, weeks: 2},
+		{name: "fall back", first: time.Date(2025, 10, 27, 0, 0, 0, 0, newYork), days: 14, weeks: 2},
+		{name: "whole year", first: time.Date(2024, 12, 30, 0, 0, 0, 0, newYork), days: 364, weeks: 52},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var days []HeatmapDay
+			for i := range tt.days {
+				days = append(days, HeatmapDay{Date: tt.first.AddDate(0, 0, i), Value: 1})
+			}
+
+			layout := Heatmap{Days: days}.layout()
+			if layout.weeks != tt.weeks {
+				t.Errorf("weeks = %d, want %d", layout.weeks, tt.weeks)
+			}
+
+			// every monday starts a new column
+			for i, cell := range layout.cells {
+				if i > 0 && cell.weekday == 0 && cell.week != layout.cells[i-1].week+1 {
+					t.Errorf("%s is in week %d after week %d", cell.day.Date.Format(time.DateOnly), cell.week, layout.cells[i-1].week)
+				}
+				if i > 0 && cell.weekday != 0 && cell.week != layout.cells[i-1].week {
+					t.Errorf("%s is in week %d but the day before is in %d", cell.day.Date.Format(time.DateOnly), cell.week, layout.cells[i-1].week)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+// Package render draws the charts akami reports are made of. A Chart takes labelled
+// values and lays them out as horizontal bars, a single stacked bar or a sparkline,
+// sizing everything by display width so it fits the terminal whatever the labels
+// are written in.
+package render
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/taciturnaxolotl/akami/styles"
+)
+
+// Mode is how a chart is drawn
+type Mode string
+
+// Chart modes
+const (
+	// Horizontal draws a bar per item
+	Horizontal Mode = "horizontal"
+	// Stacked draws one bar split between the items with a legend under it
+	Stacked Mode = "stacked"
+	// Sparkline draws a character per item whose height follows its value
+	Sparkline Mode = "sparkline"
+)
+
+// Modes lists every chart mode
+var Modes = []Mode{Horizontal, Stacked, Sparkline}
+
+// Sort is the order items are drawn in
+type Sort string
+
+// Sort orders
+const (
+	// ByValue puts the largest value first
+	ByValue Sort = "value"
+	// ByLabel orders items alphabetically
+	ByLabel Sort = "label"
+	// Unsorted keeps items in the order they were given
+	Unsorted Sort = "none"
+)
+
+// Sorts lists every sort order
+var Sorts = []Sort{ByValue, ByLabel, Unsorted}
+
+// sparks are the characters a sparkline is drawn with from lowest to highest
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// palette colours the segments of stacked bars in turn
+var palette = []func(...string) string{styles.Success.Render, styles.Fancy.Render, styles.Warn.Render, styles.Muted.Render, styles.Bad.Render}
+
+// Item is a single labelled value in a chart
+type Item struct {
+	// Label names the item
+	Label string
+	// Value is the size of the item
+	Value float64
+	// Text is shown next to the item instead of the raw value when it's set
+	Text string
+}
+
+// Chart is a set of items and how to draw them
+type Chart struct {
+	// Title is printed above the chart when it's set
+	Title string
+	// Items are the values to draw
+	Items []Item
+	// Mode is how the chart is drawn and defaults to Horizontal
+	Mode Mode
+	// Sort is the order items are drawn in and defaults to ByValue
+	Sort Sort
+	// Top limits the chart to the first Top items after sorting; 0 shows every item
+	Top int
+	// Width is how many columns the chart can use and defaults to DefaultWidth
+	Width int
+	// Total is what percentages are worked out against and defaults to the sum of every item
+	Total float64
+}
+
+// ParseMode turns the name of a chart mode into a Mode
+func ParseMode(name string) (Mode, error) {
+	if name == "" {
+		return Horizontal, nil
+	}
+	if !slices.Contains(Modes, Mode(name)) {
+		return "", fmt.Errorf("unknown chart %q; pick one of horizontal, stacked or sparkline", name)
+	}
+
+	return Mode(name), nil
+}
+
+// ParseSort turns the name of a sort order into a Sort
+func ParseSort(name string) (Sort, error) {
+	if name == "" {
+		return ByValue, nil
+	}
+	if !slices.Contains(Sorts, Sort(name)) {
+		return "", fmt.Errorf("unknown sort %q; pick one of value, label or none", name)
+	}
+
+	return Sort(name), nil
+}
+
+// String draws the chart; charts without items draw as an empty string
+func (c Chart) String() string {
+	if len(c.Items) == 0 {
+		return ""
+	}
+
+	width := c.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	total := c.Total
+	if total <= 0 {
+		for _, item := range c.Items {
+			total += item.Value
+		}
+	}
+
+	items := c.sorted()
+	var rest []Item
+	if c.Top > 0 && len(items) > c.Top {
+		items, rest = items[:c.Top], items[c.Top:]
+	}
+
+	var out strings.Builder
+	if c.Title != "" {
+		out.WriteString(styles.Fancy.Render(c.Title) + "\n")
+	}
+
+	switch c.Mode {
+	case Stacked:
+		out.WriteString(stacked(items, rest, total, width))
+	case Sparkline:
+		out.WriteString(sparkline(items, width))
+	default:
+		out.WriteString(horizontal(items, total, width))
+	}
+
+	return out.String()
+}
+
+// sorted returns a copy of the items in the chart's sort order
+func (c Chart) sorted() []Item {
+	items := slices.Clone(c.Items)
+
+	switch c.Sort {
+	case Unsorted:
+	case ByLabel:
+		slices.SortStableFunc(items, func(a, b Item) int { return strings.Compare(a.Label, b.Label) })
+	default:
+		slices.SortStableFunc(items, func(a, b Item) int {
+			if a.Value != b.Value {
+				return int(math.Copysign(1, b.Value-a.Value))
+			}
+			return strings.Compare(a.Label, b.Label)
+		})
+	}
+
+	return items
+}
+
+// text is what's printed next to an item
+func (i Item) text() string {
+	if i.Text != "" {
+		return i.Text
+	}
+
+	return fmt.Sprintf("%g", i.Value)
+}
+
+// percent is how much of total an item is
+func percent(value float64, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+
+	return value / total * 100
+}
+
+// horizontal draws a line per item with its label, text, a bar and its percentage
+func horizontal(items []Item, total float64, width int) string {
+	labelWidth, textWidth := columns(items, width)
+	percentWidth := len("100.00%")
+
+	// indent, label, text and percentage all come off the width with two spaces between each
+	barWidth := min(max(width-2-labelWidth-2-textWidth-2-2-percentWidth, 5), 50)
+
+	var out strings.Builder
+	for _, item := range items {
+		pct := percent(item.Value, total)
+		filled := min(int(math.Round(pct/100*float64(barWidth))), barWidth)
+
+		out.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+			styles.Fancy.Render(Pad(Truncate(item.Label, labelWidth), labelWidth)),
+			styles.Muted.Render(Pad(item.text(), textWidth)),
+			styles.Success.Render(strings.Repeat("█", filled)+strings.Repeat("░", barWidth-filled)),
+			styles.Warn.Render(fmt.Sprintf("%.2f%%", pct)),
+		))
+	}
+
+	return out.String()
+}
+
+// stacked draws a single bar split between the items followed by a legend. Anything
+// cut off by Top is lumped together as other.
+func stacked(items []Item, rest []Item, total float64, width int) string {
+	if len(rest) > 0 {
+		other := Item{Label: "other"}
+		for _, item := range rest {
+			other.Value += item.Value
+		}
+		other.Text = fmt.Sprintf("%d more", len(rest))
+		items = append(slices.Clone(items), other)
+	}
+
+	barWidth := max(width-2, 10)
+
+	var bar strings.Builder
+	used := 0
+	for i, item := range items {
+		cells := int(math.Round(percent(item.Value, total) / 100 * float64(barWidth)))
+		if i == len(items)-1 && total > 0 {
+			// rounding can leave a gap or overshoot so the last segment fills what's left
+			cells = barWidth - used
+		}
+		cells = min(max(cells, 0), barWidth-used)
+		used += cells
+
+		bar.WriteString(palette[i%len(palette)](strings.Repeat("█", cells)))
+	}
+	if used < barWidth {
+		bar.WriteString(styles.Muted.Render(strings.Repeat("░", barWidth-used)))
+	}
+
+	labelWidth, textWidth := columns(items, width-2)
+
+	var out strings.Builder
+	out.WriteString("  " + bar.String() + "\n")
+	for i, item := range items {
+		out.WriteString(fmt.Sprintf("  %s %s  %s  %s\n",
+			palette[i%len(palette)]("■"),
+			styles.Fancy.Render(Pad(Truncate(item.Label, labelWidth), labelWidth)),
+			styles.Muted.Render(Pad(item.text(), textWidth)),
+			styles.Warn.Render(fmt.Sprintf("%.2f%%", percent(item.Value, total))),
+		))
+	}
+
+	return out.String()
+}
+
+// sparkline draws the items as one line of bars with the labels listed after it
+func sparkline(items []Item, width int) string {
+	values := make([]float64, len(items))
+	labels := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.Value
+		labels[i] = item.Label
+	}
+
+	line := "  " + styles.Success.Render(Spark(values))
+	legend := Truncate(strings.Join(labels, " · "), max(width-Width(line)-2, 1))
+
+	return line + "  " + styles.Muted.Render(legend) + "\n"
+}
+
+// Spark draws values as a string of block characters, one per value, scaled so the
+// largest value is a full block
+func Spark(values []float64) string {
+	highest := 0.0
+	for _, value := range values {
+		highest = max(highest, value)
+	}
+
+	var out strings.Builder
+	for _, value := range values {
+		if highest <= 0 || value <= 0 {
+			out.WriteRune(' ')
+			continue
+		}
+
+		level := int(math.Ceil(value/highest*float64(len(sparks)))) - 1
+		out.WriteRune(sparks[min(max(level, 0), len(sparks)-1)])
+	}
+
+	return out.String()
+}
+
+// columns works out how wide the label and text columns need to be, keeping labels
+// to at most a third of the width so the bars always have room
+func columns(items []Item, width int) (int, int) {
+	labelWidth, textWidth := 0, 0
+	for _, item := range items {
+		labelWidth = max(labelWidth, Width(item.Label))
+		textWidth = max(textWidth, Width(item.text()))
+	}
+
+	return min(labelWidth, max(width/3, 8)), textWidth
+}
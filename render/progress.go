@@ -0,0 +1,96 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/taciturnaxolotl/akami/styles"
+)
+
+// ProgressBar is how far a single value is towards a target
+type ProgressBar struct {
+	// Label names the bar
+	Label string
+	// Value is how far along the bar is
+	Value float64
+	// Target is the value that fills the bar
+	Target float64
+	// Text is shown next to the bar instead of the raw value and target when it's set
+	Text string
+	// Suffix is drawn after the percentage, like a goal's recent history
+	Suffix string
+}
+
+// Progress is a set of progress bars lined up under each other
+type Progress struct {
+	// Title is printed above the bars when it's set
+	Title string
+	// Bars are the bars to draw
+	Bars []ProgressBar
+	// Width is how many columns the bars can use and defaults to DefaultWidth
+	Width int
+}
+
+// text is what's printed next to a bar
+func (b ProgressBar) text() string {
+	if b.Text != "" {
+		return b.Text
+	}
+
+	return fmt.Sprintf("%g/%g", b.Value, b.Target)
+}
+
+// String draws the bars; bars that reached their target are drawn in full and
+// everything else is drawn in the warning colour
+func (p Progress) String() string {
+	if len(p.Bars) == 0 {
+		return ""
+	}
+
+	width := p.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	items := make([]Item, len(p.Bars))
+	suffixWidth := 0
+	for i, bar := range p.Bars {
+		items[i] = Item{Label: bar.Label, Text: bar.text()}
+		suffixWidth = max(suffixWidth, Width(bar.Suffix))
+	}
+	labelWidth, textWidth := columns(items, width)
+	percentWidth := len("100%")
+	if suffixWidth > 0 {
+		suffixWidth += 2
+	}
+
+	barWidth := min(max(width-2-labelWidth-2-textWidth-2-2-percentWidth-suffixWidth, 5), 40)
+
+	var out strings.Builder
+	if p.Title != "" {
+		out.WriteString(styles.Fancy.Render(p.Title) + "\n")
+	}
+	for _, bar := range p.Bars {
+		pct := percent(bar.Value, bar.Target)
+		filled := min(int(math.Round(pct/100*float64(barWidth))), barWidth)
+
+		style := styles.Warn
+		if bar.Target > 0 && bar.Value >= bar.Target {
+			style = styles.Success
+		}
+
+		line := fmt.Sprintf("  %s  %s  %s  %s",
+			styles.Fancy.Render(Pad(Truncate(bar.Label, labelWidth), labelWidth)),
+			styles.Muted.Render(Pad(bar.text(), textWidth)),
+			style.Render(strings.Repeat("█", filled))+styles.Muted.Render(strings.Repeat("░", barWidth-filled)),
+			style.Render(PadLeft(fmt.Sprintf("%.0f%%", pct), percentWidth)),
+		)
+		if bar.Suffix != "" {
+			line += "  " + bar.Suffix
+		}
+		out.WriteString(line + "\n")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// DefaultWidth is the width used when the terminal size can't be found
+const DefaultWidth = 80
+
+// TerminalWidth returns how many columns w is wide. It falls back to $COLUMNS and
+// then DefaultWidth when w isn't a terminal.
+func TerminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(f.Fd()); err == nil && width > 0 {
+			return width
+		}
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return DefaultWidth
+}
+
+// Width returns how many columns s takes up on screen ignoring escape codes, so
+// emoji and CJK characters count as two
+func Width(s string) int {
+	return ansi.StringWidth(s)
+}
+
+// Pad adds spaces to the right of s until it takes up width columns
+func Pad(s string, width int) string {
+	if gap := width - Width(s); gap > 0 {
+		return s + strings.Repeat(" ", gap)
+	}
+
+	return s
+}
+
+// PadLeft adds spaces to the left of s until it takes up width columns
+func PadLeft(s string, width int) string {
+	if gap := width - Width(s); gap > 0 {
+		return strings.Repeat(" ", gap) + s
+	}
+
+	return s
+}
+
+// Truncate cuts s down to width columns ending it with … when anything was cut
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+
+	return ansi.Truncate(s, width, "…")
+}
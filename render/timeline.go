@@ -0,0 +1,146 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/styles"
+)
+
+// DefaultTimelineWidth is how many columns a timeline's day spans by default, which
+// makes every column half an hour
+const DefaultTimelineWidth = 48
+
+// Span is a stretch of time on a timeline
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TimelineRow is a labelled line of a timeline
+type TimelineRow struct {
+	// Label names the row
+	Label string
+	// Spans are the stretches of the day the row covers
+	Spans []Span
+	// Text is shown after the row when it's set
+	Text string
+}
+
+// Timeline lays a day out from midnight to midnight with a row per label, shading
+// each column by how much of it the row's spans cover
+type Timeline struct {
+	// Day is midnight at the start of the day to draw
+	Day time.Time
+	// Width is how many columns the day spans and defaults to DefaultTimelineWidth
+	Width int
+	// Rows are drawn in order with colours taken from the palette in turn
+	Rows []TimelineRow
+	// Marks are moments to tick off on a row of their own under the others
+	Marks []time.Time
+	// MarksLabel names the row of marks; the row is only drawn when it's set
+	MarksLabel string
+}
+
+// String draws the timeline with an hour axis above it
+func (t Timeline) String() string {
+	width := t.Width
+	if width <= 0 {
+		width = DefaultTimelineWidth
+	}
+
+	// every label is padded to the longest so the bars line up
+	labelWidth := Width(t.MarksLabel)
+	for _, row := range t.Rows {
+		labelWidth = max(labelWidth, Width(row.Label))
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("  %s %s\n", strings.Repeat(" ", labelWidth), styles.Muted.Render(timelineAxis(width))))
+	for i, row := range t.Rows {
+		line := fmt.Sprintf("  %s %s", Pad(row.Label, labelWidth), t.bar(width, row.Spans, palette[i%len(palette)]))
+		if row.Text != "" {
+			line += "  " + styles.Muted.Render(row.Text)
+		}
+		out.WriteString(line + "\n")
+	}
+	if t.MarksLabel != "" {
+		out.WriteString(fmt.Sprintf("  %s %s\n", Pad(t.MarksLabel, labelWidth), t.marks(width)))
+	}
+
+	return out.String()
+}
+
+// timelineAxis labels every third hour across a timeline of the given width
+func timelineAxis(width int) string {
+	axis := []rune(strings.Repeat(" ", width+2))
+	for hour := 0; hour <= 24; hour += 3 {
+		label := fmt.Sprint(hour)
+		pos := hour * width / 24
+		if pos+len(label) > len(axis) {
+			pos = len(axis) - len(label)
+		}
+		copy(axis[pos:], []rune(label))
+	}
+
+	return strings.TrimRight(string(axis), " ")
+}
+
+// bar draws how much of each column of the day was covered by spans
+func (t Timeline) bar(width int, spans []Span, colour func(...string) string) string {
+	cell := 24 * time.Hour / time.Duration(width)
+
+	var bar strings.Builder
+	for i := range width {
+		start := t.Day.Add(time.Duration(i) * cell)
+		end := start.Add(cell)
+
+		var covered time.Duration
+		for _, span := range spans {
+			from, to := span.Start, span.End
+			if from.Before(start) {
+				from = start
+			}
+			if to.After(end) {
+				to = end
+			}
+			if overlap := to.Sub(from); overlap > 0 {
+				covered += overlap
+			}
+		}
+
+		if covered <= 0 {
+			bar.WriteString(styles.Muted.Render("·"))
+			continue
+		}
+
+		level := int(math.Ceil(float64(covered)/float64(cell)*float64(len(sparks)))) - 1
+		bar.WriteString(colour(string(sparks[min(max(level, 0), len(sparks)-1)])))
+	}
+
+	return bar.String()
+}
+
+// marks ticks off the columns of the day that have at least one mark in them
+func (t Timeline) marks(width int) string {
+	cell := 24 * time.Hour / time.Duration(width)
+	counts := make([]int, width)
+	for _, mark := range t.Marks {
+		if i := int(mark.Sub(t.Day) / cell); i >= 0 && i < width && !mark.Before(t.Day) {
+			counts[i]++
+		}
+	}
+
+	var bar strings.Builder
+	for _, count := range counts {
+		if count == 0 {
+			bar.WriteString(styles.Muted.Render("·"))
+		} else {
+			bar.WriteString(styles.Warn.Render("│"))
+		}
+	}
+
+	return bar.String()
+}
@@ -0,0 +1,107 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scripts are the hooks for each shell. {{akami}} is replaced with the command that
+// runs akami hook and every call is put in the background so prompts never wait on it.
+var scripts = map[string]string{
+	"zsh": `# akami shell integration; add eval "$(akami shell-init zsh)" to ~/.zshrc
+_akami_hook() {
+  {{akami}} --cwd "$PWD" --command "$1" >/dev/null 2>&1 &!
+}
+_akami_preexec() {
+  _akami_command="$1"
+  _akami_hook "$1"
+}
+_akami_precmd() {
+  [[ -n "$_akami_command" ]] && _akami_hook "$_akami_command"
+  _akami_command=
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec _akami_preexec
+add-zsh-hook precmd _akami_precmd
+`,
+	"bash": `# akami shell integration; add eval "$(akami shell-init bash)" to ~/.bashrc
+_akami_hook() {
+  ( {{akami}} --cwd "$PWD" --command "$1" >/dev/null 2>&1 & )
+}
+_akami_start() {
+  _akami_command="$1"
+  _akami_hook "$1"
+}
+_akami_precmd() {
+  [[ -n "$_akami_command" ]] && _akami_hook "$_akami_command"
+  _akami_command=
+  _akami_ready=1
+}
+if [[ -n "${bash_preexec_imported:-}${__bp_imported:-}" ]]; then
+  # bash-preexec owns the DEBUG trap so the hooks go through it
+  [[ " ${preexec_functions[*]} " == *" _akami_start "* ]] || preexec_functions+=(_akami_start)
+  [[ " ${precmd_functions[*]} " == *" _akami_precmd "* ]] || precmd_functions+=(_akami_precmd)
+else
+  _akami_preexec() {
+    # the DEBUG trap also fires for completions and for whatever PROMPT_COMMAND runs
+    [[ -n "$_akami_ready" && -z "$COMP_LINE" ]] || return
+    [[ "$PROMPT_COMMAND" == *"$BASH_COMMAND"* ]] && return
+    _akami_ready=
+    _akami_start "$BASH_COMMAND"
+  }
+  # keep running whatever DEBUG trap was there before
+  _akami_old_trap="$(trap -p DEBUG)"
+  if [[ "$_akami_old_trap" != *_akami_preexec* ]]; then
+    _akami_old_trap="${_akami_old_trap#trap -- }"
+    eval "_akami_trap=${_akami_old_trap% DEBUG}"
+    if [[ -n "$_akami_trap" ]]; then
+      trap 'eval "$_akami_trap"; _akami_preexec' DEBUG
+    else
+      trap '_akami_preexec' DEBUG
+    fi
+  fi
+  unset _akami_old_trap
+  [[ "$PROMPT_COMMAND" == *_akami_precmd* ]] || PROMPT_COMMAND="${PROMPT_COMMAND:+$PROMPT_COMMAND; }_akami_precmd"
+fi
+`,
+	"fish": `# akami shell integration; add akami shell-init fish | source to ~/.config/fish/config.fish
+function _akami_hook
+    {{akami}} --cwd "$PWD" --command "$argv[1]" >/dev/null 2>&1 &
+    disown 2>/dev/null
+end
+function _akami_preexec --on-event fish_preexec
+    _akami_hook "$argv[1]"
+end
+function _akami_postexec --on-event fish_postexec
+    _akami_hook "$argv[1]"
+end
+`,
+}
+
+// Names lists the shells there are hooks for in the order they're shown
+var Names = []string{"zsh", "bash", "fish"}
+
+// Script returns the hooks for a shell. args is the command that runs akami hook,
+// like the path to akami followed by hook and any --profile flag.
+func Script(shell string, args []string) (string, error) {
+	script, ok := scripts[shell]
+	if !ok {
+		return "", fmt.Errorf("there are no hooks for %q; pick one of %s", shell, strings.Join(Names, ", "))
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+
+	return strings.ReplaceAll(script, "{{akami}}", "command "+strings.Join(quoted, " ")), nil
+}
+
+// quote single quotes s for zsh, bash and fish when it has anything a shell would expand
+func quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
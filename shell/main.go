@@ -0,0 +1,107 @@
+// Package shell backs akami shell-init and akami hook, which turn time spent running
+// commands in a terminal into app heartbeats. Shell hooks call akami hook before and
+// after every command; it works out the project from the folder and a category from
+// the command, then leaves the heartbeat in a spool for a sender started in the
+// background so the prompt never waits on the network.
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Plugin is added to the user agent of every heartbeat so they can be told apart from an editor's
+	Plugin = "akami-shell/1.0.0"
+
+	// Categories commands are sorted into; anything else counts as coding
+	CategoryBuilding     = "building"
+	CategoryDebugging    = "debugging"
+	CategoryRunningTests = "running tests"
+	CategoryCoding       = "coding"
+)
+
+// categories maps the start of a command to its category. Tests come first so
+// make test and npm run test aren't taken for builds.
+var categories = []struct {
+	category string
+	prefixes []string
+}{
+	{CategoryRunningTests, []string{
+		"go test", "cargo test", "cargo nextest", "npm test", "npm run test", "yarn test", "yarn run test",
+		"pnpm test", "pnpm run test", "bun test", "deno test", "make test", "make check", "just test",
+		"pytest", "py.test", "python -m pytest", "python3 -m pytest", "python -m unittest", "python3 -m unittest",
+		"tox", "nox", "jest", "vitest", "mocha", "rspec", "bundle exec rspec", "rake test", "mix test",
+		"phpunit", "ctest", "gradle test", "./gradlew test", "mvn test", "dotnet test", "zig build test",
+		"swift test", "bazel test", "nix flake check",
+	}},
+	{CategoryDebugging, []string{
+		"gdb", "lldb", "dlv", "rust-gdb", "rust-lldb", "valgrind", "strace", "ltrace", "rr", "perf",
+		"python -m pdb", "python3 -m pdb", "node inspect", "node --inspect", "node --inspect-brk",
+	}},
+	{CategoryBuilding, []string{
+		"go build", "go install", "go generate", "cargo build", "cargo check", "npm run build", "yarn build",
+		"yarn run build", "pnpm build", "pnpm run build", "bun run build", "make", "cmake", "ninja", "meson",
+		"just build", "gcc", "g++", "clang", "clang++", "rustc", "tsc", "webpack", "vite build", "esbuild",
+		"docker build", "docker compose build", "podman build", "nix build", "nix-build", "mvn", "gradle",
+		"./gradlew", "dotnet build", "zig build", "swift build", "bazel build", "xcodebuild",
+	}},
+}
+
+// wrappers are commands that run another command, which is what the category comes from
+var wrappers = map[string]bool{
+	"sudo": true, "doas": true, "time": true, "nice": true, "nohup": true, "command": true,
+	"exec": true, "env": true, "builtin": true, "noglob": true,
+}
+
+// Categorize works out what running a command counts as
+func Categorize(command string) string {
+	words := strings.Join(commandWords(command), " ")
+
+	for _, group := range categories {
+		for _, prefix := range group.prefixes {
+			if words == prefix || strings.HasPrefix(words, prefix+" ") {
+				return group.category
+			}
+		}
+	}
+
+	return CategoryCoding
+}
+
+// Program returns the name of the program a command runs, which is used as the
+// heartbeat's entity so the stats show which tools the time went to
+func Program(command string) string {
+	words := commandWords(command)
+	if len(words) == 0 {
+		return ""
+	}
+
+	return filepath.Base(words[0])
+}
+
+// commandWords splits the first command of a line into words, leaving out
+// variable assignments and wrappers like sudo and time
+func commandWords(command string) []string {
+	// only the first command of a pipeline or list matters
+	if i := strings.IndexAny(command, "|;&\n"); i >= 0 {
+		command = command[:i]
+	}
+
+	words := strings.Fields(command)
+	for len(words) > 0 {
+		first := words[0]
+		if wrappers[first] || (strings.Contains(first, "=") && !strings.HasPrefix(first, "=")) {
+			words = words[1:]
+			continue
+		}
+		break
+	}
+
+	// paths to programs still count as the program, like ./node_modules/.bin/jest
+	if len(words) > 0 && !strings.HasPrefix(words[0], "./") && strings.Contains(words[0], "/") {
+		words[0] = filepath.Base(words[0])
+	}
+
+	return words
+}
@@ -0,0 +1,47 @@
+package shell
+
+import "testing"
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		command  string
+		category string
+		program  string
+	}{
+		{command: "go test ./...", category: CategoryRunningTests, program: "go"},
+		{command: "go build -o akami .", category: CategoryBuilding, program: "go"},
+		{command: "go run .", category: CategoryCoding, program: "go"},
+		{command: "make", category: CategoryBuilding, program: "make"},
+		{command: "make test", category: CategoryRunningTests, program: "make"},
+		{command: "makes", category: CategoryCoding, program: "makes"},
+		{command: "npm run test -- --watch", category: CategoryRunningTests, program: "npm"},
+		{command: "npm run build", category: CategoryBuilding, program: "npm"},
+		{command: "npm install", category: CategoryCoding, program: "npm"},
+		{command: "cargo nextest run", category: CategoryRunningTests, program: "cargo"},
+		{command: "python3 -m pytest tests/", category: CategoryRunningTests, program: "python3"},
+		{command: "dlv debug ./cmd/akami", category: CategoryDebugging, program: "dlv"},
+		{command: "node --inspect-brk index.js", category: CategoryDebugging, program: "node"},
+		{command: "sudo docker build .", category: CategoryBuilding, program: "docker"},
+		{command: "time nice go test ./track", category: CategoryRunningTests, program: "go"},
+		{command: "CGO_ENABLED=0 GOOS=linux go build", category: CategoryBuilding, program: "go"},
+		{command: "env DEBUG=1 pytest", category: CategoryRunningTests, program: "pytest"},
+		{command: "/usr/local/bin/gdb ./akami", category: CategoryDebugging, program: "gdb"},
+		{command: "./gradlew test", category: CategoryRunningTests, program: "gradlew"},
+		{command: "go test ./... | tee out.txt", category: CategoryRunningTests, program: "go"},
+		{command: "cd web && npm test", category: CategoryCoding, program: "cd"},
+		{command: "vim main.go", category: CategoryCoding, program: "vim"},
+		{command: "   ", category: CategoryCoding, program: ""},
+		{command: "sudo", category: CategoryCoding, program: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := Categorize(tt.command); got != tt.category {
+				t.Errorf("Categorize(%q) = %q, want %q", tt.command, got, tt.category)
+			}
+			if got := Program(tt.command); got != tt.program {
+				t.Errorf("Program(%q) = %q, want %q", tt.command, got, tt.program)
+			}
+		})
+	}
+}
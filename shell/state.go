@@ -0,0 +1,106 @@
+package shell
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+)
+
+// DefaultThrottle is how often a heartbeat is sent for the same thing, like editor plugins do
+const DefaultThrottle = 2 * time.Minute
+
+// sendTimeout is how long a background sender gets before another one may be started,
+// so one that hangs or dies doesn't leave heartbeats in the spool forever
+const sendTimeout = 30 * time.Second
+
+// State is what akami hook remembers between commands
+type State struct {
+	// Entity, Project and Category describe the last heartbeat
+	Entity   string `json:"entity,omitempty"`
+	Project  string `json:"project,omitempty"`
+	Category string `json:"category,omitempty"`
+	// At is when the last heartbeat happened
+	At time.Time `json:"at,omitzero"`
+	// Sending is when a background sender was last started
+	Sending time.Time `json:"sending,omitzero"`
+	// Error is why the last send failed, cleared by the next one that works
+	Error string `json:"error,omitempty"`
+
+	path string
+}
+
+// Dir returns the folder akami hook keeps its state and spool in
+func Dir() (string, error) {
+	dir, err := utils.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hook"), nil
+}
+
+// LoadState reads the state at path; a missing state is empty rather than an error
+func LoadState(path string) (*State, error) {
+	state := &State{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return state, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+
+	return state, nil
+}
+
+// Path returns where the state is saved
+func (s *State) Path() string {
+	return s.path
+}
+
+// Save writes the state back to disk, replacing it in one go so a hook running at the
+// same time never reads half of it
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + "." + strconv.Itoa(os.Getpid()) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// Allow reports whether a heartbeat should be sent and remembers it if so. Like
+// editor plugins it sends one when anything changes or once the throttle has passed.
+func (s *State) Allow(entity string, project string, category string, now time.Time, throttle time.Duration) bool {
+	if entity == s.Entity && project == s.Project && category == s.Category && now.Sub(s.At) < throttle {
+		return false
+	}
+
+	s.Entity, s.Project, s.Category, s.At = entity, project, category, now
+	return true
+}
+
+// NeedsSender reports whether a background sender should be started, which is when
+// none has been started recently
+func (s *State) NeedsSender(now time.Time) bool {
+	return now.Sub(s.Sending) >= sendTimeout
+}
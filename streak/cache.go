@@ -0,0 +1,122 @@
+package streak
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+)
+
+// dateFormat is how days are keyed in the cache
+const dateFormat = "2006-01-02"
+
+// settle is how many days back from today totals can still change, since late
+// heartbeats from the offline queue can land a while after they were made
+const settle = 2
+
+// Cache holds the daily totals of one account on disk
+type Cache struct {
+	// Days maps YYYY-MM-DD to the seconds coded that day
+	Days map[string]float64 `json:"days"`
+	// Fetched is when the cache was last filled in
+	Fetched time.Time `json:"fetched"`
+
+	path string
+}
+
+// CachePath returns where the daily totals of the account at apiURL are cached.
+// Each account gets its own file named after a hash of its url and key so keys
+// never end up in file names.
+func CachePath(apiURL string, apiKey string) (string, error) {
+	dir, err := utils.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(apiURL + "\n" + apiKey))
+	return filepath.Join(dir, "streaks", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// LoadCache reads the cache at path; a missing cache is empty rather than an error
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{Days: map[string]float64{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	if cache.Days == nil {
+		cache.Days = map[string]float64{}
+	}
+
+	return cache, nil
+}
+
+// Path returns the file the cache is stored in
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Save writes the cache back to disk
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// Missing returns the first day from from onwards that has to be fetched to bring
+// the cache up to today. Days that have settled are trusted while the last few days
+// are always fetched again. It returns false when nothing needs fetching.
+func (c *Cache) Missing(from time.Time, today time.Time) (time.Time, bool) {
+	from, today = midnight(from), midnight(today)
+	settled := today.AddDate(0, 0, -settle)
+
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if _, ok := c.Days[day.Format(dateFormat)]; !ok || day.After(settled) {
+			return day, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Set records the time coded on a day
+func (c *Cache) Set(day time.Time, seconds float64) {
+	c.Days[day.Format(dateFormat)] = seconds
+}
+
+// Range returns the cached days from from to today
+func (c *Cache) Range(from time.Time, today time.Time) []Day {
+	var days []Day
+	for day := midnight(from); !day.After(midnight(today)); day = day.AddDate(0, 0, 1) {
+		if seconds, ok := c.Days[day.Format(dateFormat)]; ok {
+			days = append(days, Day{Date: day, Seconds: seconds})
+		}
+	}
+
+	return days
+}
@@ -0,0 +1,116 @@
+// Package streak works out coding streaks and how consistent someone has been from
+// their daily totals, and keeps those totals cached on disk so a year of history
+// doesn't have to be fetched again every time.
+package streak
+
+import (
+	"time"
+)
+
+// DefaultMinimum is how long someone has to code on a day for it to count towards a streak
+const DefaultMinimum = 15 * time.Minute
+
+// Day is the time coded on a single day
+type Day struct {
+	// Date is midnight at the start of the day
+	Date time.Time
+	// Seconds is the time coded that day
+	Seconds float64
+}
+
+// Run is a stretch of consecutive days that all met the minimum
+type Run struct {
+	// Days is how long the run is
+	Days int
+	// Start is the first day of the run
+	Start time.Time
+	// End is the last day of the run
+	End time.Time
+}
+
+// Result is how consistent someone has been over a stretch of days
+type Result struct {
+	// Current is the streak that's still going; a streak stays alive through today
+	// until the day is over so today not meeting the minimum yet doesn't break it
+	Current Run
+	// Longest is the longest streak in the days looked at
+	Longest Run
+	// TodayMet is whether today has met the minimum already
+	TodayMet bool
+	// TodayRemaining is how much longer today needs to meet the minimum
+	TodayRemaining time.Duration
+	// Days is how many days were looked at
+	Days int
+	// ActiveDays is how many of them met the minimum
+	ActiveDays int
+	// Missed are the days that didn't meet the minimum, not counting today
+	Missed []time.Time
+	// WeekdayAverages is the average time coded on each weekday in seconds, starting with monday
+	WeekdayAverages [7]float64
+}
+
+// Compute works out streaks and averages from daily totals between from and today.
+// Days missing from days count as zero.
+func Compute(days []Day, minimum time.Duration, from time.Time, today time.Time) Result {
+	from, today = midnight(from), midnight(today)
+
+	seconds := map[time.Time]float64{}
+	for _, day := range days {
+		seconds[midnight(day.Date)] += day.Seconds
+	}
+	met := func(day time.Time) bool {
+		return seconds[day] >= minimum.Seconds()
+	}
+
+	var result Result
+	var weekdayTotals, weekdayCounts [7]float64
+
+	var run Run
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		result.Days++
+		weekday := (int(day.Weekday()) + 6) % 7
+		weekdayTotals[weekday] += seconds[day]
+		weekdayCounts[weekday]++
+
+		if !met(day) {
+			if !day.Equal(today) {
+				result.Missed = append(result.Missed, day)
+				run = Run{}
+			}
+			continue
+		}
+
+		result.ActiveDays++
+		if run.Days == 0 {
+			run.Start = day
+		}
+		run.Days++
+		run.End = day
+		if run.Days > result.Longest.Days {
+			result.Longest = run
+		}
+	}
+
+	// whatever run reaches yesterday or today is still going
+	if run.Days > 0 && !run.End.Before(today.AddDate(0, 0, -1)) {
+		result.Current = run
+	}
+
+	result.TodayMet = met(today)
+	if !result.TodayMet {
+		result.TodayRemaining = minimum - time.Duration(seconds[today]*float64(time.Second))
+	}
+
+	for i := range result.WeekdayAverages {
+		if weekdayCounts[i] > 0 {
+			result.WeekdayAverages[i] = weekdayTotals[i] / weekdayCounts[i]
+		}
+	}
+
+	return result
+}
+
+// midnight returns the start of the day t is in
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,144 @@
+package streak
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+
+	// wednesday the 12th, a few days after the clocks went forward in new york
+	today := time.Date(2025, 3, 12, 0, 0, 0, 0, newYork)
+	day := func(offset int, minutes float64) Day {
+		return Day{Date: today.AddDate(0, 0, offset), Seconds: minutes * 60}
+	}
+
+	tests := []struct {
+		name      string
+		days      []Day
+		from      int
+		current   int
+		longest   int
+		active    int
+		missed    int
+		todayMet  bool
+		remaining time.Duration
+	}{
+		{
+			name:      "nothing coded",
+			from:      -6,
+			missed:    6,
+			remaining: DefaultMinimum,
+		},
+		{
+			name:     "every day",
+			days:     []Day{day(-6, 20), day(-5, 20), day(-4, 20), day(-3, 20), day(-2, 20), day(-1, 20), day(0, 20)},
+			from:     -6,
+			current:  7,
+			longest:  7,
+			active:   7,
+			todayMet: true,
+		},
+		{
+			name:      "today isn't over yet",
+			days:      []Day{day(-3, 30), day(-2, 30), day(-1, 30), day(0, 10)},
+			from:      -6,
+			current:   3,
+			longest:   3,
+			active:    3,
+			missed:    3,
+			remaining: 5 * time.Minute,
+		},
+		{
+			name:      "missing yesterday ends the streak",
+			days:      []Day{day(-6, 20), day(-5, 20), day(-3, 20), day(-2, 20)},
+			from:      -6,
+			longest:   2,
+			active:    4,
+			missed:    2,
+			remaining: DefaultMinimum,
+		},
+		{
+			name:      "just under the minimum doesn't count",
+			days:      []Day{day(-2, 20), day(-1, 14.9)},
+			from:      -2,
+			longest:   1,
+			active:    1,
+			missed:    1,
+			remaining: DefaultMinimum,
+		},
+		{
+			name:     "totals on the same day add up",
+			days:     []Day{day(0, 10), {Date: today.Add(9 * time.Hour), Seconds: 600}},
+			from:     0,
+			current:  1,
+			longest:  1,
+			active:   1,
+			todayMet: true,
+		},
+		{
+			name:     "across the clocks going forward",
+			days:     []Day{day(-9, 20), day(-8, 20), day(-7, 20), day(-6, 20), day(-5, 20), day(-4, 20), day(-3, 20), day(-2, 20), day(-1, 20), day(0, 20)},
+			from:     -9,
+			current:  10,
+			longest:  10,
+			active:   10,
+			todayMet: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Compute(tt.days, DefaultMinimum, today.AddDate(0, 0, tt.from), today)
+
+			if result.Days != 1-tt.from {
+				t.Errorf("days = %d, want %d", result.Days, 1-tt.from)
+			}
+			if result.Current.Days != tt.current {
+				t.Errorf("current = %d, want %d", result.Current.Days, tt.current)
+			}
+			if result.Longest.Days != tt.longest {
+				t.Errorf("longest = %d, want %d", result.Longest.Days, tt.longest)
+			}
+			if result.ActiveDays != tt.active {
+				t.Errorf("active days = %d, want %d", result.ActiveDays, tt.active)
+			}
+			if len(result.Missed) != tt.missed {
+				t.Errorf("missed %d days, want %d", len(result.Missed), tt.missed)
+			}
+			if result.TodayMet != tt.todayMet {
+				t.Errorf("today met = %v, want %v", result.TodayMet, tt.todayMet)
+			}
+			if result.TodayRemaining != tt.remaining {
+				t.Errorf("today remaining = %v, want %v", result.TodayRemaining, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestComputeRunDates(t *testing.T) {
+	today := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+	days := []Day{
+		{Date: today.AddDate(0, 0, -9), Seconds: 3600},
+		{Date: today.AddDate(0, 0, -8), Seconds: 3600},
+		{Date: today.AddDate(0, 0, -7), Seconds: 3600},
+		{Date: today.AddDate(0, 0, -1), Seconds: 3600},
+	}
+
+	result := Compute(days, DefaultMinimum, today.AddDate(0, 0, -9), today)
+
+	if !result.Longest.Start.Equal(today.AddDate(0, 0, -9)) || !result.Longest.End.Equal(today.AddDate(0, 0, -7)) {
+		t.Errorf("longest runs %v to %v", result.Longest.Start, result.Longest.End)
+	}
+	if !result.Current.Start.Equal(today.AddDate(0, 0, -1)) || result.Current.Days != 1 {
+		t.Errorf("current is %d days from %v", result.Current.Days, result.Current.Start)
+	}
+	// the 3rd was a monday and the 10th was too
+	if got := result.WeekdayAverages[0]; got != 1800 {
+		t.Errorf("monday average = %v, want 1800", got)
+	}
+}
@@ -0,0 +1,140 @@
+package track
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one line of a .gitignore file
+type ignoreRule struct {
+	// base is the folder the .gitignore is in; the rule only covers paths under it
+	base    string
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Ignore matches paths against the .gitignore files in a tree. Later rules win over
+// earlier ones like they do in git, so a folder's .gitignore can undo its parent's.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// Load adds the rules from the .gitignore in dir if there is one
+func (ig *Ignore) Load(dir string) error {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(dir, scanner.Text()); ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Match reports whether git would ignore path
+func (ig *Ignore) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		if rule.pattern.MatchString(filepath.ToSlash(rel)) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// parseIgnoreLine turns a .gitignore line into a rule, skipping blanks and comments
+func parseIgnoreLine(base string, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+
+	// a pattern with a slash before its end only matches from the .gitignore's folder
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+
+	return rule, true
+}
+
+// globToRegexp converts a gitignore glob, including ** and character classes, to a regex
+func globToRegexp(glob string) string {
+	var out strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			out.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			out.WriteString(".*")
+			i++
+		case ch == '*':
+			out.WriteString("[^/]*")
+		case ch == '?':
+			out.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				out.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			out.WriteString("[" + class + "]")
+			i += end + 1
+		case ch == '\\' && i+1 < len(glob):
+			i++
+			out.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			out.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	return out.String()
+}
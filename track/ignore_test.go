@@ -0,0 +1,79 @@
+package track
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	root := t.TempDir()
+	write := func(path string, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(root, ".gitignore"), `# build output
+*.log
+/dist
+node_modules/
+build/**/*.o
+docs/*.pdf
+tmp?.txt
+\#notes
+[Bb]in/
+!keep.log
+`)
+	write(filepath.Join(root, "web", ".gitignore"), "*.js\n!vendor.js\n")
+
+	var ignore Ignore
+	for _, dir := range []string{root, filepath.Join(root, "web"), filepath.Join(root, "missing")} {
+		if err := ignore.Load(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "main.go"},
+		{path: "debug.log", ignored: true},
+		{path: "nested/deep/debug.log", ignored: true},
+		{path: "keep.log"},
+		{path: "nested/keep.log"},
+		{path: "dist", isDir: true, ignored: true},
+		{path: "dist", ignored: true},
+		{path: "src/dist", isDir: true},
+		{path: "node_modules", isDir: true, ignored: true},
+		{path: "web/node_modules", isDir: true, ignored: true},
+		{path: "node_modules"},
+		{path: "build/a.o", ignored: true},
+		{path: "build/x/y/a.o", ignored: true},
+		{path: "build/a.c"},
+		{path: "docs/guide.pdf", ignored: true},
+		{path: "docs/old/guide.pdf"},
+		{path: "tmp1.txt", ignored: true},
+		{path: "tmp12.txt"},
+		{path: "#notes", ignored: true},
+		{path: "Bin", isDir: true, ignored: true},
+		{path: "bin", isDir: true, ignored: true},
+		{path: "web/app.js", ignored: true},
+		{path: "web/vendor.js"},
+		{path: "app.js"},
+		{path: ".", isDir: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ignore.Match(filepath.Join(root, tt.path), tt.isDir); got != tt.ignored {
+				t.Errorf("Match(%s, %v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+// Package track turns changes to files in a project tree into heartbeats for editors
+// that don't have a wakatime plugin. It watches the tree with fsnotify, skips what
+// .gitignore and the wakatime config exclude, throttles events the way editor
+// plugins do and sends the heartbeats in batches.
+package track
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+const (
+	// DefaultThrottle is how often editor plugins send a heartbeat for the same file
+	DefaultThrottle = 2 * time.Minute
+	// DefaultFlush is how often batched heartbeats are sent
+	DefaultFlush = 10 * time.Second
+	// Plugin is added to the user agent of every heartbeat so they can be told apart from an editor's
+	Plugin = "akami-track/1.0.0"
+)
+
+// shutdownTimeout is how long the last batch gets to send after tracking stops
+const shutdownTimeout = 10 * time.Second
+
+// Options configures a Tracker
+type Options struct {
+	// Client sends the heartbeats; give it a queue so they survive going offline
+	Client *wakatime.Client
+	// Config supplies exclude, include, hide and project settings when it's set
+	Config *config.Config
+	// Project is used for every file instead of detecting one
+	Project string
+	// Reads also sends heartbeats for files that are opened without being changed
+	Reads bool
+	// Throttle is how long to wait before sending another heartbeat for the same file
+	Throttle time.Duration
+	// Flush is how often batched heartbeats are sent
+	Flush time.Duration
+	// Now returns the current time and defaults to time.Now
+	Now func() time.Time
+}
+
+// Tally counts what a Tracker has done so far
+type Tally struct {
+	// Folders is how many folders are being watched
+	Folders int
+	// Events is how many file events came in
+	Events int
+	// Ignored is how many events were for files that are ignored or excluded
+	Ignored int
+	// Throttled is how many events came too soon after the last heartbeat for the same file
+	Throttled int
+	// Heartbeats is how many heartbeats were made, sent or not
+	Heartbeats int
+	// Pending is how many heartbeats are waiting for the next batch
+	Pending int
+	// Sent is how many heartbeats the server accepted
+	Sent int
+	// Queued is how many heartbeats went to the offline queue
+	Queued int
+	// Failed is how many heartbeats were rejected or couldn't be sent
+	Failed int
+	// Projects counts heartbeats per project
+	Projects map[string]int
+	// Last is the file of the most recent heartbeat
+	Last string
+	// LastAt is when the most recent heartbeat happened
+	LastAt time.Time
+	// Err is the last error from watching or sending, cleared by the next successful send
+	Err error
+}
+
+// Tracker watches a tree and sends heartbeats for the files that change in it
+type Tracker struct {
+	root     string
+	opts     Options
+	watcher  *fsnotify.Watcher
+	reads    *readWatcher
+	ignore   Ignore
+	throttle Throttle
+	pending  []wakatime.Heartbeat
+	tally    Tally
+}
+
+// New starts watching every folder under root that git doesn't ignore
+func New(root string, opts Options) (*Tracker, error) {
+	if opts.Throttle <= 0 {
+		opts.Throttle = DefaultThrottle
+	}
+	if opts.Flush <= 0 {
+		opts.Flush = DefaultFlush
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if stat, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("%s isn't a folder", root)
+	}
+
+	t := &Tracker{
+		root:     root,
+		opts:     opts,
+		throttle: Throttle{Interval: opts.Throttle},
+		tally:    Tally{Projects: map[string]int{}},
+	}
+
+	t.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Reads {
+		if t.reads, err = newReadWatcher(); err != nil {
+			t.watcher.Close()
+			return nil, err
+		}
+	}
+
+	if err := t.addTree(root); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Root is the folder being tracked
+func (t *Tracker) Root() string {
+	return t.root
+}
+
+// Tally returns a copy of what the tracker has done so far
+func (t *Tracker) Tally() Tally {
+	tally := t.tally
+	tally.Projects = maps.Clone(t.tally.Projects)
+	tally.Pending = len(t.pending)
+
+	return tally
+}
+
+// Run turns file events into heartbeats until ctx is cancelled, then sends whatever is
+// left. update is called with the tally after every event and batch.
+func (t *Tracker) Run(ctx context.Context, update func(Tally)) error {
+	ticker := time.NewTicker(t.opts.Flush)
+	defer ticker.Stop()
+
+	var reads <-chan string
+	if t.reads != nil {
+		reads = t.reads.Events
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the last batch still gets sent even though ctx is done
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			t.flush(flushCtx)
+			cancel()
+			update(t.Tally())
+			return nil
+
+		case event, ok := <-t.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() {
+					if err := t.addTree(event.Name); err != nil {
+						t.tally.Err = err
+					}
+					break
+				}
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+				t.handle(ctx, event.Name, true)
+			}
+
+		case path, ok := <-reads:
+			if !ok {
+				reads = nil
+				break
+			}
+			t.handle(ctx, path, false)
+
+		case err, ok := <-t.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			t.tally.Err = err
+
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+
+		update(t.Tally())
+	}
+}
+
+// Close stops watching
+func (t *Tracker) Close() error {
+	if t.reads != nil {
+		t.reads.Close()
+	}
+
+	return t.watcher.Close()
+}
+
+// addTree watches dir and every folder under it that isn't ignored
+func (t *Tracker) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// folders can vanish or be unreadable while the tree is walked
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == ".git" || (path != t.root && t.ignore.Match(path, true)) {
+			return filepath.SkipDir
+		}
+
+		if err := t.ignore.Load(path); err != nil {
+			return err
+		}
+		if err := t.watcher.Add(path); err != nil {
+			if errors.Is(err, syscall.ENOSPC) {
+				return fmt.Errorf("ran out of inotify watches at %s; raise fs.inotify.max_user_watches or ignore more folders in .gitignore", path)
+			}
+			return err
+		}
+		if t.reads != nil {
+			if err := t.reads.Add(path); err != nil {
+				return err
+			}
+		}
+		t.tally.Folders++
+
+		return nil
+	})
+}
+
+// handle turns an event for a file into a heartbeat if it passes the ignore rules and the throttle
+func (t *Tracker) handle(ctx context.Context, path string, isWrite bool) {
+	t.tally.Events++
+
+	if editorFile(filepath.Base(path)) || t.ignore.Match(path, false) {
+		t.tally.Ignored++
+		return
+	}
+	if stat, err := os.Stat(path); err != nil || !stat.Mode().IsRegular() {
+		t.tally.Ignored++
+		return
+	}
+
+	// the throttle comes first since detecting the file reads it, which would
+	// otherwise show up as another read event
+	now := t.opts.Now()
+	if !t.throttle.Allow(path, isWrite, now) {
+		t.tally.Throttled++
+		return
+	}
+
+	opts := wakatime.FileOptions{Config: t.opts.Config, Project: t.opts.Project, IsWrite: isWrite, Time: now, Plugin: Plugin}
+	info, err := wakatime.DetectFile(path, opts)
+	if err != nil {
+		t.tally.Ignored++
+		return
+	}
+
+	filtered := wakatime.Filter(info, t.opts.Config)
+	if filtered.Skipped {
+		t.tally.Ignored++
+		return
+	}
+
+	t.pending = append(t.pending, filtered.Apply(info.Heartbeat(opts), info))
+	t.tally.Heartbeats++
+	t.tally.Projects[info.Project]++
+	t.tally.Last, t.tally.LastAt = info.Path, now
+
+	if len(t.pending) >= wakatime.MaxBulkHeartbeats {
+		t.flush(ctx)
+	}
+}
+
+// flush sends the pending heartbeats
+func (t *Tracker) flush(ctx context.Context) {
+	if len(t.pending) == 0 {
+		return
+	}
+
+	batch := t.pending
+	t.pending = nil
+
+	results, err := t.opts.Client.SendHeartbeatsContext(ctx, batch)
+	for _, result := range results {
+		if result.OK() {
+			t.tally.Sent++
+		} else {
+			t.tally.Failed++
+		}
+	}
+
+	switch {
+	case errors.Is(err, wakatime.ErrQueued):
+		t.tally.Queued += len(batch) - len(results)
+		t.tally.Err = err
+	case err != nil:
+		t.tally.Failed += len(batch) - len(results)
+		t.tally.Err = err
+	default:
+		t.tally.Err = nil
+	}
+}
+
+// editorFile reports whether name is a swap, backup or lock file an editor keeps next to the real one
+func editorFile(name string) bool {
+	switch {
+	case name == "4913", name == ".wakatime-project", name == ".gitignore":
+		return true
+	case strings.HasSuffix(name, "~"), strings.HasPrefix(name, ".#"):
+		return true
+	case strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"):
+		return true
+	case strings.HasPrefix(name, ".goutputstream-"):
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".swp", ".swo", ".swx", ".tmp", ".bak":
+		return true
+	}
+
+	return false
+}
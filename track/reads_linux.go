@@ -0,0 +1,102 @@
+package track
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// readWatcher reports files that were opened and closed without being written, which
+// fsnotify doesn't expose, by watching the tree with inotify directly
+type readWatcher struct {
+	// Events are the paths of files that were read
+	Events chan string
+
+	fd   int
+	file *os.File
+	mu   sync.Mutex
+	dirs map[int]string
+	// done is closed by Close so run doesn't block on Events once nobody is reading them
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newReadWatcher() (*readWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &readWatcher{
+		Events: make(chan string, 64),
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		dirs:   map[int]string{},
+		done:   make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// Add starts watching the files directly inside dir. It uses the raw descriptor because
+// calling Fd on the file would switch it to blocking mode and stop Close interrupting reads.
+func (w *readWatcher) Add(dir string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, dir, unix.IN_CLOSE_NOWRITE|unix.IN_ONLYDIR)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+
+	w.mu.Lock()
+	w.dirs[wd] = dir
+	w.mu.Unlock()
+
+	return nil
+}
+
+// Close stops watching and closes Events
+func (w *readWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	return w.file.Close()
+}
+
+// run reads inotify events until the watcher is closed
+func (w *readWatcher) run() {
+	defer close(w.Events)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if errors.Is(err, os.ErrClosed) || (err != nil && n == 0) {
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&unix.IN_ISDIR != 0 || event.Mask&unix.IN_CLOSE_NOWRITE == 0 {
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.dirs[int(event.Wd)]
+			w.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			select {
+			case w.Events <- filepath.Join(dir, string(bytes.TrimRight(name, "\x00"))):
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
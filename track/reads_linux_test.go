@@ -0,0 +1,51 @@
+package track
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestReadWatcherCloseWithoutReader(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	w, err := newReadWatcher()
+	if err != nil {
+		t.Skip("no inotify:", err)
+	}
+
+	dir := t.TempDir()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// read more files than Events holds so run ends up waiting on a send nobody takes
+	for i := range 2 * cap(w.Events) {
+		path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.ReadFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(w.Events) < cap(w.Events) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline.Add(time.Second)) {
+			t.Fatalf("%d goroutines are still running after Close, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
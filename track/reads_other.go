@@ -0,0 +1,18 @@
+//go:build !linux
+
+package track
+
+import "errors"
+
+// readWatcher would report files that were read, which only inotify can do
+type readWatcher struct {
+	Events chan string
+}
+
+func newReadWatcher() (*readWatcher, error) {
+	return nil, errors.New("tracking reads needs inotify, which is only on linux")
+}
+
+func (w *readWatcher) Add(string) error { return nil }
+
+func (w *readWatcher) Close() error { return nil }
@@ -0,0 +1,32 @@
+package track
+
+import "time"
+
+// writeDebounce is how close together writes to the same file count as one save,
+// since editors often write a file a few times while saving it
+const writeDebounce = 2 * time.Second
+
+// Throttle decides which file events become heartbeats using the rules editor
+// plugins follow: a heartbeat is sent when the file changes, when the file is
+// saved or when Interval has passed since the last one.
+type Throttle struct {
+	// Interval is how long to wait before sending another heartbeat for the same file
+	Interval time.Duration
+
+	entity string
+	at     time.Time
+	write  bool
+}
+
+// Allow reports whether an event should become a heartbeat and remembers it if so
+func (t *Throttle) Allow(entity string, isWrite bool, now time.Time) bool {
+	switch {
+	case entity != t.entity, now.Sub(t.at) >= t.Interval:
+	case isWrite && !(t.write && now.Sub(t.at) < writeDebounce):
+	default:
+		return false
+	}
+
+	t.entity, t.at, t.write = entity, now, isWrite
+	return true
+}
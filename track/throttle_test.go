@@ -0,0 +1,93 @@
+package track
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	type event struct {
+		entity  string
+		write   bool
+		after   time.Duration
+		allowed bool
+	}
+
+	tests := []struct {
+		name   string
+		events []event
+	}{
+		{
+			name: "first event",
+			events: []event{
+				{entity: "a.go", allowed: true},
+			},
+		},
+		{
+			name: "same file within the interval",
+			events: []event{
+				{entity: "a.go", allowed: true},
+				{entity: "a.go", after: time.Minute},
+				{entity: "a.go", after: 59 * time.Second},
+			},
+		},
+		{
+			name: "same file after the interval",
+			events: []event{
+				{entity: "a.go", allowed: true},
+				{entity: "a.go", after: 2 * time.Minute, allowed: true},
+			},
+		},
+		{
+			name: "switching files",
+			events: []event{
+				{entity: "a.go", allowed: true},
+				{entity: "b.go", after: time.Second, allowed: true},
+				{entity: "a.go", after: time.Second, allowed: true},
+			},
+		},
+		{
+			name: "save within the interval",
+			events: []event{
+				{entity: "a.go", allowed: true},
+				{entity: "a.go", write: true, after: 10 * time.Second, allowed: true},
+			},
+		},
+		{
+			name: "a save written in several goes",
+			events: []event{
+				{entity: "a.go", write: true, allowed: true},
+				{entity: "a.go", write: true, after: 100 * time.Millisecond},
+				{entity: "a.go", write: true, after: time.Second},
+			},
+		},
+		{
+			name: "saves further apart than the debounce",
+			events: []event{
+				{entity: "a.go", write: true, allowed: true},
+				{entity: "a.go", write: true, after: 3 * time.Second, allowed: true},
+			},
+		},
+		{
+			name: "reads after a save",
+			events: []event{
+				{entity: "a.go", write: true, allowed: true},
+				{entity: "a.go", after: 5 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := &Throttle{Interval: 2 * time.Minute}
+			now := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+
+			for i, e := range tt.events {
+				now = now.Add(e.after)
+				if got := throttle.Allow(e.entity, e.write, now); got != e.allowed {
+					t.Errorf("event %d for %s: allowed = %v, want %v", i, e.entity, got, e.allowed)
+				}
+			}
+		})
+	}
+}
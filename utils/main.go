@@ -2,6 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 )
 
 func PrettyPrintTime(totalSeconds int) string {
@@ -20,3 +24,84 @@ func PrettyPrintTime(totalSeconds int) string {
 
 	return formattedTime
 }
+
+// ShortTime formats seconds compactly like 1h20m or 45m for places PrettyPrintTime is too long for
+func ShortTime(seconds float64) string {
+	total := int(seconds)
+	hours, minutes := total/3600, (total%3600)/60
+
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// DaysBetween returns how many calendar days to is after from. It compares the
+// dates rather than the instants so a day that is 23 or 25 hours long because of
+// a daylight saving change still counts as one
+func DaysBetween(from time.Time, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// DataDir returns the directory akami keeps its own state in, creating it if needed.
+// It follows XDG_DATA_HOME on unix-likes and falls back to ~/.local/share/akami
+func DataDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			configDir, err := os.UserConfigDir()
+			if err != nil {
+				return "", err
+			}
+			dir = configDir
+		} else {
+			userDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(userDir, ".local", "share")
+		}
+	}
+
+	dir = filepath.Join(dir, "akami")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// ConfigDir returns the directory akami keeps its own config in, creating it if needed.
+// It follows XDG_CONFIG_HOME on unix-likes and falls back to ~/.config/akami
+func ConfigDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			configDir, err := os.UserConfigDir()
+			if err != nil {
+				return "", err
+			}
+			dir = configDir
+		} else {
+			userDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(userDir, ".config")
+		}
+	}
+
+	dir = filepath.Join(dir, "akami")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
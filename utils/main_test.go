@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{name: "same day", from: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork), to: time.Date(2025, 3, 9, 23, 0, 0, 0, newYork), want: 0},
+		{name: "utc week", from: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), to: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), want: 7},
+		{name: "spring forward", from: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), to: time.Date(2025, 3, 10, 0, 0, 0, 0, newYork), want: 7},
+		{name: "fall back", from: time.Date(2025, 11, 1, 0, 0, 0, 0, newYork), to: time.Date(2025, 11, 3, 0, 0, 0, 0, newYork), want: 2},
+		{name: "leap year", from: time.Date(2024, 1, 1, 0, 0, 0, 0, newYork), to: time.Date(2025, 1, 1, 0, 0, 0, 0, newYork), want: 366},
+		{name: "backwards", from: time.Date(2025, 3, 10, 0, 0, 0, 0, newYork), to: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), want: -7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysBetween(tt.from, tt.to); got != tt.want {
+				t.Errorf("DaysBetween = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
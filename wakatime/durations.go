@@ -0,0 +1,195 @@
+package wakatime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SliceBy picks what durations are split by on top of the project
+type SliceBy string
+
+// Ways durations can be sliced
+const (
+	SliceByNone         SliceBy = ""
+	SliceByEntity       SliceBy = "entity"
+	SliceByLanguage     SliceBy = "language"
+	SliceByDependencies SliceBy = "dependencies"
+	SliceByOS           SliceBy = "os"
+	SliceByEditor       SliceBy = "editor"
+	SliceByCategory     SliceBy = "category"
+	SliceByMachine      SliceBy = "machine"
+)
+
+// ID is an identifier the API sends as a string or a number depending on the server
+type ID string
+
+// UnmarshalJSON accepts both quoted and bare ids, leaving a null id empty
+func (id *ID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*id = ""
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+	default:
+		*id = ID(data)
+	}
+
+	return nil
+}
+
+// Duration is a stretch of continuous coding the API stitched together from heartbeats
+type Duration struct {
+	// Project is the project the time was spent in
+	Project string `json:"project"`
+	// Time is when the duration starts in UNIX epoch format
+	Time float64 `json:"time"`
+	// Duration is how long the stretch lasted in seconds
+	Duration float64 `json:"duration"`
+	// Entity is set when slicing by entity
+	Entity string `json:"entity,omitempty"`
+	// Language is set when slicing by language
+	Language string `json:"language,omitempty"`
+	// Dependencies is set when slicing by dependencies
+	Dependencies []string `json:"dependencies,omitempty"`
+	// OS is set when slicing by os
+	OS string `json:"os,omitempty"`
+	// Editor is set when slicing by editor
+	Editor string `json:"editor,omitempty"`
+	// Category is set when slicing by category
+	Category string `json:"category,omitempty"`
+	// Machine is set when slicing by machine
+	Machine string `json:"machine_name_id,omitempty"`
+}
+
+// Start returns when the duration starts
+func (d Duration) Start() time.Time {
+	return unixTime(d.Time)
+}
+
+// End returns when the duration ends
+func (d Duration) End() time.Time {
+	return unixTime(d.Time + d.Duration)
+}
+
+// DurationsResponse represents the response from the WakaTime durations endpoint
+type DurationsResponse struct {
+	// Data holds the durations in the order they happened
+	Data []Duration `json:"data"`
+	// Branches lists the branches worked on that day
+	Branches []string `json:"branches"`
+	// Start is the start of the day as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is the end of the day as an ISO 8601 timestamp
+	End string `json:"end"`
+	// Timezone is the timezone the day was split in
+	Timezone string `json:"timezone"`
+}
+
+// RecordedHeartbeat is a heartbeat as the server stored it
+type RecordedHeartbeat struct {
+	Heartbeat
+	// ID is the identifier the server gave the heartbeat
+	ID ID `json:"id"`
+	// CreatedAt is when the server received the heartbeat as an ISO 8601 timestamp
+	CreatedAt string `json:"created_at"`
+}
+
+// At returns when the heartbeat happened
+func (h RecordedHeartbeat) At() time.Time {
+	return unixTime(h.Time)
+}
+
+// HeartbeatsResponse represents the response from the WakaTime heartbeats endpoint
+type HeartbeatsResponse struct {
+	// Data holds the day's heartbeats in the order they happened
+	Data []RecordedHeartbeat `json:"data"`
+	// Start is the start of the day as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is the end of the day as an ISO 8601 timestamp
+	End string `json:"end"`
+	// Timezone is the timezone the day was split in
+	Timezone string `json:"timezone"`
+}
+
+// DurationsOptions narrows down and splits the durations GetDurationsWithOptions returns
+type DurationsOptions struct {
+	// Project limits the durations to a single project when set
+	Project string
+	// SliceBy splits the durations further by another attribute
+	SliceBy SliceBy
+	// Branches limits the durations to time spent on these branches when set
+	Branches []string
+}
+
+// GetDurations retrieves the stretches of coding the API recorded on a day. project
+// narrows the durations down to one project when it isn't empty and sliceBy splits
+// them further by another attribute.
+func (c *Client) GetDurations(date time.Time, project string, sliceBy SliceBy) (DurationsResponse, error) {
+	return c.GetDurationsContext(context.Background(), date, project, sliceBy)
+}
+
+// GetDurationsContext is GetDurations with a context that can cancel the request and its retries.
+func (c *Client) GetDurationsContext(ctx context.Context, date time.Time, project string, sliceBy SliceBy) (DurationsResponse, error) {
+	return c.GetDurationsWithOptionsContext(ctx, date, DurationsOptions{Project: project, SliceBy: sliceBy})
+}
+
+// GetDurationsWithOptions is GetDurations with every filter the endpoint supports,
+// like only counting time spent on some branches.
+func (c *Client) GetDurationsWithOptions(date time.Time, opts DurationsOptions) (DurationsResponse, error) {
+	return c.GetDurationsWithOptionsContext(context.Background(), date, opts)
+}
+
+// GetDurationsWithOptionsContext is GetDurationsWithOptions with a context that can cancel the request and its retries.
+func (c *Client) GetDurationsWithOptionsContext(ctx context.Context, date time.Time, opts DurationsOptions) (DurationsResponse, error) {
+	query := url.Values{}
+	query.Set("date", date.Format(DateFormat))
+	if opts.Project != "" {
+		query.Set("project", opts.Project)
+	}
+	if opts.SliceBy != SliceByNone {
+		query.Set("slice_by", string(opts.SliceBy))
+	}
+	if len(opts.Branches) > 0 {
+		query.Set("branches", strings.Join(opts.Branches, ","))
+	}
+
+	var durationsResp DurationsResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/durations?%s", c.APIURL, query.Encode()), &durationsResp); err != nil {
+		return DurationsResponse{}, err
+	}
+
+	return durationsResp, nil
+}
+
+// GetHeartbeats retrieves every heartbeat the API recorded on a day.
+func (c *Client) GetHeartbeats(date time.Time) (HeartbeatsResponse, error) {
+	return c.GetHeartbeatsContext(context.Background(), date)
+}
+
+// GetHeartbeatsContext is GetHeartbeats with a context that can cancel the request and its retries.
+func (c *Client) GetHeartbeatsContext(ctx context.Context, date time.Time) (HeartbeatsResponse, error) {
+	query := url.Values{}
+	query.Set("date", date.Format(DateFormat))
+
+	var heartbeatsResp HeartbeatsResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/heartbeats?%s", c.APIURL, query.Encode()), &heartbeatsResp); err != nil {
+		return HeartbeatsResponse{}, err
+	}
+
+	return heartbeatsResp, nil
+}
+
+// unixTime converts a timestamp in UNIX epoch format with fractional seconds to a time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
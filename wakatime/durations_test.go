@@ -0,0 +1,38 @@
+package wakatime_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want wakatime.ID
+	}{
+		{name: "string", json: `{"id": "b5a8f3c2"}`, want: "b5a8f3c2"},
+		{name: "number", json: `{"id": 1234}`, want: "1234"},
+		{name: "numeric string", json: `{"id": "1234"}`, want: "1234"},
+		{name: "escaped string", json: `{"id": "a\"b\u00e9"}`, want: `a"bé`},
+		{name: "empty string", json: `{"id": ""}`, want: ""},
+		{name: "null", json: `{"id": null}`, want: ""},
+		{name: "missing", json: `{}`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v struct {
+				ID wakatime.ID `json:"id"`
+			}
+			if err := json.Unmarshal([]byte(tt.json), &v); err != nil {
+				t.Fatal(err)
+			}
+			if v.ID != tt.want {
+				t.Errorf("id = %q, want %q", v.ID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,489 @@
+package wakatime
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/config"
+)
+
+// Where DetectFile found a project, branch or language
+const (
+	FromOption       = "option"
+	FromProjectFile  = ".wakatime-project"
+	FromProjectMap   = "projectmap"
+	FromSubmoduleMap = "git_submodule_projectmap"
+	FromGitRemote    = "git remote"
+	FromGit          = "git"
+	FromAlternate    = "alternate project"
+	FromModeline     = "modeline"
+	FromFilename     = "filename"
+	FromExtension    = "extension"
+	FromShebang      = "shebang"
+)
+
+// maxLineCountSize is the largest file lines are counted in; editor plugins skip huge files too
+const maxLineCountSize = 2 << 20
+
+// FileOptions tunes what DetectFile and NewHeartbeatForFile fill in. The zero value
+// detects everything the way wakatime-cli does with an empty config.
+type FileOptions struct {
+	// Config supplies [projectmap], [git_submodule_projectmap] and [git] settings when it's set
+	Config *config.Config
+	// Project is used instead of detecting one, like wakatime-cli's --project
+	Project string
+	// AlternateProject is used when no project is detected, like wakatime-cli's --alternate-project
+	AlternateProject string
+	// Language is used instead of detecting one
+	Language string
+	// Category is what the time was spent on and defaults to coding
+	Category string
+	// IsWrite marks the heartbeat as a save rather than a read
+	IsWrite bool
+	// LineNo and CursorPos are where the cursor is
+	LineNo    int
+	CursorPos int
+	// Time is when the heartbeat happened and defaults to now
+	Time time.Time
+	// Plugin is added to the user agent like "vim/9.1.0 vim-wakatime/11.2.0"
+	Plugin string
+}
+
+// FileInfo is everything DetectFile worked out about a path along with where each part came from
+type FileInfo struct {
+	// Path is the absolute path with symlinks resolved
+	Path string
+	// IsDir is set when the path is a directory, which has no language or lines
+	IsDir bool
+	// Project is the detected project or empty when there isn't one
+	Project string
+	// ProjectSource is where the project came from, one of the From constants
+	ProjectSource string
+	// ProjectDetail explains the match, like the projectmap pattern or the .wakatime-project file
+	ProjectDetail string
+	// ProjectRoot is the folder the project lives in
+	ProjectRoot string
+	// ProjectRootCount is how many folders deep ProjectRoot is
+	ProjectRootCount int
+	// Branch is the checked out branch or empty when there isn't one
+	Branch string
+	// BranchSource is where the branch came from
+	BranchSource string
+	// Language is the detected language or empty when it's unknown
+	Language string
+	// LanguageSource is where the language came from
+	LanguageSource string
+	// LineCount is how many lines the file has
+	LineCount int
+}
+
+// DetectFile works out the project, branch and language of a file the way editor
+// plugins and wakatime-cli do. The project comes from the first of a .wakatime-project
+// file, a [projectmap] rule and the enclosing git repository that matches.
+func DetectFile(path string, opts FileOptions) (FileInfo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	stat, err := os.Stat(abs)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info := FileInfo{Path: abs, IsDir: stat.IsDir()}
+	dir := abs
+	if !info.IsDir {
+		dir = filepath.Dir(abs)
+	}
+
+	info.detectProject(dir, opts)
+	if info.ProjectRoot != "" {
+		info.ProjectRootCount = len(strings.FieldsFunc(info.ProjectRoot, func(r rune) bool { return r == '/' || r == '\\' }))
+	}
+
+	if info.IsDir {
+		return info, nil
+	}
+
+	head, lines, err := readFile(abs, stat.Size())
+	if err != nil {
+		return info, err
+	}
+	info.LineCount = lines
+
+	switch {
+	case opts.Language != "":
+		info.Language, info.LanguageSource = opts.Language, FromOption
+	default:
+		info.Language, info.LanguageSource = detectLanguage(abs, head)
+	}
+
+	return info, nil
+}
+
+// NewHeartbeatForFile builds the heartbeat an editor plugin would send for a file
+func NewHeartbeatForFile(path string, opts FileOptions) (Heartbeat, error) {
+	info, err := DetectFile(path, opts)
+	if err != nil {
+		return Heartbeat{}, err
+	}
+
+	return info.Heartbeat(opts), nil
+}
+
+// Heartbeat turns what was detected about a file into the heartbeat an editor plugin would send
+func (info FileInfo) Heartbeat(opts FileOptions) Heartbeat {
+	at := opts.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	category := opts.Category
+	if category == "" {
+		category = "coding"
+	}
+	agent := userAgent
+	if opts.Plugin != "" {
+		agent += " " + opts.Plugin
+	}
+
+	return Heartbeat{
+		Entity:           info.Path,
+		Type:             "file",
+		Time:             float64(at.UnixNano()) / float64(time.Second),
+		Project:          info.Project,
+		Language:         info.Language,
+		IsWrite:          opts.IsWrite,
+		Branch:           info.Branch,
+		Category:         category,
+		LineCount:        info.LineCount,
+		LineNo:           opts.LineNo,
+		CursorPos:        opts.CursorPos,
+		UserAgent:        agent,
+		ProjectRootCount: info.ProjectRootCount,
+	}
+}
+
+// detectProject fills in the project and branch starting from dir
+func (info *FileInfo) detectProject(dir string, opts FileOptions) {
+	repo := findGitRepo(dir, opts.Config)
+	if repo.root != "" {
+		info.ProjectRoot = repo.root
+		info.Branch, info.BranchSource = repo.branch(), FromGit
+	}
+
+	if opts.Project != "" {
+		info.Project, info.ProjectSource = opts.Project, FromOption
+		return
+	}
+
+	if path, ok := findUp(dir, ".wakatime-project"); ok {
+		root := filepath.Dir(path)
+		info.ProjectSource, info.ProjectDetail, info.ProjectRoot = FromProjectFile, path, root
+
+		// the first line names the project and the optional second line the branch
+		data, _ := os.ReadFile(path)
+		lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		info.Project = strings.TrimSpace(lines[0])
+		if info.Project == "" {
+			info.Project = filepath.Base(root)
+		}
+		if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+			info.Branch, info.BranchSource = strings.TrimSpace(lines[1]), FromProjectFile
+		}
+		return
+	}
+
+	if opts.Config != nil {
+		if project, pattern, ok := mapProject(opts.Config.ProjectMap, info.Path); ok {
+			info.Project, info.ProjectSource, info.ProjectDetail = project, FromProjectMap, pattern
+			if info.ProjectRoot == "" {
+				info.ProjectRoot = dir
+			}
+			return
+		}
+	}
+
+	if repo.root != "" {
+		info.Project, info.ProjectSource, info.ProjectDetail = repo.project, repo.source, repo.detail
+		return
+	}
+
+	if opts.AlternateProject != "" {
+		info.Project, info.ProjectSource = opts.AlternateProject, FromAlternate
+	}
+}
+
+// mapProject returns the project of the first mapping whose pattern matches path.
+// Mappings can use the pattern's groups in the project name as {0}, {1} and so on.
+func mapProject(mappings []config.Mapping, path string) (string, string, bool) {
+	for _, mapping := range mappings {
+		groups := mapping.Pattern.FindStringSubmatch(path)
+		if groups == nil {
+			continue
+		}
+
+		project := mapping.Value
+		for i, group := range groups[1:] {
+			project = strings.ReplaceAll(project, fmt.Sprintf("{%d}", i), group)
+		}
+
+		return strings.TrimSpace(project), mapping.Pattern.String(), true
+	}
+
+	return "", "", false
+}
+
+// findUp looks for name in dir and each of its parents and returns the first match
+func findUp(dir string, name string) (string, bool) {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// gitRepo is the git repository a path is in
+type gitRepo struct {
+	// root is the working tree's top folder
+	root string
+	// gitDir is where the repository's HEAD and config are
+	gitDir string
+	// project is the name the repository gives the project
+	project string
+	source  string
+	detail  string
+}
+
+// findGitRepo finds the repository dir is in. Submodules count as their own project
+// unless [git] submodules_disabled covers them, in which case the repository they're
+// in is used instead.
+func findGitRepo(dir string, cfg *config.Config) gitRepo {
+	for {
+		path, ok := findUp(dir, ".git")
+		if !ok {
+			return gitRepo{}
+		}
+
+		repo := gitRepo{root: filepath.Dir(path), gitDir: path, source: FromGit}
+		submodule := false
+		if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+			// worktrees and submodules have a .git file pointing at the real git dir
+			data, _ := os.ReadFile(path)
+			if target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:"); ok {
+				target = strings.TrimSpace(target)
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(repo.root, target)
+				}
+				repo.gitDir = target
+				submodule = strings.Contains(filepath.ToSlash(target), "/modules/")
+			}
+		}
+
+		if submodule && cfg != nil {
+			if cfg.Git.SubmodulesDisabled.Match(repo.root) {
+				dir = filepath.Dir(repo.root)
+				continue
+			}
+			if project, pattern, ok := mapProject(cfg.GitSubmoduleProjectMap, repo.root); ok {
+				repo.project, repo.source, repo.detail = project, FromSubmoduleMap, pattern
+				return repo
+			}
+		}
+
+		repo.project, repo.detail = filepath.Base(repo.root), repo.root
+		if cfg != nil && cfg.Git.ProjectFromGitRemote {
+			if remote := repo.remote(); remote != "" {
+				repo.project, repo.source, repo.detail = remote, FromGitRemote, "origin"
+			}
+		}
+
+		return repo
+	}
+}
+
+// branch returns the checked out branch or an empty string for a detached HEAD
+func (r gitRepo) branch() string {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/")
+	if !ok {
+		return ""
+	}
+
+	return ref
+}
+
+// remoteURL matches the path part of ssh and https git remote urls
+var remoteURL = regexp.MustCompile(`[:/]([^/:]+/[^/]+?)(?:\.git)?/?$`)
+
+// remote returns the origin remote as owner/repo like wakatime-cli's project_from_git_remote
+func (r gitRepo) remote() string {
+	gitDir := r.gitDir
+	// worktrees keep their config in the main repository
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		gitDir = filepath.Join(gitDir, strings.TrimSpace(string(common)))
+	}
+
+	data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+
+	inOrigin := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if inOrigin && ok && strings.TrimSpace(key) == "url" {
+			if match := remoteURL.FindStringSubmatch(strings.TrimSpace(value)); match != nil {
+				return match[1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// readFile returns the first and last few lines of a file for spotting shebangs and
+// modelines along with how many lines it has
+func readFile(path string, size int64) ([]string, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if size > maxLineCountSize {
+		reader = io.LimitReader(f, maxLineCountSize)
+	}
+
+	var head, tail []string
+	lines := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLineCountSize)
+	for scanner.Scan() {
+		lines++
+		line := scanner.Text()
+		if len(head) < 5 {
+			head = append(head, line)
+			continue
+		}
+		tail = append(tail, line)
+		if len(tail) > 5 {
+			tail = tail[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, 0, err
+	}
+	if size > maxLineCountSize {
+		lines = 0
+	}
+
+	return append(head, tail...), lines, nil
+}
+
+// vimModeline matches vim modelines like "vim: set ft=python:" or "vi: filetype=sh"
+var vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syntax)=([\w+.-]+)`)
+
+// emacsModeline matches emacs mode lines like "-*- mode: python -*-" or "-*- ruby -*-"
+var emacsModeline = regexp.MustCompile(`-\*-(.*?)-\*-`)
+
+// detectLanguage works out a file's language from a modeline, its name or its shebang,
+// in that order since a modeline is someone saying what the file is on purpose
+func detectLanguage(path string, lines []string) (string, string) {
+	for _, line := range lines {
+		if match := vimModeline.FindStringSubmatch(line); match != nil {
+			return modelineLanguage(match[1]), FromModeline
+		}
+		if match := emacsModeline.FindStringSubmatch(line); match != nil {
+			if mode := emacsMode(match[1]); mode != "" {
+				return modelineLanguage(mode), FromModeline
+			}
+		}
+	}
+
+	name := strings.ToLower(filepath.Base(path))
+	if language, ok := languagesByFilename[name]; ok {
+		return language, FromFilename
+	}
+	if language, ok := languagesByExtension[strings.ToLower(filepath.Ext(name))]; ok {
+		return language, FromExtension
+	}
+
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		if language := shebangLanguage(lines[0]); language != "" {
+			return language, FromShebang
+		}
+	}
+
+	return "", ""
+}
+
+// emacsMode picks the mode out of the inside of an emacs mode line
+func emacsMode(inside string) string {
+	inside = strings.TrimSpace(inside)
+	if !strings.Contains(inside, ":") {
+		return inside
+	}
+
+	for _, part := range strings.Split(inside, ";") {
+		key, value, ok := strings.Cut(part, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "mode") {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
+
+// shebangLanguage works out the language a shebang line runs, seeing through env
+func shebangLanguage(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	program := filepath.Base(fields[0])
+	if program == "env" {
+		program = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				program = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	if language, ok := languagesByInterpreter[program]; ok {
+		return language
+	}
+
+	return languagesByInterpreter[string(bytes.TrimRight([]byte(program), "0123456789."))]
+}
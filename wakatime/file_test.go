@@ -0,0 +1,288 @@
+package wakatime_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taciturnaxolotl/akami/config"
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// writeTree creates files under root, making the folders they need
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectFileProject(t *testing.T) {
+	const (
+		main    = "ref: refs/heads/main\n"
+		origin  = "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = git@github.com:taciturnaxolotl/akami.git\n"
+		library = "gitdir: ../.git/modules/lib\n"
+	)
+
+	tests := []struct {
+		name          string
+		files         map[string]string
+		file          string
+		cfg           string
+		opts          wakatime.FileOptions
+		project       string
+		projectSource string
+		branch        string
+		branchSource  string
+		root          string
+	}{
+		{
+			name:          "git repository",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/src/main.go": ""},
+			file:          "repo/src/main.go",
+			project:       "repo",
+			projectSource: wakatime.FromGit,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "detached head",
+			files:         map[string]string{"repo/.git/HEAD": "0123456789abcdef0123456789abcdef01234567\n", "repo/main.go": ""},
+			file:          "repo/main.go",
+			project:       "repo",
+			projectSource: wakatime.FromGit,
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "project file beats git",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/app/.wakatime-project": "named\nfeature\n", "repo/app/main.go": ""},
+			file:          "repo/app/main.go",
+			project:       "named",
+			projectSource: wakatime.FromProjectFile,
+			branch:        "feature",
+			branchSource:  wakatime.FromProjectFile,
+			root:          "repo/app",
+		},
+		{
+			name:          "empty project file uses its folder",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/.wakatime-project": "", "repo/main.go": ""},
+			file:          "repo/main.go",
+			project:       "repo",
+			projectSource: wakatime.FromProjectFile,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "project file beats projectmap",
+			files:         map[string]string{"work/acme/.wakatime-project": "named\n", "work/acme/main.go": ""},
+			file:          "work/acme/main.go",
+			cfg:           "[projectmap]\nwork/(\\w+)/ = client-{0}\n",
+			project:       "named",
+			projectSource: wakatime.FromProjectFile,
+			root:          "work/acme",
+		},
+		{
+			name:          "projectmap beats git",
+			files:         map[string]string{"work/acme/.git/HEAD": main, "work/acme/main.go": ""},
+			file:          "work/acme/main.go",
+			cfg:           "[projectmap]\nwork/(\\w+)/ = client-{0}\n[git]\nproject_from_git_remote = true\n",
+			project:       "client-acme",
+			projectSource: wakatime.FromProjectMap,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "work/acme",
+		},
+		{
+			name:          "projectmap without git",
+			files:         map[string]string{"work/acme/notes/todo.md": ""},
+			file:          "work/acme/notes/todo.md",
+			cfg:           "[projectmap]\nwork/(\\w+)/ = client-{0}\n",
+			project:       "client-acme",
+			projectSource: wakatime.FromProjectMap,
+			root:          "work/acme/notes",
+		},
+		{
+			name:          "submodule is its own project",
+			files:         map[string]string{"outer/.git/HEAD": main, "outer/.git/modules/lib/HEAD": "ref: refs/heads/dev\n", "outer/lib/.git": library, "outer/lib/lib.go": ""},
+			file:          "outer/lib/lib.go",
+			project:       "lib",
+			projectSource: wakatime.FromGit,
+			branch:        "dev",
+			branchSource:  wakatime.FromGit,
+			root:          "outer/lib",
+		},
+		{
+			name:          "submodule projectmap",
+			files:         map[string]string{"outer/.git/HEAD": main, "outer/.git/modules/lib/HEAD": "ref: refs/heads/dev\n", "outer/lib/.git": library, "outer/lib/lib.go": ""},
+			file:          "outer/lib/lib.go",
+			cfg:           "[git_submodule_projectmap]\nouter/lib = vendored\n",
+			project:       "vendored",
+			projectSource: wakatime.FromSubmoduleMap,
+			branch:        "dev",
+			branchSource:  wakatime.FromGit,
+			root:          "outer/lib",
+		},
+		{
+			name:          "submodule projectmap beats the remote",
+			files:         map[string]string{"outer/.git/HEAD": main, "outer/.git/modules/lib/HEAD": "ref: refs/heads/dev\n", "outer/.git/modules/lib/config": origin, "outer/lib/.git": library, "outer/lib/lib.go": ""},
+			file:          "outer/lib/lib.go",
+			cfg:           "[git_submodule_projectmap]\nouter/lib = vendored\n[git]\nproject_from_git_remote = true\n",
+			project:       "vendored",
+			projectSource: wakatime.FromSubmoduleMap,
+			branch:        "dev",
+			branchSource:  wakatime.FromGit,
+			root:          "outer/lib",
+		},
+		{
+			name:          "submodules disabled",
+			files:         map[string]string{"outer/.git/HEAD": main, "outer/.git/modules/lib/HEAD": "ref: refs/heads/dev\n", "outer/lib/.git": library, "outer/lib/lib.go": ""},
+			file:          "outer/lib/lib.go",
+			cfg:           "[git]\nsubmodules_disabled = true\n",
+			project:       "outer",
+			projectSource: wakatime.FromGit,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "outer",
+		},
+		{
+			name:          "remote",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/.git/config": origin, "repo/main.go": ""},
+			file:          "repo/main.go",
+			cfg:           "[git]\nproject_from_git_remote = true\n",
+			project:       "taciturnaxolotl/akami",
+			projectSource: wakatime.FromGitRemote,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "remote is off by default",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/.git/config": origin, "repo/main.go": ""},
+			file:          "repo/main.go",
+			project:       "repo",
+			projectSource: wakatime.FromGit,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "project option beats everything",
+			files:         map[string]string{"repo/.git/HEAD": main, "repo/.wakatime-project": "named\n", "repo/main.go": ""},
+			file:          "repo/main.go",
+			opts:          wakatime.FileOptions{Project: "forced"},
+			project:       "forced",
+			projectSource: wakatime.FromOption,
+			branch:        "main",
+			branchSource:  wakatime.FromGit,
+			root:          "repo",
+		},
+		{
+			name:          "alternate project",
+			files:         map[string]string{"loose/main.go": ""},
+			file:          "loose/main.go",
+			opts:          wakatime.FileOptions{AlternateProject: "fallback"},
+			project:       "fallback",
+			projectSource: wakatime.FromAlternate,
+		},
+		{
+			name:  "nothing",
+			files: map[string]string{"loose/main.go": ""},
+			file:  "loose/main.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+
+			opts := tt.opts
+			if tt.cfg != "" {
+				cfg, problems := config.Parse(tt.cfg)
+				if len(problems) > 0 {
+					t.Fatalf("config problems: %v", problems)
+				}
+				opts.Config = cfg
+			}
+
+			info, err := wakatime.DetectFile(filepath.Join(root, tt.file), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Project != tt.project || info.ProjectSource != tt.projectSource {
+				t.Errorf("project = %q from %q, want %q from %q", info.Project, info.ProjectSource, tt.project, tt.projectSource)
+			}
+			if info.Branch != tt.branch || info.BranchSource != tt.branchSource {
+				t.Errorf("branch = %q from %q, want %q from %q", info.Branch, info.BranchSource, tt.branch, tt.branchSource)
+			}
+
+			want := ""
+			if tt.root != "" {
+				want = filepath.Join(root, tt.root)
+			}
+			if info.ProjectRoot != want {
+				t.Errorf("project root = %q, want %q", info.ProjectRoot, want)
+			}
+		})
+	}
+}
+
+func TestDetectFileLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		opts     wakatime.FileOptions
+		language string
+		source   string
+		lines    int
+	}{
+		{name: "extension", file: "main.go", content: "package main\n\nfunc main() {}\n", language: "Go", source: wakatime.FromExtension, lines: 3},
+		{name: "extension ignores case", file: "README.MD", content: "# akami\n", language: "Markdown", source: wakatime.FromExtension, lines: 1},
+		{name: "filename", file: "Makefile", content: "all:\n\tgo build\n", language: "Makefile", source: wakatime.FromFilename, lines: 2},
+		{name: "filename beats extension", file: "go.mod", content: "module x\n", language: "Go Module", source: wakatime.FromFilename, lines: 1},
+		{name: "shebang", file: "deploy", content: "#!/bin/bash\necho hi\n", language: "Bash", source: wakatime.FromShebang, lines: 2},
+		{name: "shebang through env", file: "serve", content: "#!/usr/bin/env -S python3 -u\nprint('hi')\n", language: "Python", source: wakatime.FromShebang, lines: 2},
+		{name: "versioned interpreter", file: "old", content: "#!/usr/bin/python3.11\n", language: "Python", source: wakatime.FromShebang, lines: 1},
+		{name: "extension beats shebang", file: "tool.rb", content: "#!/usr/bin/env python\n", language: "Ruby", source: wakatime.FromExtension, lines: 1},
+		{name: "vim modeline", file: "notes.txt", content: "# vim: set ft=python:\nx = 1\n", language: "Python", source: wakatime.FromModeline, lines: 2},
+		{name: "vim modeline at the end", file: "build.txt", content: strings.Repeat("line\n", 20) + "# vi: filetype=sh\n", language: "Bash", source: wakatime.FromModeline, lines: 21},
+		{name: "emacs mode", file: "config", content: "# -*- mode: ruby; coding: utf-8 -*-\n", language: "Ruby", source: wakatime.FromModeline, lines: 1},
+		{name: "emacs short mode", file: "init", content: ";; -*- emacs-lisp -*-\n", language: "Emacs Lisp", source: wakatime.FromModeline, lines: 1},
+		{name: "modeline through extensions", file: "x", content: "// vim: ft=rs\n", language: "Rust", source: wakatime.FromModeline, lines: 1},
+		{name: "modeline in the middle is ignored", file: "long.txt", content: strings.Repeat("line\n", 6) + "# vim: ft=python\n" + strings.Repeat("line\n", 6), language: "Text", source: wakatime.FromExtension, lines: 13},
+		{name: "unknown", file: "data.xyz", content: "???\n", lines: 1},
+		{name: "language option", file: "main.go", content: "package main\n", opts: wakatime.FileOptions{Language: "Go Template"}, language: "Go Template", source: wakatime.FromOption, lines: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, map[string]string{tt.file: tt.content})
+
+			info, err := wakatime.DetectFile(filepath.Join(root, tt.file), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Language != tt.language || info.LanguageSource != tt.source {
+				t.Errorf("language = %q from %q, want %q from %q", info.Language, info.LanguageSource, tt.language, tt.source)
+			}
+			if info.LineCount != tt.lines {
+				t.Errorf("line count = %d, want %d", info.LineCount, tt.lines)
+			}
+		})
+	}
+}
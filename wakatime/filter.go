@@ -0,0 +1,101 @@
+package wakatime
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/taciturnaxolotl/akami/config"
+)
+
+// HiddenFileName is what hide_file_names replaces a file's name with, keeping its extension
+const HiddenFileName = "HIDDEN"
+
+// Filtered is how a wakatime config treats a file: whether it's skipped and what's
+// hidden when it isn't. It follows wakatime-cli's rules so akami reports what the
+// real plugins would.
+type Filtered struct {
+	// Skipped is set when no heartbeat would be sent for the file
+	Skipped bool
+	// Reason explains why the file is skipped
+	Reason string
+	// ExcludedBy is the exclude pattern that matched the file
+	ExcludedBy string
+	// IncludedBy is the include pattern that matched the file, which beats any exclude
+	IncludedBy string
+	// HideFileName is set when hide_file_names covers the file
+	HideFileName bool
+	// HideProjectName is set when hide_project_names covers the file
+	HideProjectName bool
+	// HideBranchName is set when hide_branch_names covers the file
+	HideBranchName bool
+	// HideProjectFolder is set when paths are sent relative to the project folder
+	HideProjectFolder bool
+}
+
+// Filter checks a detected file against the config's exclude, include and hide settings.
+// A nil config skips nothing and hides nothing.
+func Filter(info FileInfo, cfg *config.Config) Filtered {
+	var f Filtered
+	if cfg == nil {
+		return f
+	}
+	s := cfg.Settings
+
+	if pattern := firstMatch(s.Include, info.Path); pattern != nil {
+		f.IncludedBy = pattern.String()
+	} else if pattern := firstMatch(s.Exclude, info.Path); pattern != nil {
+		f.ExcludedBy = pattern.String()
+	}
+
+	switch {
+	case s.ExcludeUnknownProject && info.Project == "":
+		f.Skipped, f.Reason = true, "exclude_unknown_project is on and no project was detected"
+	case f.ExcludedBy != "":
+		f.Skipped, f.Reason = true, "it matches the exclude pattern "+f.ExcludedBy
+	case s.IncludeOnlyWithProjectFile && info.ProjectSource != FromProjectFile:
+		f.Skipped, f.Reason = true, "include_only_with_project_file is on and there's no .wakatime-project file above it"
+	}
+
+	f.HideFileName = s.HideFileNames.Match(info.Path)
+	f.HideProjectName = s.HideProjectNames.Match(info.Path)
+	f.HideBranchName = s.HideBranchNames.Match(info.Path)
+	f.HideProjectFolder = s.HideProjectFolder && info.ProjectRoot != ""
+
+	return f
+}
+
+// Apply hides whatever the config says to hide in a heartbeat for the file.
+// wakatime-cli swaps a hidden project name for a made up one it saves in a
+// .wakatime-project file, so the project is left out here unless that file
+// already names it.
+func (f Filtered) Apply(heartbeat Heartbeat, info FileInfo) Heartbeat {
+	if f.HideProjectFolder {
+		if rel, err := filepath.Rel(info.ProjectRoot, heartbeat.Entity); err == nil {
+			heartbeat.Entity = rel
+		}
+	}
+	if f.HideFileName {
+		heartbeat.Entity = HiddenFileName + filepath.Ext(info.Path)
+		heartbeat.LineCount, heartbeat.LineNo, heartbeat.CursorPos = 0, 0, 0
+		heartbeat.Dependencies = nil
+	}
+	if f.HideProjectName && info.ProjectSource != FromProjectFile {
+		heartbeat.Project = ""
+	}
+	if f.HideBranchName {
+		heartbeat.Branch = ""
+	}
+
+	return heartbeat
+}
+
+// firstMatch returns the first pattern that matches s
+func firstMatch(patterns []*regexp.Regexp, s string) *regexp.Regexp {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return pattern
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package wakatime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrGoalsUnsupported occurs when the server doesn't have the goals endpoints, which
+// is the case for hackatime and wakapi
+var ErrGoalsUnsupported = fmt.Errorf("the server doesn't support goals")
+
+// Goal deltas, which are how long each period a goal is tracked over lasts
+const (
+	GoalDaily  = "day"
+	GoalWeekly = "week"
+)
+
+// Statuses a goal or one of its periods can have
+const (
+	GoalSuccess = "success"
+	GoalFail    = "fail"
+	GoalPending = "pending"
+	GoalIgnored = "ignored"
+)
+
+// GoalRange is the period a point in a goal's history covers
+type GoalRange struct {
+	// Date is the day as YYYY-MM-DD for daily goals
+	Date string `json:"date"`
+	// Start is when the period starts as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is when the period ends as an ISO 8601 timestamp
+	End string `json:"end"`
+	// Text describes the period like "Mon Jan 2nd"
+	Text string `json:"text"`
+	// Timezone is the timezone the period was split in
+	Timezone string `json:"timezone"`
+}
+
+// GoalPeriod is how a goal went over one day or week
+type GoalPeriod struct {
+	// ActualSeconds is the time spent towards the goal in seconds
+	ActualSeconds float64 `json:"actual_seconds"`
+	// ActualSecondsText is the human-readable representation of the time spent
+	ActualSecondsText string `json:"actual_seconds_text"`
+	// GoalSeconds is the target for the period in seconds
+	GoalSeconds float64 `json:"goal_seconds"`
+	// GoalSecondsText is the human-readable representation of the target
+	GoalSecondsText string `json:"goal_seconds_text"`
+	// Range is the period this point covers
+	Range GoalRange `json:"range"`
+	// RangeStatus is one of GoalSuccess, GoalFail, GoalPending or GoalIgnored
+	RangeStatus string `json:"range_status"`
+	// RangeStatusReason explains the status
+	RangeStatusReason string `json:"range_status_reason"`
+}
+
+// Goal is a coding target the user set on the server
+type Goal struct {
+	// ID is the goal's identifier
+	ID ID `json:"id"`
+	// Title is the goal's name like "Code 1 hr per day"
+	Title string `json:"title"`
+	// Status is how the goal is doing overall, one of the goal statuses
+	Status string `json:"status"`
+	// IsEnabled is false for goals that were turned off
+	IsEnabled bool `json:"is_enabled"`
+	// IsInverse goals are met by staying under the target instead of over it
+	IsInverse bool `json:"is_inverse"`
+	// IsSnoozed goals aren't tracked until they're unsnoozed
+	IsSnoozed bool `json:"is_snoozed"`
+	// Delta is how long each period lasts, either GoalDaily or GoalWeekly
+	Delta string `json:"delta"`
+	// Seconds is the target for each period in seconds
+	Seconds float64 `json:"seconds"`
+	// Languages narrows the goal down to time spent in these languages
+	Languages []string `json:"languages"`
+	// Projects narrows the goal down to time spent in these projects
+	Projects []string `json:"projects"`
+	// Editors narrows the goal down to time spent in these editors
+	Editors []string `json:"editors"`
+	// Categories narrows the goal down to time spent in these categories
+	Categories []string `json:"categories"`
+	// IgnoreDays are weekdays like "saturday" the goal isn't tracked on
+	IgnoreDays []string `json:"ignore_days"`
+	// RangeText describes the period the chart data covers
+	RangeText string `json:"range_text"`
+	// StatusPercentCalculated is how far through the current period's target the user is
+	StatusPercentCalculated float64 `json:"status_percent_calculated"`
+	// ChartData is the goal's history, oldest period first
+	ChartData []GoalPeriod `json:"chart_data"`
+	// CreatedAt is when the goal was created as an ISO 8601 timestamp
+	CreatedAt string `json:"created_at"`
+	// ModifiedAt is when the goal was last changed as an ISO 8601 timestamp
+	ModifiedAt string `json:"modified_at"`
+}
+
+// GoalsResponse represents the response from the WakaTime goals endpoint
+type GoalsResponse struct {
+	// Data holds the user's goals
+	Data []Goal `json:"data"`
+	// Total is how many goals the user has
+	Total int `json:"total"`
+	// TotalPages is how many pages of goals there are
+	TotalPages int `json:"total_pages"`
+}
+
+// GoalResponse represents the response from the WakaTime endpoint for a single goal
+type GoalResponse struct {
+	// Data is the goal
+	Data Goal `json:"data"`
+}
+
+// GetGoals retrieves the goals the user has set along with their recent history.
+// It returns an error matching ErrGoalsUnsupported when the server has no goals endpoint.
+func (c *Client) GetGoals() (GoalsResponse, error) {
+	return c.GetGoalsContext(context.Background())
+}
+
+// GetGoalsContext is GetGoals with a context that can cancel the request and its retries.
+func (c *Client) GetGoalsContext(ctx context.Context) (GoalsResponse, error) {
+	var goalsResp GoalsResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/goals", c.APIURL), &goalsResp); err != nil {
+		return GoalsResponse{}, goalsError(err)
+	}
+
+	return goalsResp, nil
+}
+
+// GetGoal retrieves a single goal by its id.
+func (c *Client) GetGoal(id string) (GoalResponse, error) {
+	return c.GetGoalContext(context.Background(), id)
+}
+
+// GetGoalContext is GetGoal with a context that can cancel the request and its retries.
+func (c *Client) GetGoalContext(ctx context.Context, id string) (GoalResponse, error) {
+	var goalResp GoalResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/goals/%s", c.APIURL, url.PathEscape(id)), &goalResp); err != nil {
+		return GoalResponse{}, err
+	}
+
+	return goalResp, nil
+}
+
+// goalsError marks a 404 from the goals endpoints as the server not supporting goals
+func goalsError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", ErrGoalsUnsupported, err)
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package wakatime
+
+import "strings"
+
+// languagesByExtension maps lowercase file extensions to the language names the api uses
+var languagesByExtension = map[string]string{
+	".go":         "Go",
+	".mod":        "Go Module",
+	".py":         "Python",
+	".pyi":        "Python",
+	".js":         "JavaScript",
+	".mjs":        "JavaScript",
+	".cjs":        "JavaScript",
+	".jsx":        "JSX",
+	".ts":         "TypeScript",
+	".mts":        "TypeScript",
+	".tsx":        "TSX",
+	".rs":         "Rust",
+	".c":          "C",
+	".h":          "C",
+	".cc":         "C++",
+	".cpp":        "C++",
+	".cxx":        "C++",
+	".hpp":        "C++",
+	".cs":         "C#",
+	".java":       "Java",
+	".kt":         "Kotlin",
+	".kts":        "Kotlin",
+	".swift":      "Swift",
+	".rb":         "Ruby",
+	".php":        "PHP",
+	".lua":        "Lua",
+	".zig":        "Zig",
+	".nix":        "Nix",
+	".hs":         "Haskell",
+	".ex":         "Elixir",
+	".exs":        "Elixir",
+	".erl":        "Erlang",
+	".clj":        "Clojure",
+	".scala":      "Scala",
+	".dart":       "Dart",
+	".r":          "R",
+	".jl":         "Julia",
+	".ml":         "OCaml",
+	".sh":         "Bash",
+	".bash":       "Bash",
+	".zsh":        "Zsh",
+	".fish":       "Fish",
+	".ps1":        "PowerShell",
+	".sql":        "SQL",
+	".html":       "HTML",
+	".htm":        "HTML",
+	".css":        "CSS",
+	".scss":       "SCSS",
+	".sass":       "Sass",
+	".less":       "Less",
+	".vue":        "Vue.js",
+	".svelte":     "Svelte",
+	".astro":      "Astro",
+	".json":       "JSON",
+	".yaml":       "YAML",
+	".yml":        "YAML",
+	".toml":       "TOML",
+	".xml":        "XML",
+	".ini":        "INI",
+	".cfg":        "INI",
+	".md":         "Markdown",
+	".markdown":   "Markdown",
+	".rst":        "reStructuredText",
+	".tex":        "TeX",
+	".txt":        "Text",
+	".vim":        "VimL",
+	".el":         "Emacs Lisp",
+	".proto":      "Protocol Buffer",
+	".graphql":    "GraphQL",
+	".tf":         "HCL",
+	".hcl":        "HCL",
+	".dockerfile": "Docker",
+}
+
+// languagesByFilename maps whole file names that don't have a telling extension
+var languagesByFilename = map[string]string{
+	"makefile":       "Makefile",
+	"gnumakefile":    "Makefile",
+	"dockerfile":     "Docker",
+	"containerfile":  "Docker",
+	"justfile":       "Just",
+	"cmakelists.txt": "CMake",
+	"go.mod":         "Go Module",
+	"go.sum":         "Go Checksums",
+	"gemfile":        "Ruby",
+	"rakefile":       "Ruby",
+	".bashrc":        "Bash",
+	".zshrc":         "Zsh",
+	".wakatime.cfg":  "INI",
+}
+
+// languagesByInterpreter maps the program a shebang runs to a language
+var languagesByInterpreter = map[string]string{
+	"sh":      "Bash",
+	"bash":    "Bash",
+	"zsh":     "Zsh",
+	"fish":    "Fish",
+	"python":  "Python",
+	"python2": "Python",
+	"python3": "Python",
+	"node":    "JavaScript",
+	"deno":    "TypeScript",
+	"bun":     "JavaScript",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"nix":     "Nix",
+	"pwsh":    "PowerShell",
+	"Rscript": "R",
+}
+
+// languagesByModeline maps vim filetypes and emacs modes to languages. Anything not
+// listed is looked up as if it were a file extension before being used as written.
+var languagesByModeline = map[string]string{
+	"python":     "Python",
+	"javascript": "JavaScript",
+	"typescript": "TypeScript",
+	"sh":         "Bash",
+	"bash":       "Bash",
+	"shell":      "Bash",
+	"ruby":       "Ruby",
+	"rust":       "Rust",
+	"golang":     "Go",
+	"go":         "Go",
+	"c++":        "C++",
+	"cpp":        "C++",
+	"markdown":   "Markdown",
+	"yaml":       "YAML",
+	"make":       "Makefile",
+	"makefile":   "Makefile",
+	"dockerfile": "Docker",
+	"lisp":       "Common Lisp",
+	"emacs-lisp": "Emacs Lisp",
+	"vim":        "VimL",
+	"text":       "Text",
+}
+
+// modelineLanguage maps a vim filetype or emacs mode to a language
+func modelineLanguage(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "-mode"))
+	if language, ok := languagesByModeline[name]; ok {
+		return language
+	}
+	if language, ok := languagesByExtension["."+name]; ok {
+		return language
+	}
+
+	return name
+}
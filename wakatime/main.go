@@ -4,9 +4,9 @@
 package wakatime
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -27,7 +27,7 @@ var (
 	ErrCreatingRequest = fmt.Errorf("failed to create HTTP request")
 	// ErrSendingRequest occurs when the HTTP request fails to send
 	ErrSendingRequest = fmt.Errorf("failed to send HTTP request")
-	// ErrInvalidStatusCode occurs when the API returns a non-success status code
+	// ErrInvalidStatusCode occurs when the API returns a non-success status code; the error is an *APIError
 	ErrInvalidStatusCode = fmt.Errorf("received invalid status code from API")
 	// ErrDecodingResponse occurs when the API response can't be decoded
 	ErrDecodingResponse = fmt.Errorf("failed to decode API response")
@@ -51,6 +51,10 @@ type Client struct {
 	APIURL string
 	// HTTPClient is the HTTP client used to make requests to the WakaTime API
 	HTTPClient *http.Client
+	// Queue is an optional offline queue that heartbeats are saved to when they can't be sent
+	Queue *Queue
+	// Retry controls how failed requests are retried
+	Retry RetryPolicy
 }
 
 // NewClient creates a new WakaTime API client with the provided API key
@@ -60,6 +64,7 @@ func NewClient(apiKey string) *Client {
 		APIKey:     apiKey,
 		APIURL:     DefaultAPIURL,
 		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Retry:      DefaultRetryPolicy,
 	}
 }
 
@@ -70,6 +75,7 @@ func NewClientWithOptions(apiKey string, apiURL string) *Client {
 		APIKey:     apiKey,
 		APIURL:     strings.TrimSuffix(apiURL, "/"),
 		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Retry:      DefaultRetryPolicy,
 	}
 }
 
@@ -77,37 +83,37 @@ func NewClientWithOptions(apiKey string, apiURL string) *Client {
 // Heartbeats are the core data structure for tracking time spent coding.
 type Heartbeat struct {
 	// Entity is the file path or resource being worked on
-	Entity string `json:"entity"`
+	Entity string `json:"entity" yaml:"entity"`
 	// Type specifies the entity type (usually "file")
-	Type string `json:"type"`
+	Type string `json:"type" yaml:"type"`
 	// Time is the timestamp of the heartbeat in UNIX epoch format
-	Time float64 `json:"time"`
+	Time float64 `json:"time" yaml:"time"`
 	// Project is the optional project name associated with the entity
-	Project string `json:"project,omitempty"`
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
 	// Language is the optional programming language of the entity
-	Language string `json:"language,omitempty"`
+	Language string `json:"language,omitempty" yaml:"language,omitempty"`
 	// IsWrite indicates if the file was being written to (vs. just viewed)
-	IsWrite bool `json:"is_write,omitempty"`
+	IsWrite bool `json:"is_write,omitempty" yaml:"is_write,omitempty"`
 	// EditorName is the optional name of the editor or IDE being used
-	EditorName string `json:"editor_name,omitempty"`
+	EditorName string `json:"editor_name,omitempty" yaml:"editor_name,omitempty"`
 	// Branch is the optional git branch name
-	Branch string `json:"branch,omitempty"`
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
 	// Category is the optional activity category
-	Category string `json:"category,omitempty"`
+	Category string `json:"category,omitempty" yaml:"category,omitempty"`
 	// LineCount is the optional number of lines in the file
-	LineCount int `json:"lines,omitempty"`
+	LineCount int `json:"lines,omitempty" yaml:"lines,omitempty"`
 	// LineNo is the current line number
-	LineNo int `json:"lineno,omitempty"`
+	LineNo int `json:"lineno,omitempty" yaml:"lineno,omitempty"`
 	// CursorPos is the current column of text the cursor is on
-	CursorPos int `json:"cursorpos,omitempty"`
+	CursorPos int `json:"cursorpos,omitempty" yaml:"cursorpos,omitempty"`
 	// UserAgent is the optional user agent string
-	UserAgent string `json:"user_agent,omitempty"`
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
 	// EntityType is the optional entity type (usually redundant with Type)
-	EntityType string `json:"entity_type,omitempty"`
+	EntityType string `json:"entity_type,omitempty" yaml:"entity_type,omitempty"`
 	// Dependencies is an optional list of project dependencies
-	Dependencies []string `json:"dependencies,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 	// ProjectRootCount is the optional number of directories in the project root path
-	ProjectRootCount int `json:"project_root_count,omitempty"`
+	ProjectRootCount int `json:"project_root_count,omitempty" yaml:"project_root_count,omitempty"`
 }
 
 // StatusBarResponse represents the response from the WakaTime Status Bar API endpoint.
@@ -127,177 +133,201 @@ type StatusBarResponse struct {
 	} `json:"data"`
 }
 
-// SendHeartbeat sends a coding activity heartbeat to the WakaTime API.
-// It returns an error if the request fails or returns a non-success status code.
-func (c *Client) SendHeartbeat(heartbeat Heartbeat) error {
-	// Set the user agent in the heartbeat data
-	if heartbeat.UserAgent == "" {
-		heartbeat.UserAgent = "wakatime/unset (" + runtime.GOOS + "-" + runtime.GOARCH + ") akami-wakatime/1.0.0"
-	}
+// userAgent is the user agent akami reports for heartbeats and requests
+var userAgent = "wakatime/unset (" + runtime.GOOS + "-" + runtime.GOARCH + ") akami-wakatime/1.0.0"
+
+// MaxBulkHeartbeats is the largest number of heartbeats the API accepts in a single bulk request
+const MaxBulkHeartbeats = 25
+
+// HeartbeatResult represents the outcome of a single heartbeat in a bulk request.
+// The API answers each heartbeat with a body and a status code of its own.
+type HeartbeatResult struct {
+	// StatusCode is the HTTP status code the API assigned to this heartbeat
+	StatusCode int
+	// ID is the identifier the API assigned to the heartbeat when it was accepted
+	ID string
+	// Error is the error message the API returned when the heartbeat was rejected
+	Error string
+	// Body is the raw response body for this heartbeat
+	Body json.RawMessage
+}
 
-	data, err := json.Marshal(heartbeat)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrMarshalingHeartbeat, err)
-	}
+// OK reports whether the API accepted the heartbeat.
+func (r HeartbeatResult) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/current/statusbar/today", c.APIURL), bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrCreatingRequest, err)
+// UnmarshalJSON decodes a single [body, status] pair from a bulk heartbeat response.
+func (r *HeartbeatResult) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.APIKey)))
-	// Set the user agent in the request header as well
-	req.Header.Set("User-Agent", "wakatime/unset ("+runtime.GOOS+"-"+runtime.GOARCH+") akami-wakatime/1.0.0")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrSendingRequest, err)
+	if len(pair) != 2 {
+		return fmt.Errorf("expected a [body, status] pair but got %d elements", len(pair))
 	}
-	defer resp.Body.Close()
 
-	// Read and log the response
-	var respBody bytes.Buffer
-	_, err = respBody.ReadFrom(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
+	if err := json.Unmarshal(pair[1], &r.StatusCode); err != nil {
+		return err
+	}
+	r.Body = pair[0]
+
+	// wakatime wraps the heartbeat in a data field while hackatime returns it bare
+	var body struct {
+		Data *struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+		ID    json.RawMessage `json:"id"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(pair[0], &body); err != nil {
+		// not every server sends an object back so keep the raw body and move on
+		return nil
 	}
 
-	respContent := respBody.String()
-
-	if resp.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("%w: %s", ErrUnauthorized, respContent)
-	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("%w: status code %d, response: %s", ErrInvalidStatusCode, resp.StatusCode, respContent)
+	id := body.ID
+	if body.Data != nil && len(body.Data.ID) > 0 {
+		id = body.Data.ID
+	}
+	r.ID = strings.Trim(string(id), `"`)
+
+	// errors can be a plain string or an object keyed by field name
+	var errMsg string
+	if err := json.Unmarshal(body.Error, &errMsg); err == nil {
+		r.Error = errMsg
+	} else if len(body.Error) > 0 && string(body.Error) != "null" {
+		r.Error = string(body.Error)
 	}
 
 	return nil
 }
 
-// GetStatusBar retrieves a user's current day coding activity summary from the WakaTime API.
-// It returns an error if the request fails or returns a non-success status code.
-func (c *Client) GetStatusBar() (StatusBarResponse, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/current/statusbar/today", c.APIURL), nil)
-	if err != nil {
-		return StatusBarResponse{}, fmt.Errorf("%w: %v", ErrCreatingRequest, err)
-	}
+// BulkHeartbeatResponse represents the response from the WakaTime bulk heartbeats endpoint.
+type BulkHeartbeatResponse struct {
+	// Responses contains one result per heartbeat in the order they were sent
+	Responses []HeartbeatResult `json:"responses"`
+}
 
-	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.APIKey)))
+// SendHeartbeat sends a coding activity heartbeat to the WakaTime API.
+// It returns an error if the request fails or returns a non-success status code.
+func (c *Client) SendHeartbeat(heartbeat Heartbeat) error {
+	return c.SendHeartbeatContext(context.Background(), heartbeat)
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return StatusBarResponse{}, fmt.Errorf("%w: %v", ErrSendingRequest, err)
+// SendHeartbeatContext is SendHeartbeat with a context that can cancel the request and its retries.
+func (c *Client) SendHeartbeatContext(ctx context.Context, heartbeat Heartbeat) error {
+	// Set the user agent in the heartbeat data
+	if heartbeat.UserAgent == "" {
+		heartbeat.UserAgent = userAgent
 	}
-	defer resp.Body.Close()
 
-	// Read the response body for potential error messages
-	var respBody bytes.Buffer
-	_, err = respBody.ReadFrom(resp.Body)
+	data, err := json.Marshal(heartbeat)
 	if err != nil {
-		return StatusBarResponse{}, fmt.Errorf("failed to read response body: %v", err)
+		return fmt.Errorf("%w: %v", ErrMarshalingHeartbeat, err)
 	}
 
-	respContent := respBody.String()
+	_, err = c.do(ctx, http.MethodPost, fmt.Sprintf("%s/users/current/heartbeats", c.APIURL), data)
+	return c.spill(err, heartbeat)
+}
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return StatusBarResponse{}, fmt.Errorf("%w: %s", ErrUnauthorized, respContent)
-	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return StatusBarResponse{}, fmt.Errorf("%w: status code %d, response: %s", ErrInvalidStatusCode, resp.StatusCode, respContent)
-	}
+// SendHeartbeats sends multiple heartbeats to the WakaTime API using the bulk endpoint.
+// Heartbeats are split into batches of MaxBulkHeartbeats and the per-heartbeat results
+// are returned in the same order as the input. An error is only returned when a whole
+// request fails; individual rejected heartbeats are reported through their result.
+func (c *Client) SendHeartbeats(heartbeats []Heartbeat) ([]HeartbeatResult, error) {
+	return c.SendHeartbeatsContext(context.Background(), heartbeats)
+}
 
-	var durationResp StatusBarResponse
-	if err := json.Unmarshal(respBody.Bytes(), &durationResp); err != nil {
-		return StatusBarResponse{}, fmt.Errorf("%w: %v, response: %s", ErrDecodingResponse, err, respContent)
+// SendHeartbeatsContext is SendHeartbeats with a context that can cancel the requests and their retries.
+func (c *Client) SendHeartbeatsContext(ctx context.Context, heartbeats []Heartbeat) ([]HeartbeatResult, error) {
+	results := make([]HeartbeatResult, 0, len(heartbeats))
+
+	for start := 0; start < len(heartbeats); start += MaxBulkHeartbeats {
+		batch := make([]Heartbeat, 0, MaxBulkHeartbeats)
+		for _, heartbeat := range heartbeats[start:min(start+MaxBulkHeartbeats, len(heartbeats))] {
+			if heartbeat.UserAgent == "" {
+				heartbeat.UserAgent = userAgent
+			}
+			batch = append(batch, heartbeat)
+		}
+
+		data, err := json.Marshal(batch)
+		if err != nil {
+			return results, fmt.Errorf("%w: %v", ErrMarshalingHeartbeat, err)
+		}
+
+		respBody, err := c.do(ctx, http.MethodPost, fmt.Sprintf("%s/users/current/heartbeats.bulk", c.APIURL), data)
+		if err != nil {
+			// nothing from here on made it to the server so spill the rest as well
+			return results, c.spill(err, heartbeats[start:]...)
+		}
+
+		var bulkResp BulkHeartbeatResponse
+		if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+			return results, fmt.Errorf("%w: %v, response: %s", ErrDecodingResponse, err, string(respBody))
+		}
+		if len(bulkResp.Responses) != len(batch) {
+			return results, fmt.Errorf("%w: sent %d heartbeats but got %d results back", ErrDecodingResponse, len(batch), len(bulkResp.Responses))
+		}
+
+		results = append(results, bulkResp.Responses...)
 	}
 
-	return durationResp, nil
+	return results, nil
 }
 
-// Last7DaysResponse represents the response from the WakaTime Last 7 Days API endpoint.
-// This contains detailed information about a user's coding activity over the past 7 days.
-type Last7DaysResponse struct {
-	// Data contains coding statistics for the last 7 days
-	Data struct {
-		// TotalSeconds is the total time spent coding in seconds
-		TotalSeconds float64 `json:"total_seconds"`
-		// HumanReadableTotal is the human-readable representation of the total coding time
-		HumanReadableTotal string `json:"human_readable_total"`
-		// DailyAverage is the average time spent coding per day in seconds
-		DailyAverage float64 `json:"daily_average"`
-		// HumanReadableDailyAverage is the human-readable representation of the daily average
-		HumanReadableDailyAverage string `json:"human_readable_daily_average"`
-		// Languages is a list of programming languages used with statistics
-		Languages []struct {
-			// Name is the programming language name
-			Name string `json:"name"`
-			// TotalSeconds is the time spent coding in this language in seconds
-			TotalSeconds float64 `json:"total_seconds"`
-			// Percent is the percentage of time spent in this language
-			Percent float64 `json:"percent"`
-			// Text is the human-readable representation of time spent in this language
-			Text string `json:"text"`
-		} `json:"languages"`
-		// Editors is a list of editors used with statistics
-		Editors []struct {
-			// Name is the editor name
-			Name string `json:"name"`
-			// TotalSeconds is the time spent using this editor in seconds
-			TotalSeconds float64 `json:"total_seconds"`
-			// Percent is the percentage of time spent using this editor
-			Percent float64 `json:"percent"`
-			// Text is the human-readable representation of time spent using this editor
-			Text string `json:"text"`
-		} `json:"editors"`
-		// Projects is a list of projects worked on with statistics
-		Projects []struct {
-			// Name is the project name
-			Name string `json:"name"`
-			// TotalSeconds is the time spent on this project in seconds
-			TotalSeconds float64 `json:"total_seconds"`
-			// Percent is the percentage of time spent on this project
-			Percent float64 `json:"percent"`
-			// Text is the human-readable representation of time spent on this project
-			Text string `json:"text"`
-		} `json:"projects"`
-	} `json:"data"`
-}
+// spill saves heartbeats to the offline queue when err means the request never reached the API.
+// It returns err unchanged when there is no queue or the failure wasn't a connection problem.
+func (c *Client) spill(err error, heartbeats ...Heartbeat) error {
+	if err == nil || c.Queue == nil || !errors.Is(err, ErrSendingRequest) {
+		return err
+	}
 
-// GetLast7Days retrieves a user's coding activity summary for the past 7 days from the WakaTime API.
-// It returns a Last7DaysResponse and an error if the request fails or returns a non-success status code.
-func (c *Client) GetLast7Days() (Last7DaysResponse, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/current/stats/last_7_days", c.APIURL), nil)
-	if err != nil {
-		return Last7DaysResponse{}, fmt.Errorf("%w: %v", ErrCreatingRequest, err)
+	if queueErr := c.Queue.Push(heartbeats...); queueErr != nil {
+		return fmt.Errorf("%w (also failed to save to the offline queue: %v)", err, queueErr)
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.APIKey)))
+	return fmt.Errorf("%w: %w", ErrQueued, err)
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// get fetches an API endpoint and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	respBody, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return Last7DaysResponse{}, fmt.Errorf("%w: %v", ErrSendingRequest, err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	// Read the response body for potential error messages
-	var respBody bytes.Buffer
-	_, err = respBody.ReadFrom(resp.Body)
-	if err != nil {
-		return Last7DaysResponse{}, fmt.Errorf("failed to read response body: %v", err)
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return fmt.Errorf("%w: %v, response: %s", ErrDecodingResponse, err, string(respBody))
 	}
 
-	respContent := respBody.String()
+	return nil
+}
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return Last7DaysResponse{}, fmt.Errorf("%w: %s", ErrUnauthorized, respContent)
-	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return Last7DaysResponse{}, fmt.Errorf("%w: status code %d, response: %s", ErrInvalidStatusCode, resp.StatusCode, respContent)
-	}
+// GetStatusBar retrieves a user's current day coding activity summary from the WakaTime API.
+// It returns an error if the request fails or returns a non-success status code.
+func (c *Client) GetStatusBar() (StatusBarResponse, error) {
+	return c.GetStatusBarContext(context.Background())
+}
 
-	var statsResp Last7DaysResponse
-	if err := json.Unmarshal(respBody.Bytes(), &statsResp); err != nil {
-		return Last7DaysResponse{}, fmt.Errorf("%w: %v, response: %s", ErrDecodingResponse, err, respContent)
+// GetStatusBarContext is GetStatusBar with a context that can cancel the request and its retries.
+func (c *Client) GetStatusBarContext(ctx context.Context) (StatusBarResponse, error) {
+	var durationResp StatusBarResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/statusbar/today", c.APIURL), &durationResp); err != nil {
+		return StatusBarResponse{}, err
 	}
 
-	return statsResp, nil
+	return durationResp, nil
+}
+
+// StatItem is a single named entry in a stats breakdown such as a language, editor or project.
+type StatItem struct {
+	// Name is the name of the language, editor or project
+	Name string `json:"name"`
+	// TotalSeconds is the time spent in seconds
+	TotalSeconds float64 `json:"total_seconds"`
+	// Percent is the percentage of the total time
+	Percent float64 `json:"percent"`
+	// Text is the human-readable representation of the time spent
+	Text string `json:"text"`
 }
@@ -0,0 +1,88 @@
+package wakatime_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+// fastRetry retries like the default policy without making tests wait
+var fastRetry = wakatime.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// heartbeats makes n heartbeats for different files in the same project
+func heartbeats(n int) []wakatime.Heartbeat {
+	hbs := make([]wakatime.Heartbeat, n)
+	for i := range hbs {
+		hbs[i] = wakatime.Heartbeat{Entity: fmt.Sprintf("/code/akami/file%d.go", i), Type: "file", Time: float64(1700000000 + i), Project: "akami"}
+	}
+
+	return hbs
+}
+
+func TestSendHeartbeatsBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		requests int
+	}{
+		{name: "none", count: 0, requests: 0},
+		{name: "one", count: 1, requests: 1},
+		{name: "full batch", count: wakatime.MaxBulkHeartbeats, requests: 1},
+		{name: "one over", count: wakatime.MaxBulkHeartbeats + 1, requests: 2},
+		{name: "several batches", count: 3*wakatime.MaxBulkHeartbeats - 5, requests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := wakatimetest.NewServer()
+			defer server.Close()
+
+			results, err := server.Client().SendHeartbeats(heartbeats(tt.count))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != tt.count {
+				t.Errorf("got %d results, want %d", len(results), tt.count)
+			}
+			for i, result := range results {
+				if !result.OK() || result.ID == "" {
+					t.Errorf("result %d = %+v, want it accepted", i, result)
+				}
+			}
+			if got := len(server.Requests()); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+			if got := len(server.Heartbeats()); got != tt.count {
+				t.Errorf("server has %d heartbeats, want %d", got, tt.count)
+			}
+		})
+	}
+}
+
+func TestSendHeartbeatsRejected(t *testing.T) {
+	server := wakatimetest.NewServer()
+	defer server.Close()
+
+	hbs := heartbeats(3)
+	hbs[1].Entity = ""
+
+	results, err := server.Client().SendHeartbeats(hbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{http.StatusCreated, http.StatusBadRequest, http.StatusCreated}
+	for i, result := range results {
+		if result.StatusCode != want[i] {
+			t.Errorf("result %d has status %d, want %d", i, result.StatusCode, want[i])
+		}
+	}
+	if results[1].OK() || results[1].Error == "" {
+		t.Errorf("rejected result = %+v, want an error message", results[1])
+	}
+}
@@ -0,0 +1,119 @@
+package wakatime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/config"
+	bolt "go.etcd.io/bbolt"
+)
+
+// offlineBucket is the bucket wakatime-cli stores unsent heartbeats in
+const offlineBucket = "heartbeats"
+
+// ErrOfflineQueueBusy occurs when wakatime-cli is holding the offline queue open
+var ErrOfflineQueueBusy = fmt.Errorf("wakatime-cli's offline queue is in use by another process")
+
+// OfflineHeartbeat is a heartbeat sitting in wakatime-cli's offline queue.
+type OfflineHeartbeat struct {
+	// Key is the key wakatime-cli stored the heartbeat under
+	Key string `json:"key"`
+	// Heartbeat is the decoded heartbeat
+	Heartbeat Heartbeat `json:"heartbeat"`
+}
+
+// OfflineQueuePath returns where wakatime-cli keeps its offline heartbeat queue which
+// lives under $WAKATIME_HOME when it is set.
+func OfflineQueuePath() (string, error) {
+	home, err := config.Home()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".wakatime", "offline_heartbeats.bdb"), nil
+}
+
+// ReadOfflineQueue opens wakatime-cli's offline queue read-only and returns every
+// heartbeat in it, oldest first. It returns os.ErrNotExist if there is no queue.
+func ReadOfflineQueue(path string) ([]OfflineHeartbeat, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := openOfflineQueue(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var heartbeats []OfflineHeartbeat
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(offlineBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var heartbeat Heartbeat
+			if err := json.Unmarshal(v, &heartbeat); err != nil {
+				// leave heartbeats we can't read for wakatime-cli to deal with
+				return nil
+			}
+
+			heartbeats = append(heartbeats, OfflineHeartbeat{Key: string(k), Heartbeat: heartbeat})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(heartbeats, func(i, j int) bool {
+		return heartbeats[i].Heartbeat.Time < heartbeats[j].Heartbeat.Time
+	})
+
+	return heartbeats, nil
+}
+
+// RemoveOfflineHeartbeats deletes the heartbeats stored under keys from wakatime-cli's offline queue.
+func RemoveOfflineHeartbeats(path string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	db, err := openOfflineQueue(path, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(offlineBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		for _, key := range keys {
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// openOfflineQueue opens the bolt database giving up quickly if wakatime-cli has it locked
+func openOfflineQueue(path string, readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{ReadOnly: readOnly, Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, ErrOfflineQueueBusy
+	}
+
+	return db, err
+}
@@ -0,0 +1,278 @@
+package wakatime
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrQueued is joined onto a send error when the heartbeat was saved to the offline queue instead
+var ErrQueued = fmt.Errorf("heartbeat saved to the offline queue")
+
+// ErrQueueLocked occurs when another process holds the queue lock for too long
+var ErrQueueLocked = fmt.Errorf("offline queue is locked by another process")
+
+// QueuedHeartbeat is a heartbeat waiting in the offline queue along with its bookkeeping.
+type QueuedHeartbeat struct {
+	// ID uniquely identifies the heartbeat within the queue
+	ID string `json:"id"`
+	// QueuedAt is when the heartbeat was added to the queue
+	QueuedAt time.Time `json:"queued_at"`
+	// Heartbeat is the heartbeat that failed to send
+	Heartbeat Heartbeat `json:"heartbeat"`
+}
+
+// QueueStats summarises the contents of the offline queue.
+type QueueStats struct {
+	// Count is the number of heartbeats waiting to be sent
+	Count int `json:"count"`
+	// Size is the size of the queue file in bytes
+	Size int64 `json:"size"`
+	// Oldest is the time of the oldest heartbeat in the queue
+	Oldest time.Time `json:"oldest"`
+	// Newest is the time of the newest heartbeat in the queue
+	Newest time.Time `json:"newest"`
+	// Projects is the number of queued heartbeats per project
+	Projects map[string]int `json:"projects"`
+}
+
+// Queue is a file-backed store for heartbeats that could not be sent.
+// Heartbeats are stored one JSON object per line so appends stay cheap, and
+// a lock file next to the queue keeps multiple akami processes from clobbering it.
+type Queue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// OpenQueue opens the queue stored at path, creating its directory if needed.
+// The file itself is only created once the first heartbeat is pushed.
+func OpenQueue(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Queue{path: path}, nil
+}
+
+// Path returns the location of the queue file on disk.
+func (q *Queue) Path() string {
+	return q.path
+}
+
+// Push appends heartbeats to the end of the queue.
+func (q *Queue) Push(heartbeats ...Heartbeat) error {
+	if len(heartbeats) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, heartbeat := range heartbeats {
+		line, err := json.Marshal(QueuedHeartbeat{
+			ID:        newQueueID(),
+			QueuedAt:  time.Now(),
+			Heartbeat: heartbeat,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrMarshalingHeartbeat, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return q.withLock(func() error {
+		f, err := os.OpenFile(q.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = f.Write(buf.Bytes())
+		return err
+	})
+}
+
+// List returns every heartbeat in the queue, oldest first.
+func (q *Queue) List() ([]QueuedHeartbeat, error) {
+	var queued []QueuedHeartbeat
+	err := q.withLock(func() error {
+		var err error
+		queued, err = q.read()
+		return err
+	})
+
+	return queued, err
+}
+
+// Remove deletes the heartbeats with the given ids from the queue.
+func (q *Queue) Remove(ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	return q.withLock(func() error {
+		queued, err := q.read()
+		if err != nil {
+			return err
+		}
+
+		kept := queued[:0]
+		for _, item := range queued {
+			if !remove[item.ID] {
+				kept = append(kept, item)
+			}
+		}
+
+		return q.write(kept)
+	})
+}
+
+// Purge deletes every heartbeat in the queue.
+func (q *Queue) Purge() error {
+	return q.withLock(func() error {
+		err := os.Remove(q.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Stats returns a summary of what is currently in the queue.
+func (q *Queue) Stats() (QueueStats, error) {
+	stats := QueueStats{Projects: map[string]int{}}
+
+	queued, err := q.List()
+	if err != nil {
+		return stats, err
+	}
+
+	if info, err := os.Stat(q.path); err == nil {
+		stats.Size = info.Size()
+	}
+
+	for _, item := range queued {
+		stats.Count++
+		stats.Projects[item.Heartbeat.Project]++
+
+		sent := time.Unix(0, int64(item.Heartbeat.Time*float64(time.Second)))
+		if stats.Oldest.IsZero() || sent.Before(stats.Oldest) {
+			stats.Oldest = sent
+		}
+		if sent.After(stats.Newest) {
+			stats.Newest = sent
+		}
+	}
+
+	return stats, nil
+}
+
+// read loads the queue file; callers must hold the lock
+func (q *Queue) read() ([]QueuedHeartbeat, error) {
+	f, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queued []QueuedHeartbeat
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item QueuedHeartbeat
+		if err := json.Unmarshal(line, &item); err != nil {
+			// skip corrupt lines rather than losing the whole queue
+			continue
+		}
+		queued = append(queued, item)
+	}
+
+	return queued, scanner.Err()
+}
+
+// write atomically replaces the queue file; callers must hold the lock
+func (q *Queue) write(queued []QueuedHeartbeat) error {
+	if len(queued) == 0 {
+		err := os.Remove(q.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, item := range queued {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrMarshalingHeartbeat, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
+
+// withLock runs fn while holding both the in-process mutex and the on-disk lock file
+func (q *Queue) withLock(fn func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lockPath := q.path + ".lock"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+
+		// a lock older than a minute was left behind by a crashed process
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return ErrQueueLocked
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+	defer os.Remove(lockPath)
+
+	return fn()
+}
+
+// newQueueID returns a random identifier for a queued heartbeat
+func newQueueID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package wakatime_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+func TestQueue(t *testing.T) {
+	tests := []struct {
+		name   string
+		push   int
+		remove []int
+		left   []string
+	}{
+		{name: "empty", push: 0},
+		{name: "keeps order", push: 3, left: []string{"/code/akami/file0.go", "/code/akami/file1.go", "/code/akami/file2.go"}},
+		{name: "remove first", push: 3, remove: []int{0}, left: []string{"/code/akami/file1.go", "/code/akami/file2.go"}},
+		{name: "remove some", push: 4, remove: []int{1, 3}, left: []string{"/code/akami/file0.go", "/code/akami/file2.go"}},
+		{name: "remove all", push: 2, remove: []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, err := wakatime.OpenQueue(filepath.Join(t.TempDir(), "nested", "queue.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := queue.Push(heartbeats(tt.push)...); err != nil {
+				t.Fatal(err)
+			}
+
+			queued, err := queue.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) != tt.push {
+				t.Fatalf("listed %d heartbeats, want %d", len(queued), tt.push)
+			}
+
+			var ids []string
+			for _, i := range tt.remove {
+				ids = append(ids, queued[i].ID)
+			}
+			if err := queue.Remove(ids...); err != nil {
+				t.Fatal(err)
+			}
+
+			queued, err = queue.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var left []string
+			for _, item := range queued {
+				left = append(left, item.Heartbeat.Entity)
+			}
+			if !slices.Equal(left, tt.left) {
+				t.Errorf("left %q, want %q", left, tt.left)
+			}
+
+			stats, err := queue.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Count != len(tt.left) {
+				t.Errorf("stats count = %d, want %d", stats.Count, len(tt.left))
+			}
+		})
+	}
+}
+
+func TestSendToQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		offline bool
+		failure wakatimetest.Failure
+		count   int
+		queued  int
+		err     error
+	}{
+		{name: "sent", count: 1},
+		{name: "offline single", offline: true, count: 1, queued: 1, err: wakatime.ErrQueued},
+		{name: "offline bulk", offline: true, count: wakatime.MaxBulkHeartbeats + 2, queued: wakatime.MaxBulkHeartbeats + 2, err: wakatime.ErrQueued},
+		{name: "server error isn't queued", failure: wakatimetest.FailureServerError, count: 1, err: wakatime.ErrInvalidStatusCode},
+		{name: "unauthorized isn't queued", failure: wakatimetest.FailureUnauthorized, count: 2, err: wakatime.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := wakatimetest.NewServer(wakatimetest.WithFailure(tt.failure))
+			defer server.Close()
+
+			client := server.Client()
+			client.Retry = fastRetry
+			if tt.offline {
+				// a closed server refuses connections like an unreachable one
+				server.Close()
+			}
+
+			queue, err := wakatime.OpenQueue(filepath.Join(t.TempDir(), "queue.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.Queue = queue
+
+			if tt.count == 1 {
+				err = client.SendHeartbeat(heartbeats(1)[0])
+			} else {
+				_, err = client.SendHeartbeats(heartbeats(tt.count))
+			}
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if tt.offline && !errors.Is(err, wakatime.ErrSendingRequest) {
+				t.Errorf("err = %v, want it to keep the send error", err)
+			}
+
+			queued, err := queue.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) != tt.queued {
+				t.Errorf("queued %d heartbeats, want %d", len(queued), tt.queued)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package wakatime
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is returned when the API answers with a non-success status code.
+// It matches ErrUnauthorized for 401s and ErrInvalidStatusCode for everything else
+// so existing errors.Is checks keep working.
+type APIError struct {
+	// StatusCode is the HTTP status code the API answered with
+	StatusCode int
+	// Header holds the response headers
+	Header http.Header
+	// Body is the raw response body
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return fmt.Sprintf("%v: %s", ErrUnauthorized, e.Body)
+	}
+
+	return fmt.Sprintf("%v: status code %d, response: %s", ErrInvalidStatusCode, e.StatusCode, e.Body)
+}
+
+// Is lets errors.Is match an APIError against the client's sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrInvalidStatusCode:
+		return e.StatusCode != http.StatusUnauthorized
+	}
+
+	return false
+}
+
+// Temporary reports whether the request might succeed if it's tried again
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// RetryAfter returns how long the API asked us to wait before trying again, or 0
+func (e *APIError) RetryAfter() time.Duration {
+	value := e.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+
+	return 0
+}
+
+// RetryPolicy controls how the client retries requests that fail with a network
+// error, a 429 or a 5xx. Delays double after every attempt starting at BaseDelay
+// and are capped at MaxDelay, with jitter so many clients don't retry in lockstep.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a request is tried in total; 1 or less disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts including ones asked for with Retry-After
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy new clients start with
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// delay returns how long to wait before the given retry, 0 being the first
+func (p RetryPolicy) delay(retry int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if after := apiErr.RetryAfter(); after > 0 {
+			return min(after, p.MaxDelay)
+		}
+	}
+
+	backoff := p.BaseDelay << retry
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+
+	// equal jitter: wait at least half the backoff and a random amount of the rest
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// retryable reports whether a failed request is worth trying again
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	return errors.Is(err, ErrSendingRequest)
+}
+
+// do sends a request to the API, retrying according to the client's retry policy,
+// and returns the body of the first successful response
+func (c *Client) do(ctx context.Context, method string, url string, body []byte) ([]byte, error) {
+	attempts := max(c.Retry.MaxAttempts, 1)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Retry.delay(attempt-1, err)):
+			}
+		}
+
+		var respBody []byte
+		respBody, err = c.doOnce(ctx, method, url, body)
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return respBody, err
+		}
+	}
+
+	return nil, err
+}
+
+// doOnce makes a single attempt at a request
+func (c *Client) doOnce(ctx context.Context, method string, url string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCreatingRequest, err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.APIKey)))
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		// a cancelled request didn't fail to send so don't let it look like we're offline
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrSendingRequest, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}
+	}
+
+	return respBody, nil
+}
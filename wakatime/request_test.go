@@ -0,0 +1,68 @@
+package wakatime_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+	"github.com/taciturnaxolotl/akami/wakatime/wakatimetest"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failure  wakatimetest.Failure
+		failures int
+		requests int
+		err      error
+	}{
+		{name: "no failures", requests: 1},
+		{name: "server error once", failure: wakatimetest.FailureServerError, failures: 1, requests: 2},
+		{name: "server error twice", failure: wakatimetest.FailureServerError, failures: 2, requests: 3},
+		{name: "server error every time", failure: wakatimetest.FailureServerError, failures: 3, requests: 3, err: wakatime.ErrInvalidStatusCode},
+		{name: "rate limited once", failure: wakatimetest.FailureRateLimited, failures: 1, requests: 2},
+		{name: "unauthorized isn't retried", failure: wakatimetest.FailureUnauthorized, failures: 3, requests: 1, err: wakatime.ErrUnauthorized},
+		{name: "malformed isn't retried", failure: wakatimetest.FailureMalformed, failures: 3, requests: 1, err: wakatime.ErrDecodingResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := wakatimetest.NewServer()
+			defer server.Close()
+			if tt.failures > 0 {
+				server.FailNext(tt.failure, tt.failures)
+			}
+
+			client := server.Client()
+			client.Retry = fastRetry
+
+			_, err := client.GetStatusBar()
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if got := len(server.Requests()); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	server := wakatimetest.NewServer(wakatimetest.WithFailure(wakatimetest.FailureServerError))
+	defer server.Close()
+
+	client := server.Client()
+	client.Retry = wakatime.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetStatusBarContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline to be exceeded", err)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
@@ -0,0 +1,109 @@
+package wakatime
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Ranges the stats endpoint precomputes
+const (
+	RangeLast7Days   = "last_7_days"
+	RangeLast30Days  = "last_30_days"
+	RangeLast6Months = "last_6_months"
+	RangeLastYear    = "last_year"
+	RangeAllTime     = "all_time"
+)
+
+// StatsRanges lists every range GetStats can be asked for
+var StatsRanges = []string{RangeLast7Days, RangeLast30Days, RangeLast6Months, RangeLastYear, RangeAllTime}
+
+// BestDay is the day with the most coding activity in a stats range
+type BestDay struct {
+	// Date is the day as YYYY-MM-DD
+	Date string `json:"date"`
+	// TotalSeconds is the time spent coding that day in seconds
+	TotalSeconds float64 `json:"total_seconds"`
+	// Text is the human-readable representation of the time spent
+	Text string `json:"text"`
+}
+
+// Stats is a user's coding activity over one of the precomputed stats ranges.
+type Stats struct {
+	// Range is the name of the range such as last_7_days
+	Range string `json:"range"`
+	// HumanReadableRange describes the range like "Last 7 Days"
+	HumanReadableRange string `json:"human_readable_range"`
+	// Start is when the range starts as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is when the range ends as an ISO 8601 timestamp
+	End string `json:"end"`
+	// Timezone is the timezone the range was calculated in
+	Timezone string `json:"timezone"`
+	// IsUpToDate is false while the server is still calculating the stats
+	IsUpToDate bool `json:"is_up_to_date"`
+	// TotalSeconds is the total time spent coding in seconds
+	TotalSeconds float64 `json:"total_seconds"`
+	// HumanReadableTotal is the human-readable representation of the total coding time
+	HumanReadableTotal string `json:"human_readable_total"`
+	// DailyAverage is the average time spent coding per day in seconds
+	DailyAverage float64 `json:"daily_average"`
+	// HumanReadableDailyAverage is the human-readable representation of the daily average
+	HumanReadableDailyAverage string `json:"human_readable_daily_average"`
+	// DaysIncludingHolidays is how many days the range covers
+	DaysIncludingHolidays int `json:"days_including_holidays"`
+	// DaysMinusHolidays is how many days in the range had any coding activity
+	DaysMinusHolidays int `json:"days_minus_holidays"`
+	// BestDay is the day in the range with the most coding activity
+	BestDay BestDay `json:"best_day"`
+	// Languages is a list of programming languages used with statistics
+	Languages []StatItem `json:"languages"`
+	// Editors is a list of editors used with statistics
+	Editors []StatItem `json:"editors"`
+	// Projects is a list of projects worked on with statistics
+	Projects []StatItem `json:"projects"`
+	// OperatingSystems is a list of operating systems coded on with statistics
+	OperatingSystems []StatItem `json:"operating_systems"`
+	// Machines is a list of machines coded on with statistics
+	Machines []StatItem `json:"machines"`
+	// Categories is a list of activity categories such as coding or debugging with statistics
+	Categories []StatItem `json:"categories"`
+	// Dependencies is a list of libraries and packages used with statistics
+	Dependencies []StatItem `json:"dependencies"`
+}
+
+// StatsResponse represents the response from the WakaTime stats endpoint.
+type StatsResponse struct {
+	// Data contains the coding statistics for the range
+	Data Stats `json:"data"`
+}
+
+// Last7DaysResponse is the StatsResponse for the last 7 days.
+type Last7DaysResponse = StatsResponse
+
+// GetStats retrieves a user's coding activity over a range such as RangeLast30Days from the WakaTime API.
+// It returns an error if the request fails or returns a non-success status code.
+func (c *Client) GetStats(rangeName string) (StatsResponse, error) {
+	return c.GetStatsContext(context.Background(), rangeName)
+}
+
+// GetStatsContext is GetStats with a context that can cancel the request and its retries.
+func (c *Client) GetStatsContext(ctx context.Context, rangeName string) (StatsResponse, error) {
+	var statsResp StatsResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/stats/%s", c.APIURL, url.PathEscape(rangeName)), &statsResp); err != nil {
+		return StatsResponse{}, err
+	}
+
+	return statsResp, nil
+}
+
+// GetLast7Days retrieves a user's coding activity summary for the past 7 days from the WakaTime API.
+// It returns a Last7DaysResponse and an error if the request fails or returns a non-success status code.
+func (c *Client) GetLast7Days() (Last7DaysResponse, error) {
+	return c.GetStats(RangeLast7Days)
+}
+
+// GetLast7DaysContext is GetLast7Days with a context that can cancel the request and its retries.
+func (c *Client) GetLast7DaysContext(ctx context.Context) (Last7DaysResponse, error) {
+	return c.GetStatsContext(ctx, RangeLast7Days)
+}
@@ -0,0 +1,186 @@
+package wakatime
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/utils"
+)
+
+// DateFormat is the layout the API uses for dates
+const DateFormat = "2006-01-02"
+
+// SummariesOptions narrows down a summaries request
+type SummariesOptions struct {
+	// Project only counts time spent in this project
+	Project string
+	// Branches only counts time spent on these branches; it needs Project to be set
+	Branches []string
+	// Timezone is the IANA timezone days are split in; the user's own timezone is used when empty
+	Timezone string
+}
+
+// SummaryTotal is the time spent over a summary
+type SummaryTotal struct {
+	// TotalSeconds is the time spent in seconds
+	TotalSeconds float64 `json:"total_seconds"`
+	// Text is the human-readable representation of the time spent
+	Text string `json:"text"`
+	// Digital is the time spent formatted like a clock such as 3:42
+	Digital string `json:"digital"`
+}
+
+// SummaryRange is the period a summary covers
+type SummaryRange struct {
+	// Date is the day the summary is for
+	Date string `json:"date"`
+	// Start is when the day starts as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is when the day ends as an ISO 8601 timestamp
+	End string `json:"end"`
+	// Text is a human-readable description of the day like "Mon Jan 1st 2024"
+	Text string `json:"text"`
+	// Timezone is the timezone the day was split in
+	Timezone string `json:"timezone"`
+}
+
+// Summary is the breakdown of a single day's coding activity
+type Summary struct {
+	// GrandTotal is the time spent over the whole day
+	GrandTotal SummaryTotal `json:"grand_total"`
+	// Range is the day this summary covers
+	Range SummaryRange `json:"range"`
+	// Projects is the time spent in each project
+	Projects []StatItem `json:"projects"`
+	// Languages is the time spent in each language
+	Languages []StatItem `json:"languages"`
+	// Editors is the time spent in each editor
+	Editors []StatItem `json:"editors"`
+	// OperatingSystems is the time spent on each operating system
+	OperatingSystems []StatItem `json:"operating_systems"`
+	// Machines is the time spent on each machine
+	Machines []StatItem `json:"machines"`
+	// Branches is the time spent on each branch; only set when filtering by project
+	Branches []StatItem `json:"branches"`
+	// Entities is the time spent in each file; only set when filtering by project
+	Entities []StatItem `json:"entities"`
+	// Categories is the time spent on each kind of activity such as coding or debugging
+	Categories []StatItem `json:"categories"`
+	// Dependencies is the time spent with each library or package in use
+	Dependencies []StatItem `json:"dependencies"`
+}
+
+// SummariesResponse represents the response from the WakaTime summaries endpoint
+type SummariesResponse struct {
+	// Data holds one summary per day in the requested range
+	Data []Summary `json:"data"`
+	// CumulativeTotal is the time spent over the whole range
+	CumulativeTotal struct {
+		// Seconds is the total time in seconds
+		Seconds float64 `json:"seconds"`
+		// Text is the human-readable representation of the total
+		Text string `json:"text"`
+	} `json:"cumulative_total"`
+	// DailyAverage is the average time spent per day over the range
+	DailyAverage struct {
+		// Seconds is the daily average in seconds
+		Seconds float64 `json:"seconds"`
+		// Text is the human-readable representation of the daily average
+		Text string `json:"text"`
+	} `json:"daily_average"`
+	// Start is the start of the range as an ISO 8601 timestamp
+	Start string `json:"start"`
+	// End is the end of the range as an ISO 8601 timestamp
+	End string `json:"end"`
+}
+
+// Total merges every day of the response into a single summary covering the whole range
+func (r SummariesResponse) Total() Summary {
+	var total Summary
+	for _, day := range r.Data {
+		total.GrandTotal.TotalSeconds += day.GrandTotal.TotalSeconds
+	}
+
+	total.GrandTotal.Text = utils.PrettyPrintTime(int(total.GrandTotal.TotalSeconds))
+
+	merge := func(pick func(Summary) []StatItem) []StatItem {
+		seconds := map[string]float64{}
+		for _, day := range r.Data {
+			for _, item := range pick(day) {
+				seconds[item.Name] += item.TotalSeconds
+			}
+		}
+		return rankStatItems(seconds, total.GrandTotal.TotalSeconds)
+	}
+
+	total.Projects = merge(func(s Summary) []StatItem { return s.Projects })
+	total.Languages = merge(func(s Summary) []StatItem { return s.Languages })
+	total.Editors = merge(func(s Summary) []StatItem { return s.Editors })
+	total.OperatingSystems = merge(func(s Summary) []StatItem { return s.OperatingSystems })
+	total.Machines = merge(func(s Summary) []StatItem { return s.Machines })
+	total.Branches = merge(func(s Summary) []StatItem { return s.Branches })
+	total.Entities = merge(func(s Summary) []StatItem { return s.Entities })
+	total.Categories = merge(func(s Summary) []StatItem { return s.Categories })
+	total.Dependencies = merge(func(s Summary) []StatItem { return s.Dependencies })
+
+	if len(r.Data) > 0 {
+		total.Range.Start = r.Data[0].Range.Start
+		total.Range.End = r.Data[len(r.Data)-1].Range.End
+	}
+
+	return total
+}
+
+// rankStatItems turns per-name seconds into stat items sorted by time spent
+func rankStatItems(seconds map[string]float64, total float64) []StatItem {
+	items := make([]StatItem, 0, len(seconds))
+	for name, secs := range seconds {
+		item := StatItem{Name: name, TotalSeconds: secs, Text: utils.PrettyPrintTime(int(secs))}
+		if total > 0 {
+			item.Percent = secs / total * 100
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TotalSeconds != items[j].TotalSeconds {
+			return items[i].TotalSeconds > items[j].TotalSeconds
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
+
+// GetSummaries retrieves a per-day breakdown of a user's coding activity between
+// start and end inclusive from the WakaTime API.
+func (c *Client) GetSummaries(start time.Time, end time.Time, opts SummariesOptions) (SummariesResponse, error) {
+	return c.GetSummariesContext(context.Background(), start, end, opts)
+}
+
+// GetSummariesContext is GetSummaries with a context that can cancel the request and its retries.
+func (c *Client) GetSummariesContext(ctx context.Context, start time.Time, end time.Time, opts SummariesOptions) (SummariesResponse, error) {
+	query := url.Values{}
+	query.Set("start", start.Format(DateFormat))
+	query.Set("end", end.Format(DateFormat))
+	if opts.Project != "" {
+		query.Set("project", opts.Project)
+	}
+	if len(opts.Branches) > 0 {
+		query.Set("branches", strings.Join(opts.Branches, ","))
+	}
+	if opts.Timezone != "" {
+		query.Set("timezone", opts.Timezone)
+	}
+
+	var summariesResp SummariesResponse
+	if err := c.get(ctx, fmt.Sprintf("%s/users/current/summaries?%s", c.APIURL, query.Encode()), &summariesResp); err != nil {
+		return SummariesResponse{}, err
+	}
+
+	return summariesResp, nil
+}
@@ -0,0 +1,81 @@
+package wakatimetest
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// demoFile is a made up file and the language it's written in
+type demoFile struct {
+	path     string
+	language string
+}
+
+// demoProjects are the made up projects demo heartbeats are spread over
+var demoProjects = map[string][]demoFile{
+	"akami":     {{"main.go", "Go"}, {"handler/main.go", "Go"}, {"wakatime/main.go", "Go"}, {"README.md", "Markdown"}, {"flake.nix", "Nix"}},
+	"hackatime": {{"app/models/heartbeat.rb", "Ruby"}, {"app/controllers/api_controller.rb", "Ruby"}, {"app/javascript/app.js", "JavaScript"}, {"app/views/home.html.erb", "ERB"}},
+	"dotfiles":  {{"home.nix", "Nix"}, {"nvim/init.lua", "Lua"}, {"scripts/setup.sh", "Bash"}},
+}
+
+// demoProjectNames keeps the order projects are picked in stable
+var demoProjectNames = []string{"akami", "hackatime", "dotfiles"}
+
+// demoBranches are the branches sessions in each project happen on, with main the most likely
+var demoBranches = map[string][]string{
+	"akami":     {"main", "main", "feat/git-report", "fix/prompt-cache"},
+	"hackatime": {"main", "main", "goals-api"},
+	"dotfiles":  {"main"},
+}
+
+// demoEditors are the editors demo heartbeats claim to come from
+var demoEditors = []string{"neovim", "neovim", "vscode", "zed"}
+
+// demoPlatforms are the platforms demo heartbeats claim to come from
+var demoPlatforms = []string{"linux-x86_64", "linux-x86_64", "darwin-arm64"}
+
+// DemoHeartbeats makes a believable week of coding activity ending at now, so the
+// mock server has something to show in akami status straight away. The same seed
+// always gives the same heartbeats.
+func DemoHeartbeats(now time.Time, days int, seed int64) []wakatime.Heartbeat {
+	rng := rand.New(rand.NewSource(seed))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var hbs []wakatime.Heartbeat
+	for day := days - 1; day >= 0; day-- {
+		date := today.AddDate(0, 0, -day)
+
+		// a few sessions a day starting somewhere between 9am and 9pm
+		for range 1 + rng.Intn(3) {
+			project := demoProjectNames[rng.Intn(len(demoProjectNames))]
+			editor := demoEditors[rng.Intn(len(demoEditors))]
+			branch := demoBranches[project][rng.Intn(len(demoBranches[project]))]
+			userAgent := fmt.Sprintf("wakatime/v1.102.1 (%s) go1.24.0 %s/1.0.0", demoPlatforms[rng.Intn(len(demoPlatforms))], editor)
+			at := date.Add(time.Duration(9+rng.Intn(12))*time.Hour + time.Duration(rng.Intn(60))*time.Minute)
+			end := at.Add(time.Duration(20+rng.Intn(90)) * time.Minute)
+
+			for ; at.Before(end) && at.Before(now); at = at.Add(time.Duration(30+rng.Intn(150)) * time.Second) {
+				file := demoProjects[project][rng.Intn(len(demoProjects[project]))]
+
+				hbs = append(hbs, wakatime.Heartbeat{
+					Entity:     fmt.Sprintf("/home/akami/code/%s/%s", project, file.path),
+					Type:       "file",
+					Time:       float64(at.UnixNano()) / float64(time.Second),
+					Project:    project,
+					Language:   file.language,
+					IsWrite:    rng.Intn(3) == 0,
+					EditorName: editor,
+					Branch:     branch,
+					Category:   "coding",
+					LineNo:     1 + rng.Intn(400),
+					UserAgent:  userAgent,
+				})
+			}
+		}
+	}
+
+	return hbs
+}
@@ -0,0 +1,148 @@
+package wakatimetest
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// day parses the date query parameter into the start and end of that day
+func (s *Server) day(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	start, err := time.ParseInLocation(wakatime.DateFormat, r.URL.Query().Get("date"), s.now().Location())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "date should be a date like 2006-01-02"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, start.AddDate(0, 0, 1), true
+}
+
+// dayHeartbeats returns the heartbeats in [start, end) sorted by time
+func (s *Server) dayHeartbeats(start time.Time, end time.Time) []wakatime.Heartbeat {
+	s.mu.Lock()
+	var hbs []wakatime.Heartbeat
+	for _, hb := range s.heartbeats {
+		if at := unix(hb.Time); !at.Before(start) && at.Before(end) {
+			hbs = append(hbs, hb)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(hbs, func(i, j int) bool { return hbs[i].Time < hbs[j].Time })
+	return hbs
+}
+
+// listHeartbeats handles GET /users/current/heartbeats
+func (s *Server) listHeartbeats(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := s.day(w, r)
+	if !ok {
+		return
+	}
+
+	data := []map[string]any{}
+	for i, hb := range s.dayHeartbeats(start, end) {
+		data = append(data, map[string]any{
+			"id":          i + 1,
+			"entity":      hb.Entity,
+			"type":        hb.Type,
+			"time":        hb.Time,
+			"project":     hb.Project,
+			"language":    hb.Language,
+			"branch":      hb.Branch,
+			"category":    hb.Category,
+			"is_write":    hb.IsWrite,
+			"editor_name": hb.EditorName,
+			"created_at":  unix(hb.Time).UTC().Format(time.RFC3339),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     data,
+		"start":    start.Format(time.RFC3339),
+		"end":      end.Add(-time.Second).Format(time.RFC3339),
+		"timezone": start.Location().String(),
+	})
+}
+
+// durations handles GET /users/current/durations. Heartbeats are joined into a
+// duration while they're less than the keystroke timeout apart and stay in the
+// same project and slice; the gap before a switch counts towards the earlier one.
+func (s *Server) durations(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := s.day(w, r)
+	if !ok {
+		return
+	}
+	project := r.URL.Query().Get("project")
+	sliceBy := wakatime.SliceBy(r.URL.Query().Get("slice_by"))
+	onlyBranches := map[string]bool{}
+	if list := r.URL.Query().Get("branches"); list != "" {
+		for _, branch := range strings.Split(list, ",") {
+			onlyBranches[branch] = true
+		}
+	}
+
+	var durations []wakatime.Duration
+	var last float64
+	branches := map[string]bool{}
+	for _, hb := range s.dayHeartbeats(start, end) {
+		if project != "" && hb.Project != project {
+			continue
+		}
+		if hb.Branch != "" {
+			branches[hb.Branch] = true
+		}
+		if len(onlyBranches) > 0 && !onlyBranches[hb.Branch] {
+			continue
+		}
+
+		next := wakatime.Duration{Project: orUnknown(hb.Project), Time: hb.Time}
+		switch sliceBy {
+		case wakatime.SliceByEntity:
+			next.Entity = hb.Entity
+		case wakatime.SliceByLanguage:
+			next.Language = orUnknown(hb.Language)
+		case wakatime.SliceByEditor:
+			next.Editor = orUnknown(hb.EditorName)
+		case wakatime.SliceByCategory:
+			next.Category = orUnknown(hb.Category)
+		}
+
+		if n := len(durations); n > 0 && hb.Time-last <= keystrokeTimeout.Seconds() {
+			current := &durations[n-1]
+			current.Duration = hb.Time - current.Time
+			if sameSlice(*current, next) {
+				last = hb.Time
+				continue
+			}
+		}
+
+		durations = append(durations, next)
+		last = hb.Time
+	}
+	if durations == nil {
+		durations = []wakatime.Duration{}
+	}
+
+	branchNames := []string{}
+	for branch := range branches {
+		branchNames = append(branchNames, branch)
+	}
+	sort.Strings(branchNames)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     durations,
+		"branches": branchNames,
+		"start":    start.Format(time.RFC3339),
+		"end":      end.Add(-time.Second).Format(time.RFC3339),
+		"timezone": start.Location().String(),
+	})
+}
+
+// sameSlice reports whether two durations belong to the same project and slice
+func sameSlice(a wakatime.Duration, b wakatime.Duration) bool {
+	return a.Project == b.Project && a.Entity == b.Entity && a.Language == b.Language &&
+		a.Editor == b.Editor && a.Category == b.Category && strings.Join(a.Dependencies, ",") == strings.Join(b.Dependencies, ",")
+}
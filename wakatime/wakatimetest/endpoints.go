@@ -0,0 +1,365 @@
+package wakatimetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// keystrokeTimeout is the longest gap between heartbeats that still counts as coding,
+// which is the default wakatime and hackatime both use
+const keystrokeTimeout = 15 * time.Minute
+
+// heartbeat handles POST /users/current/heartbeats
+func (s *Server) heartbeat(w http.ResponseWriter, r *http.Request) {
+	var hb wakatime.Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid heartbeat: " + err.Error()})
+		return
+	}
+
+	body, status := s.accept(hb)
+	writeJSON(w, status, body)
+}
+
+// bulkHeartbeats handles POST /users/current/heartbeats.bulk, answering every
+// heartbeat with a [body, status] pair like the real api
+func (s *Server) bulkHeartbeats(w http.ResponseWriter, r *http.Request) {
+	var hbs []wakatime.Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hbs); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid heartbeats: " + err.Error()})
+		return
+	}
+	if len(hbs) > wakatime.MaxBulkHeartbeats {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("at most %d heartbeats can be sent at once", wakatime.MaxBulkHeartbeats)})
+		return
+	}
+
+	responses := make([][]any, 0, len(hbs))
+	for _, hb := range hbs {
+		body, status := s.accept(hb)
+		responses = append(responses, []any{body, status})
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"responses": responses})
+}
+
+// accept validates and stores a heartbeat returning the body and status to answer with
+func (s *Server) accept(hb wakatime.Heartbeat) (any, int) {
+	if hb.Entity == "" {
+		return map[string]string{"error": "entity is required"}, http.StatusBadRequest
+	}
+	if hb.Time <= 0 {
+		return map[string]string{"error": "time is required"}, http.StatusBadRequest
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("mock-%d", s.nextID)
+	s.heartbeats = append(s.heartbeats, hb)
+	s.mu.Unlock()
+
+	return map[string]any{"data": map[string]any{
+		"id":     id,
+		"entity": hb.Entity,
+		"type":   hb.Type,
+		"time":   hb.Time,
+	}}, http.StatusCreated
+}
+
+// statusBar handles GET /users/current/statusbar/today
+func (s *Server) statusBar(w http.ResponseWriter) {
+	now := s.now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	totals := s.totals(start, now, "")
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"grand_total": map[string]any{
+			"text":          humanize(totals.total),
+			"total_seconds": int(totals.total),
+		},
+	}})
+}
+
+// statsRangeStart returns the first day a stats range covers or false for ranges
+// the real api doesn't have
+func (s *Server) statsRangeStart(rangeName string, today time.Time) (time.Time, bool) {
+	switch rangeName {
+	case wakatime.RangeLast7Days:
+		return today.AddDate(0, 0, -6), true
+	case wakatime.RangeLast30Days:
+		return today.AddDate(0, 0, -29), true
+	case wakatime.RangeLast6Months:
+		return today.AddDate(0, -6, 1), true
+	case wakatime.RangeLastYear:
+		return today.AddDate(-1, 0, 1), true
+	case wakatime.RangeAllTime:
+		start := today
+		s.mu.Lock()
+		for _, hb := range s.heartbeats {
+			if at := unix(hb.Time); at.Before(start) {
+				start = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, today.Location())
+			}
+		}
+		s.mu.Unlock()
+		return start, true
+	}
+
+	return time.Time{}, false
+}
+
+// stats handles GET /users/current/stats/{range}
+func (s *Server) stats(w http.ResponseWriter, rangeName string) {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start, ok := s.statsRangeStart(rangeName, today)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Not found"})
+		return
+	}
+
+	totals := s.totals(start, now, "")
+
+	days, active := 0, 0
+	best := map[string]any{"date": "", "total_seconds": 0.0, "text": humanize(0)}
+	bestSeconds := 0.0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		days++
+		seconds := s.totals(day, day.AddDate(0, 0, 1), "").total
+		if seconds > 0 {
+			active++
+		}
+		if seconds > bestSeconds {
+			bestSeconds = seconds
+			best = map[string]any{"date": day.Format(wakatime.DateFormat), "total_seconds": seconds, "text": humanize(seconds)}
+		}
+	}
+	average := totals.total / float64(days)
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"range":                        rangeName,
+		"human_readable_range":         strings.ReplaceAll(rangeName, "_", " "),
+		"start":                        start.Format(time.RFC3339),
+		"end":                          today.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339),
+		"timezone":                     now.Location().String(),
+		"is_up_to_date":                true,
+		"total_seconds":                totals.total,
+		"human_readable_total":         humanize(totals.total),
+		"daily_average":                average,
+		"human_readable_daily_average": humanize(average),
+		"days_including_holidays":      days,
+		"days_minus_holidays":          active,
+		"best_day":                     best,
+		"languages":                    statItems(totals.languages, totals.total),
+		"editors":                      statItems(totals.editors, totals.total),
+		"projects":                     statItems(totals.projects, totals.total),
+		"operating_systems":            statItems(totals.operatingSystems, totals.total),
+		"machines":                     statItems(totals.machines, totals.total),
+		"categories":                   statItems(totals.categories, totals.total),
+		"dependencies":                 statItems(totals.dependencies, totals.total),
+	}})
+}
+
+// summaries handles GET /users/current/summaries with one summary per day between
+// the start and end query parameters
+func (s *Server) summaries(w http.ResponseWriter, r *http.Request) {
+	loc := s.now().Location()
+	start, err := time.ParseInLocation(wakatime.DateFormat, r.URL.Query().Get("start"), loc)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "start should be a date like 2006-01-02"})
+		return
+	}
+	end, err := time.ParseInLocation(wakatime.DateFormat, r.URL.Query().Get("end"), loc)
+	if err != nil || end.Before(start) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "end should be a date like 2006-01-02 on or after start"})
+		return
+	}
+
+	project := r.URL.Query().Get("project")
+	days := []map[string]any{}
+	cumulative := 0.0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		t := s.totals(day, next, project)
+		cumulative += t.total
+
+		summary := map[string]any{
+			"grand_total": map[string]any{"total_seconds": t.total, "text": humanize(t.total), "digital": digital(t.total)},
+			"range": map[string]any{
+				"date":     day.Format(wakatime.DateFormat),
+				"start":    day.Format(time.RFC3339),
+				"end":      next.Add(-time.Second).Format(time.RFC3339),
+				"text":     day.Format("Mon Jan 2 2006"),
+				"timezone": loc.String(),
+			},
+			"projects":          statItems(t.projects, t.total),
+			"languages":         statItems(t.languages, t.total),
+			"editors":           statItems(t.editors, t.total),
+			"categories":        statItems(t.categories, t.total),
+			"operating_systems": statItems(t.operatingSystems, t.total),
+			"machines":          statItems(t.machines, t.total),
+			"dependencies":      statItems(t.dependencies, t.total),
+		}
+		// like the real api branches and files are only broken down for a single project
+		if project != "" {
+			summary["branches"] = statItems(t.branches, t.total)
+			summary["entities"] = statItems(t.entities, t.total)
+		}
+		days = append(days, summary)
+	}
+
+	average := cumulative / float64(len(days))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":             days,
+		"cumulative_total": map[string]any{"seconds": cumulative, "text": humanize(cumulative)},
+		"daily_average":    map[string]any{"seconds": average, "text": humanize(average)},
+		"start":            start.Format(time.RFC3339),
+		"end":              end.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339),
+	})
+}
+
+// totals is coding time between two points broken down the ways the stats endpoints need
+type totals struct {
+	total            float64
+	projects         map[string]float64
+	languages        map[string]float64
+	editors          map[string]float64
+	branches         map[string]float64
+	entities         map[string]float64
+	categories       map[string]float64
+	operatingSystems map[string]float64
+	machines         map[string]float64
+	dependencies     map[string]float64
+}
+
+// totals adds up the time between consecutive heartbeats in [start, end), only
+// counting heartbeats in project when it's set. Each gap shorter than the keystroke
+// timeout counts towards the earlier heartbeat.
+func (s *Server) totals(start time.Time, end time.Time, project string) totals {
+	s.mu.Lock()
+	var hbs []wakatime.Heartbeat
+	for _, hb := range s.heartbeats {
+		at := unix(hb.Time)
+		if !at.Before(start) && at.Before(end) && (project == "" || hb.Project == project) {
+			hbs = append(hbs, hb)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(hbs, func(i, j int) bool { return hbs[i].Time < hbs[j].Time })
+
+	t := totals{
+		projects:         map[string]float64{},
+		languages:        map[string]float64{},
+		editors:          map[string]float64{},
+		branches:         map[string]float64{},
+		entities:         map[string]float64{},
+		categories:       map[string]float64{},
+		operatingSystems: map[string]float64{},
+		machines:         map[string]float64{},
+		dependencies:     map[string]float64{},
+	}
+	for i := 0; i+1 < len(hbs); i++ {
+		gap := hbs[i+1].Time - hbs[i].Time
+		if gap <= 0 || gap > keystrokeTimeout.Seconds() {
+			continue
+		}
+
+		t.total += gap
+		t.projects[orUnknown(hbs[i].Project)] += gap
+		t.languages[orUnknown(hbs[i].Language)] += gap
+		t.editors[orUnknown(hbs[i].EditorName)] += gap
+		t.branches[orUnknown(hbs[i].Branch)] += gap
+		t.entities[hbs[i].Entity] += gap
+		t.categories[orUnknown(hbs[i].Category)] += gap
+		t.operatingSystems[operatingSystem(hbs[i].UserAgent)] += gap
+		// heartbeats don't say which machine they're from so they all share one
+		t.machines[orUnknown("")] += gap
+		for _, dep := range hbs[i].Dependencies {
+			t.dependencies[dep] += gap
+		}
+	}
+
+	return t
+}
+
+// statItems turns a breakdown into stat items sorted by time spent
+func statItems(breakdown map[string]float64, total float64) []wakatime.StatItem {
+	items := make([]wakatime.StatItem, 0, len(breakdown))
+	for name, seconds := range breakdown {
+		item := wakatime.StatItem{Name: name, TotalSeconds: seconds, Text: humanize(seconds)}
+		if total > 0 {
+			item.Percent = seconds / total * 100
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TotalSeconds != items[j].TotalSeconds {
+			return items[i].TotalSeconds > items[j].TotalSeconds
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
+
+// humanize formats seconds the way the api's text fields do, like "3 hrs 42 mins"
+func humanize(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	if hours == 0 {
+		return fmt.Sprintf("%d mins", minutes)
+	}
+
+	return fmt.Sprintf("%d hrs %d mins", hours, minutes)
+}
+
+// digital formats seconds like a clock such as 3:42
+func digital(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// orUnknown names empty attributes the way the api does
+func orUnknown(name string) string {
+	if name == "" {
+		return "Unknown"
+	}
+
+	return name
+}
+
+// operatingSystem works out the operating system from a wakatime user agent like
+// "wakatime/v1.90.0 (linux-x86_64) go1.22 neovim/0.10.0" the way the api does
+func operatingSystem(userAgent string) string {
+	_, platform, ok := strings.Cut(userAgent, "(")
+	if !ok {
+		return orUnknown("")
+	}
+
+	switch goos, _, _ := strings.Cut(platform, "-"); goos {
+	case "linux":
+		return "Linux"
+	case "darwin":
+		return "Mac"
+	case "windows":
+		return "Windows"
+	case "":
+		return orUnknown("")
+	default:
+		return goos
+	}
+}
+
+// unix converts a heartbeat timestamp to a time
+func unix(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
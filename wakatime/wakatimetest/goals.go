@@ -0,0 +1,138 @@
+package wakatimetest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// goalPeriods is how many days or weeks of history the goals endpoint answers with
+const goalPeriods = 7
+
+// WithGoals makes the server support the goals endpoints and answer them with these
+// goals, filling in their history from the heartbeats it has. Without it the goals
+// endpoints 404 like they do on hackatime.
+func WithGoals(goals ...wakatime.Goal) Option {
+	return func(s *Server) { s.goals = append(s.goals, goals...) }
+}
+
+// DemoGoals are a daily and a weekly goal that DemoHeartbeats meets some of the time
+func DemoGoals() []wakatime.Goal {
+	return []wakatime.Goal{
+		{ID: "demo-daily", Title: "Code 1 hr per day", Delta: wakatime.GoalDaily, Seconds: time.Hour.Seconds(), IsEnabled: true},
+		{ID: "demo-weekly", Title: "Code 3 hrs of Go per week", Delta: wakatime.GoalWeekly, Seconds: 3 * time.Hour.Seconds(), Languages: []string{"Go"}, IsEnabled: true},
+	}
+}
+
+// goalsList handles GET /users/current/goals
+func (s *Server) goalsList(w http.ResponseWriter) {
+	s.mu.Lock()
+	goals := s.goals
+	s.mu.Unlock()
+
+	if goals == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Not found"})
+		return
+	}
+
+	data := make([]wakatime.Goal, 0, len(goals))
+	for _, goal := range goals {
+		data = append(data, s.goalHistory(goal))
+	}
+
+	writeJSON(w, http.StatusOK, wakatime.GoalsResponse{Data: data, Total: len(data), TotalPages: 1})
+}
+
+// goal handles GET /users/current/goals/{id}
+func (s *Server) goal(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	goals := s.goals
+	s.mu.Unlock()
+
+	for _, goal := range goals {
+		if string(goal.ID) == id {
+			writeJSON(w, http.StatusOK, wakatime.GoalResponse{Data: s.goalHistory(goal)})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "Not found"})
+}
+
+// goalHistory fills in a goal's chart data and status from the heartbeats
+func (s *Server) goalHistory(goal wakatime.Goal) wakatime.Goal {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	days := 1
+	last := today
+	if goal.Delta == wakatime.GoalWeekly {
+		days = 7
+		last = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	}
+
+	goal.ChartData = []wakatime.GoalPeriod{}
+	for i := goalPeriods - 1; i >= 0; i-- {
+		start := last.AddDate(0, 0, -days*i)
+		end := start.AddDate(0, 0, days)
+
+		actual := s.goalSeconds(goal, start, end)
+		status := wakatime.GoalPending
+		switch {
+		case actual >= goal.Seconds:
+			status = wakatime.GoalSuccess
+		case !end.After(today):
+			status = wakatime.GoalFail
+		}
+
+		goal.ChartData = append(goal.ChartData, wakatime.GoalPeriod{
+			ActualSeconds:     actual,
+			ActualSecondsText: humanize(actual),
+			GoalSeconds:       goal.Seconds,
+			GoalSecondsText:   humanize(goal.Seconds),
+			Range: wakatime.GoalRange{
+				Date:     start.Format(wakatime.DateFormat),
+				Start:    start.Format(time.RFC3339),
+				End:      end.Add(-time.Second).Format(time.RFC3339),
+				Text:     start.Format("Mon Jan 2"),
+				Timezone: now.Location().String(),
+			},
+			RangeStatus:       status,
+			RangeStatusReason: fmt.Sprintf("coded %s of %s", humanize(actual), humanize(goal.Seconds)),
+		})
+	}
+
+	current := goal.ChartData[len(goal.ChartData)-1]
+	goal.Status = current.RangeStatus
+	if goal.Seconds > 0 {
+		goal.StatusPercentCalculated = min(current.ActualSeconds/goal.Seconds*100, 100)
+	}
+	goal.RangeText = strings.ToLower(fmt.Sprintf("last %d %ss", goalPeriods, goal.Delta))
+
+	return goal
+}
+
+// goalSeconds is the time in [start, end) that counts towards a goal
+func (s *Server) goalSeconds(goal wakatime.Goal, start time.Time, end time.Time) float64 {
+	projects := goal.Projects
+	if len(projects) == 0 {
+		projects = []string{""}
+	}
+
+	var seconds float64
+	for _, project := range projects {
+		t := s.totals(start, end, project)
+		if len(goal.Languages) == 0 {
+			seconds += t.total
+			continue
+		}
+		for _, language := range goal.Languages {
+			seconds += t.languages[language]
+		}
+	}
+
+	return seconds
+}
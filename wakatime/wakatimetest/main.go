@@ -0,0 +1,312 @@
+// Package wakatimetest provides an in-process fake of the hackatime/wakatime api for
+// exercising wakatime.Client and akami's handlers without a live service. It serves
+// the statusbar, stats, summaries, durations, heartbeats and bulk heartbeats endpoints
+// from the heartbeats it has been sent, optionally the goals endpoints too, and can be
+// told to fail in the ways real servers do.
+package wakatimetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taciturnaxolotl/akami/wakatime"
+)
+
+// Failure is a way the fake server can misbehave
+type Failure string
+
+// Supported failure modes
+const (
+	// FailureNone serves requests normally
+	FailureNone Failure = ""
+	// FailureUnauthorized rejects every request with a 401
+	FailureUnauthorized Failure = "401"
+	// FailureRateLimited answers every request with a 429 and a Retry-After of one second
+	FailureRateLimited Failure = "429"
+	// FailureServerError answers every request with a 500
+	FailureServerError Failure = "500"
+	// FailureSlow waits for the configured delay before answering normally
+	FailureSlow Failure = "slow"
+	// FailureMalformed answers with a 200 and a body that isn't valid json
+	FailureMalformed Failure = "malformed"
+)
+
+// Failures lists every failure mode that can be set
+var Failures = []Failure{FailureUnauthorized, FailureRateLimited, FailureServerError, FailureSlow, FailureMalformed}
+
+// ParseFailure turns the name of a failure mode into a Failure
+func ParseFailure(name string) (Failure, error) {
+	if name == "" || name == "none" {
+		return FailureNone, nil
+	}
+	if !slices.Contains(Failures, Failure(name)) {
+		return FailureNone, fmt.Errorf("unknown failure %q; pick one of none, 401, 429, 500, slow or malformed", name)
+	}
+
+	return Failure(name), nil
+}
+
+// DefaultAPIKey is the key the server accepts when no other key is set
+const DefaultAPIKey = "akami-mock-key"
+
+// DefaultDelay is how long FailureSlow waits before answering
+const DefaultDelay = 2 * time.Second
+
+// Request is a request the server received
+type Request struct {
+	Method string
+	Path   string
+	Status int
+}
+
+// Server is the fake api. It is an http.Handler so it can be mounted anywhere, and
+// NewServer starts it on a local port the way httptest does.
+type Server struct {
+	// URL is the api url to give clients once the server is started
+	URL string
+	// APIKey is the key requests have to authenticate with
+	APIKey string
+
+	mu         sync.Mutex
+	heartbeats []wakatime.Heartbeat
+	goals      []wakatime.Goal
+	requests   []Request
+	failure    Failure
+	failNext   int
+	nextID     int
+	delay      time.Duration
+	now        func() time.Time
+	log        io.Writer
+	httpServer *httptest.Server
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithAPIKey sets the key clients must authenticate with
+func WithAPIKey(key string) Option {
+	return func(s *Server) { s.APIKey = key }
+}
+
+// WithFailure makes every request fail in the given way
+func WithFailure(failure Failure) Option {
+	return func(s *Server) { s.failure = failure }
+}
+
+// WithDelay sets how long FailureSlow waits before answering
+func WithDelay(delay time.Duration) Option {
+	return func(s *Server) { s.delay = delay }
+}
+
+// WithHeartbeats preloads the server with heartbeats
+func WithHeartbeats(heartbeats ...wakatime.Heartbeat) Option {
+	return func(s *Server) { s.heartbeats = append(s.heartbeats, heartbeats...) }
+}
+
+// WithClock sets the clock used to work out what today and the last 7 days are
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) { s.now = now }
+}
+
+// WithRequestLog writes a line for every request the server answers
+func WithRequestLog(w io.Writer) Option {
+	return func(s *Server) { s.log = w }
+}
+
+// New creates a server without starting it
+func New(opts ...Option) *Server {
+	s := &Server{APIKey: DefaultAPIKey, delay: DefaultDelay, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NewServer creates and starts a server on a random local port; call Close when done
+func NewServer(opts ...Option) *Server {
+	s := New(opts...)
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL + "/api/hackatime/v1"
+
+	return s
+}
+
+// Close shuts down a server started with NewServer
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// Client returns a wakatime client pointed at the server
+func (s *Server) Client() *wakatime.Client {
+	return wakatime.NewClientWithOptions(s.APIKey, s.URL)
+}
+
+// SetFailure changes how every following request fails
+func (s *Server) SetFailure(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failure = failure
+	s.failNext = 0
+}
+
+// FailNext makes only the next n requests fail before going back to normal
+func (s *Server) FailNext(failure Failure, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failure = failure
+	s.failNext = n
+}
+
+// Heartbeats returns every heartbeat the server has accepted
+func (s *Server) Heartbeats() []wakatime.Heartbeat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.heartbeats)
+}
+
+// Requests returns every request the server has answered
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// ServeHTTP routes a request to the endpoint it's for. Routing only looks at the end
+// of the path so the server works under any api url prefix.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Status: rec.status})
+		s.mu.Unlock()
+
+		if s.log != nil {
+			fmt.Fprintf(s.log, "%s %s %s %d\n", time.Now().Format(time.TimeOnly), r.Method, r.URL.Path, rec.status)
+		}
+	}()
+
+	if s.fail(rec, r) {
+		return
+	}
+
+	if !s.authorized(r) {
+		writeJSON(rec, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return
+	}
+
+	path := strings.TrimRight(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/users/current/statusbar/today"):
+		s.statusBar(rec)
+	case r.Method == http.MethodGet && strings.Contains(path, "/users/current/stats/"):
+		s.stats(rec, path[strings.LastIndex(path, "/")+1:])
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/users/current/summaries"):
+		s.summaries(rec, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/users/current/durations"):
+		s.durations(rec, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/users/current/heartbeats"):
+		s.listHeartbeats(rec, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/users/current/goals"):
+		s.goalsList(rec)
+	case r.Method == http.MethodGet && strings.Contains(path, "/users/current/goals/"):
+		s.goal(rec, path[strings.LastIndex(path, "/")+1:])
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/users/current/heartbeats"):
+		s.heartbeat(rec, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/users/current/heartbeats.bulk"):
+		s.bulkHeartbeats(rec, r)
+	default:
+		writeJSON(rec, http.StatusNotFound, map[string]string{"error": "Not found"})
+	}
+}
+
+// fail applies the current failure mode and reports whether the request was answered
+func (s *Server) fail(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	failure, delay := s.failure, s.delay
+	if s.failNext > 0 {
+		s.failNext--
+		if s.failNext == 0 {
+			s.failure = FailureNone
+		}
+	}
+	s.mu.Unlock()
+
+	switch failure {
+	case FailureUnauthorized:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return true
+	case FailureRateLimited:
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Too many requests"})
+		return true
+	case FailureServerError:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return true
+	case FailureMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"data": {"grand_total": `)
+		return true
+	case FailureSlow:
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorized checks the request carries the server's api key. Like the real apis it
+// accepts the key as basic auth, a bearer token or the api_key query parameter.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.APIKey == "" {
+		return true
+	}
+
+	auth := r.Header.Get("Authorization")
+	if encoded, ok := strings.CutPrefix(auth, "Basic "); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		// wakatime-cli sends the key as the username with an empty password
+		return err == nil && strings.TrimSuffix(string(decoded), ":") == s.APIKey
+	}
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		return token == s.APIKey
+	}
+
+	return r.URL.Query().Get("api_key") == s.APIKey
+}
+
+// statusRecorder remembers the status code written so it can be logged
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// writeJSON writes v as the json body of a response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}